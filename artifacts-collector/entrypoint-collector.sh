@@ -21,6 +21,9 @@ os_log_info "Starting plugin..."
 sig_handler_save_results() {
     os_log_info "Saving results triggered. Slowing down..."
     sleep 5
+    if [[ -n "${ARTIFACT_SERVER_PID:-}" ]]; then
+        kill "${ARTIFACT_SERVER_PID}" || true
+    fi
 
     pushd "${RESULTS_DIR}" || exit 1
 
@@ -45,6 +48,12 @@ ${UTIL_OC_BIN} login "${KUBE_API_INT}" \
     --token="$(cat "${SA_TOKEN_PATH}")" \
     --certificate-authority="${SA_CA_PATH}" || true;
 
+# Serve the artifacts upload API, receiving the e2e artifacts from the plugins
+# (exec upload-artifact).
+os_log_info "starting artifacts upload API..."
+openshift-tests-plugin exec artifact-server --dir "${RESULTS_DIR}" &
+ARTIFACT_SERVER_PID=$!
+
 #
 # Replace wait-plugin for progress reporter
 #
@@ -69,7 +78,7 @@ watch_dependency_done
 openshift-tests-plugin exec progress-msg --message "status=running";
 
 os_log_info "starting executor..."
-"$(dirname "$0")"/collector.sh
+openshift-tests-plugin exec collector --results-dir "${RESULTS_DIR}"
 
 openshift-tests-plugin exec progress-msg --message "status=runner=preparing results"
 os_log_info "Plugin finished.";
@@ -0,0 +1,231 @@
+package exec
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/collector"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/metrics"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/transfer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	krest "k8s.io/client-go/rest"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+type OptionsArtifactServer struct {
+	Dir         string
+	Port        int
+	Namespace   string
+	TLSCertFile string
+	TLSKeyFile  string
+	ServerName  string
+}
+
+func NewCmdArtifactServer() *cobra.Command {
+	opts := OptionsArtifactServer{}
+
+	cmd := &cobra.Command{
+		Use:   "artifact-server",
+		Short: "Serve the artifacts upload API in the artifacts collector.",
+		Long: `Serve the artifacts upload API (PUT /v1/artifacts/<name>) saving the artifacts sent by
+		the conformance plugins into the results directory. The requests are authenticated by the
+		service account token of the client, validated by the TokenReview API, and the artifact
+		is saved only when the SHA-256 matches the header X-Artifact-Sha256.
+		The API is served over TLS, with the serving certificate of --tls-cert-file and --tls-key-file
+		(example: the service CA serving certificate), or a self-signed certificate of --server-name
+		published in the ConfigMap artifacts-collector-ca, read by the clients.
+		The server runs until it receives SIGTERM or SIGINT.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartArtifactServer(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dir, "dir", collector.DefaultResultsDir, "Directory to save the artifacts.")
+	cmd.Flags().IntVar(&opts.Port, "port", transfer.DefaultPort, "Port to listen.")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", "", "Namespace of the service accounts allowed to upload. Default: pod namespace.")
+	cmd.Flags().StringVar(&opts.TLSCertFile, "tls-cert-file", "", "Serving certificate file. Default: self-signed certificate published in the namespace.")
+	cmd.Flags().StringVar(&opts.TLSKeyFile, "tls-key-file", "", "Serving certificate key file.")
+	cmd.Flags().StringVar(&opts.ServerName, "server-name", transfer.DefaultServerName, "Server name of the self-signed certificate.")
+
+	return cmd
+}
+
+func StartArtifactServer(opts *OptionsArtifactServer) error {
+	kclient, err := newInClusterKubeClient()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", opts.Dir, err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	var cert tls.Certificate
+	if len(opts.TLSCertFile) > 0 {
+		if cert, err = tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile); err != nil {
+			return fmt.Errorf("error loading serving certificate: %w", err)
+		}
+	} else {
+		var caPEM []byte
+		if cert, caPEM, err = transfer.GenerateCertificate(opts.ServerName, transfer.DefaultCertValidity); err != nil {
+			return err
+		}
+		if err := transfer.PublishCA(ctx, kclient, podNamespace(opts.Namespace), caPEM); err != nil {
+			return err
+		}
+	}
+	server := &transfer.Server{
+		Dir:       opts.Dir,
+		Addr:      fmt.Sprintf(":%d", opts.Port),
+		Auth:      transfer.NewTokenReviewAuthenticator(kclient, podNamespace(opts.Namespace)),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	return server.ListenAndServe(ctx)
+}
+
+type OptionsUploadArtifact struct {
+	File       string
+	Name       string
+	URL        string
+	Namespace  string
+	Selector   string
+	TokenFile  string
+	CAFile     string
+	ServerName string
+	Retries    int
+	Backoff    time.Duration
+}
+
+func NewCmdUploadArtifact() *cobra.Command {
+	opts := OptionsUploadArtifact{}
+
+	cmd := &cobra.Command{
+		Use:   "upload-artifact",
+		Short: "Upload an artifact to the artifacts collector.",
+		Long: `Upload an artifact to the upload API served by the artifacts collector (exec artifact-server),
+		discovering the collector pod when the URL is not set. The upload is sent over TLS, verifying the
+		server certificate of --server-name by --ca-file, or by the CA published by the collector when not
+		set. The upload is authenticated by the service account token, retried with exponential backoff
+		on transient errors, and validated by the SHA-256.
+		Example:
+		$ openshift-tests-plugin exec upload-artifact --file /tmp/e2e.tar.gz --name artifacts_e2e-metadata-plugin.tar.gz`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartUploadArtifact(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.File, "file", "", "File to upload.")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "Artifact name (prefix artifacts_). Default: file name.")
+	cmd.Flags().StringVar(&opts.URL, "url", "", "Upload API URL. Default: discovered from the collector pod.")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", "", "Namespace of the collector pod. Default: pod namespace.")
+	cmd.Flags().StringVar(&opts.Selector, "selector", transfer.DefaultCollectorSelector, "Label selector of the collector pod.")
+	cmd.Flags().StringVar(&opts.TokenFile, "token-file", metrics.DefaultTokenFile, "Service account token file to authenticate.")
+	cmd.Flags().StringVar(&opts.CAFile, "ca-file", "", "CA file to verify the collector certificate. Default: CA published by the collector.")
+	cmd.Flags().StringVar(&opts.ServerName, "server-name", transfer.DefaultServerName, "Server name of the collector certificate.")
+	cmd.Flags().IntVar(&opts.Retries, "retries", transfer.DefaultRetries, "Number of attempts to discover the collector and to upload.")
+	cmd.Flags().DurationVar(&opts.Backoff, "backoff", transfer.DefaultBackoff, "Initial backoff between attempts, doubled after each failure.")
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func StartUploadArtifact(opts *OptionsUploadArtifact) error {
+	name := opts.Name
+	if len(name) == 0 {
+		name = filepath.Base(opts.File)
+	}
+	token, err := os.ReadFile(opts.TokenFile)
+	if err != nil {
+		return fmt.Errorf("error reading token file %s: %w", opts.TokenFile, err)
+	}
+
+	ctx := context.Background()
+	var kclient kubernetes.Interface
+	if len(opts.URL) == 0 || len(opts.CAFile) == 0 {
+		if kclient, err = newInClusterKubeClient(); err != nil {
+			return err
+		}
+	}
+	url := opts.URL
+	if len(url) == 0 {
+		err = transfer.Retry(ctx, opts.Retries, opts.Backoff, func() error {
+			url, err = transfer.DiscoverCollectorURL(ctx, kclient, podNamespace(opts.Namespace), opts.Selector, transfer.DefaultPort)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("unable to discover the collector: %w", err)
+		}
+	}
+
+	var caPEM []byte
+	if len(opts.CAFile) > 0 {
+		caPEM, err = os.ReadFile(opts.CAFile)
+	} else {
+		err = transfer.Retry(ctx, opts.Retries, opts.Backoff, func() error {
+			caPEM, err = transfer.ReadCA(ctx, kclient, podNamespace(opts.Namespace))
+			return err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the collector CA: %w", err)
+	}
+	httpClient, err := transfer.NewHTTPClient(caPEM, opts.ServerName)
+	if err != nil {
+		return err
+	}
+
+	client := &transfer.Client{
+		URL:        url,
+		Token:      strings.TrimSpace(string(token)),
+		HTTPClient: httpClient,
+		Retries:    opts.Retries,
+		Backoff:    opts.Backoff,
+	}
+	log.Infof("Uploading %s as %s to %s", opts.File, name, url)
+	res, err := client.Upload(ctx, opts.File, name)
+	if err != nil {
+		return err
+	}
+	log.Infof("Artifact %s uploaded (%d bytes, sha256 %s)", res.Name, res.Size, res.SHA256)
+	return nil
+}
+
+// newInClusterKubeClient creates the kube client from the kubeconfig, or from the
+// pod service account when the kubeconfig is not set.
+func newInClusterKubeClient() (kubernetes.Interface, error) {
+	restConfig, err := plugin.CreateKubeRestConfig()
+	if err != nil {
+		if restConfig, err = krest.InClusterConfig(); err != nil {
+			return nil, fmt.Errorf("error creating kube client config: %w", err)
+		}
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// podNamespace returns the namespace, defaulting to the pod namespace.
+func podNamespace(namespace string) string {
+	if len(namespace) > 0 {
+		return namespace
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		return strings.TrimSpace(string(data))
+	}
+	return transfer.DefaultNamespace
+}
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/collector"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type OptionsCollector struct {
+	ResultsDir string
+}
+
+func NewCmdCollector() *cobra.Command {
+	opts := OptionsCollector{}
+
+	cmd := &cobra.Command{
+		Use:   "collector",
+		Short: "Run the artifacts collector workflow (plugin 99-openshift-artifacts-collector).",
+		Long: `Run the artifacts collector steps (must-gather, performance, metrics, kube-burner,
+		e2e metadata cleaning), reporting the progress of each step to the worker API, then
+		pack the artifacts, the steps JUnit and the artifacts manifest into the raw result file.
+		Steps are skipped with environment variables SKIP_MUST_GATHER, SKIP_PERFORMANCE,
+		SKIP_METRICS and SKIP_KUBE_BURNER, and the timeout of each step is set with
+		COLLECTOR_TIMEOUT_<STEP>, example: COLLECTOR_TIMEOUT_MUST_GATHER=90m.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartCollector(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ResultsDir, "results-dir", "", "Directory to save the artifacts. Default: RESULTS_DIR env var or "+collector.DefaultResultsDir)
+
+	return cmd
+}
+
+func StartCollector(opts *OptionsCollector) error {
+	c := collector.NewCollector()
+	if len(opts.ResultsDir) > 0 {
+		c.ResultsDir = opts.ResultsDir
+	}
+	if err := c.Run(context.Background()); err != nil {
+		return fmt.Errorf("collector failed: %w", err)
+	}
+	for _, res := range c.Results() {
+		log.Infof("Collector step %s: %s %s", res.Name, res.Status, res.Message)
+	}
+	return nil
+}
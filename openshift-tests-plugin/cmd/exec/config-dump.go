@@ -0,0 +1,58 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type OptionsConfigDump struct {
+	Output string
+}
+
+func NewCmdConfigDump() *cobra.Command {
+	opts := OptionsConfigDump{}
+
+	cmd := &cobra.Command{
+		Use:   "config-dump",
+		Short: "Print the effective plugin configuration.",
+		Long: `Print the plugin configuration loaded from the config file (--config or
+		$PWD/.openshift-tests-plugin.yaml), the environment variables and the flags,
+		by precedence. The token and secret fields, the credentials of URLs and image
+		references, and the token query parameters of URLs are masked.
+		Example:
+		$ RUN_MODE=upgrade openshift-tests-plugin exec config-dump --config plugin.yaml -o json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartConfigDump(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "yaml", "Output format: yaml or json")
+
+	return cmd
+}
+
+func StartConfigDump(opts *OptionsConfigDump) error {
+	var data []byte
+	var err error
+	cfg := config.Current().Masked()
+	switch opts.Output {
+	case "json":
+		data, err = json.MarshalIndent(cfg, "", "  ")
+	case "yaml":
+		data, err = yaml.Marshal(cfg)
+	default:
+		return fmt.Errorf("invalid output format %q, valid values: yaml, json", opts.Output)
+	}
+	if err != nil {
+		return fmt.Errorf("error serializing the configuration: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
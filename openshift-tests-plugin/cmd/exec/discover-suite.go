@@ -0,0 +1,126 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/discovery"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type OptionsDiscoverSuite struct {
+	Plugin         string
+	Suite          string
+	OCPVersion     string
+	OpenShiftTests string
+	Extensions     []string
+	TestsFile      string
+	InitErrorFile  string
+	Output         string
+	SuiteNameFile  string
+	Result         string
+	JUnitDir       string
+}
+
+func NewCmdDiscoverSuite() *cobra.Command {
+	opts := OptionsDiscoverSuite{}
+
+	cmd := &cobra.Command{
+		Use:   "discover-suite",
+		Short: "Discover the suite and the tests of the plugin from openshift-tests and extension binaries.",
+		Long: `Discover the suite of the plugin for the cluster version, querying the suites and the tests
+		available in openshift-tests and in the OpenShift Tests Extension (OTE) binaries. The test list
+		is saved to the suite list, and the result to the discovery file read by the plugin. The
+		discovery errors are reported in the JUnit.
+		Example:
+		$ openshift-tests-plugin exec discover-suite --plugin openshift-kube-conformance --extension /tmp/shared/k8s-tests-ext`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartDiscoverSuite(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	suite := os.Getenv("SUITE_NAME")
+	if len(suite) == 0 {
+		suite = os.Getenv("DEFAULT_SUITE_NAME")
+	}
+	cmd.Flags().StringVar(&opts.Plugin, "plugin", os.Getenv("PLUGIN_NAME"), "Plugin name. Default: env PLUGIN_NAME")
+	cmd.Flags().StringVar(&opts.Suite, "suite", suite, "Suite with priority over the default suite of the cluster version. Default: env SUITE_NAME or DEFAULT_SUITE_NAME")
+	cmd.Flags().StringVar(&opts.OCPVersion, "ocp-version", "", "OpenShift version (example: 4.20.0). Default: discovered from ClusterVersion")
+	cmd.Flags().StringVar(&opts.OpenShiftTests, "openshift-tests", plugin.OpenShiftTestsBinPath, "Path of the openshift-tests binary")
+	cmd.Flags().StringSliceVar(&opts.Extensions, "extension", []string{}, "Path of the OpenShift Tests Extension binaries, queried before openshift-tests")
+	cmd.Flags().StringVar(&opts.TestsFile, "tests-file", plugin.K8sConformanceTestsList, "Tests extracted by the init container, used for the suite when the file exists")
+	cmd.Flags().StringVar(&opts.InitErrorFile, "init-error-file", plugin.InitErrorFile, "Error reported by the init container extracting the tests")
+	cmd.Flags().StringVar(&opts.Output, "output", plugin.OpenShiftTestsSuiteList, "Suite list file")
+	cmd.Flags().StringVar(&opts.SuiteNameFile, "suite-name-file", filepath.Join(plugin.SharedDir, "suite.name"), "File with the suite name discovered")
+	cmd.Flags().StringVar(&opts.Result, "result", plugin.SuiteDiscoveryResult, "Discovery result file")
+	cmd.Flags().StringVar(&opts.JUnitDir, "junit-dir", plugin.OpenShiftTestsJUnitDir, "JUnit directory to report the discovery errors")
+
+	return cmd
+}
+
+func StartDiscoverSuite(opts *OptionsDiscoverSuite) error {
+	p, err := plugin.NewPlugin(opts.Plugin)
+	if err != nil {
+		return err
+	}
+	if len(opts.OCPVersion) == 0 {
+		if opts.OCPVersion, err = plugin.GetClusterVersion(); err != nil {
+			log.Warnf("unable to discover the cluster version, checking the suites of all versions: %v", err)
+		}
+	}
+	candidates := p.SuiteCandidates(opts.OCPVersion, opts.Suite)
+	log.Infof("Discovering suite for plugin %s (version %q), candidates: %v", p.Name(), opts.OCPVersion, candidates)
+
+	var result *discovery.Result
+	var tests []string
+	initErr, err := os.ReadFile(opts.InitErrorFile)
+	if err == nil && len(strings.TrimSpace(string(initErr))) > 0 {
+		result = &discovery.Result{
+			Candidates: candidates,
+			Errors:     []string{fmt.Sprintf("init container failed to extract the tests: %s", strings.TrimSpace(string(initErr)))},
+		}
+	} else {
+		d := &discovery.Discoverer{}
+		if info, err := os.Stat(opts.TestsFile); err == nil && info.Size() > 0 && len(candidates) > 0 {
+			d.Sources = append(d.Sources, &discovery.TestsFile{Path: opts.TestsFile, Suite: candidates[0]})
+		}
+		for _, ext := range opts.Extensions {
+			d.Sources = append(d.Sources, discovery.NewExtension(ext))
+		}
+		d.Sources = append(d.Sources, discovery.NewOpenShiftTests(opts.OpenShiftTests))
+		result, tests = d.Discover(context.Background(), candidates)
+	}
+	result.Plugin = p.Name()
+	result.OCPVersion = opts.OCPVersion
+
+	if !result.Failed() {
+		result.SuiteList = opts.Output
+		if err := discovery.WriteSuiteList(tests, opts.Output); err != nil {
+			return fmt.Errorf("error writing the suite list: %w", err)
+		}
+		if err := os.WriteFile(opts.SuiteNameFile, []byte(result.Suite+"\n"), 0644); err != nil {
+			log.Warnf("unable to write the suite name: %v", err)
+		}
+	}
+	if err := result.Save(opts.Result); err != nil {
+		return fmt.Errorf("error saving the discovery result: %w", err)
+	}
+	if result.Failed() {
+		for _, msg := range result.Errors {
+			log.Errorf("Suite discovery: %s", msg)
+		}
+		if err := plugin.WriteSuiteDiscoveryJUnit(opts.JUnitDir, result); err != nil {
+			log.Errorf("unable to write the discovery JUnit: %v", err)
+		}
+		return fmt.Errorf("unable to discover the suite of plugin %s", p.Name())
+	}
+	log.Infof("Discovered suite %s with %d tests from %s, saved to %s", result.Suite, result.Count, result.Source, opts.Output)
+	return nil
+}
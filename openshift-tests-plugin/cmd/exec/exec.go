@@ -26,6 +26,16 @@ func init() {
 	execCmd.AddCommand(NewCmdParserTestSuite())
 	execCmd.AddCommand(NewCmdWaitUpdater())
 	execCmd.AddCommand(NewCmdProgressMessage())
+	execCmd.AddCommand(NewCmdCollector())
+	execCmd.AddCommand(NewCmdGatherMetrics())
+	execCmd.AddCommand(NewCmdRedact())
+	execCmd.AddCommand(NewCmdVerifyResults())
+	execCmd.AddCommand(NewCmdArtifactServer())
+	execCmd.AddCommand(NewCmdUploadArtifact())
+	execCmd.AddCommand(NewCmdDiscoverSuite())
+	execCmd.AddCommand(NewCmdShowConfig())
+	execCmd.AddCommand(NewCmdConfigDump())
+	execCmd.AddCommand(NewCmdSimulateRun())
 }
 
 func NewCmdExec() *cobra.Command {
@@ -0,0 +1,86 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/metrics"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type OptionsGatherMetrics struct {
+	Catalog    string
+	DestDir    string
+	URL        string
+	TokenFile  string
+	CAFile     string
+	Insecure   bool
+	OCPVersion string
+	Timeout    time.Duration
+}
+
+func NewCmdGatherMetrics() *cobra.Command {
+	opts := OptionsGatherMetrics{}
+
+	cmd := &cobra.Command{
+		Use:   "gather-metrics",
+		Short: "Gather metrics running range queries in the Prometheus API.",
+		Long: `Gather metrics running the range queries from the query catalog in the in-cluster
+		Thanos/Prometheus API, authenticated by the service account token.
+		Each query result is saved as JSON in the file query_range-<name>.json, and the result
+		of each query (including failures) is saved in the summary gather-metrics.json.
+		Example:
+		$ openshift-tests-plugin exec gather-metrics --dest-dir ./metrics --catalog ./catalog.yaml`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartGatherMetrics(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Catalog, "catalog", "", "Query catalog file (YAML or JSON). Default: built-in catalog.")
+	cmd.Flags().StringVar(&opts.DestDir, "dest-dir", "./metrics", "Directory to save the query results.")
+	cmd.Flags().StringVar(&opts.URL, "url", metrics.DefaultPrometheusURL, "Prometheus/Thanos API URL.")
+	cmd.Flags().StringVar(&opts.TokenFile, "token-file", metrics.DefaultTokenFile, "Bearer token file to authenticate in the API.")
+	cmd.Flags().StringVar(&opts.CAFile, "ca-file", metrics.DefaultCAFile, "CA bundle to verify the API certificate.")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure-skip-tls-verify", false, "Skip the API certificate verification.")
+	cmd.Flags().StringVar(&opts.OCPVersion, "ocp-version", "", "OCP version to apply the catalog overrides. Default: discovered from the cluster.")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", metrics.DefaultQueryTimeout, "Timeout of each query.")
+
+	return cmd
+}
+
+func StartGatherMetrics(opts *OptionsGatherMetrics) error {
+	catalog, err := metrics.LoadCatalog(opts.Catalog)
+	if err != nil {
+		return err
+	}
+	client, err := metrics.NewClient(opts.URL, opts.TokenFile, opts.CAFile, opts.Insecure, opts.Timeout)
+	if err != nil {
+		return fmt.Errorf("unable to create Prometheus client: %w", err)
+	}
+
+	version := opts.OCPVersion
+	if len(version) == 0 {
+		if version, err = plugin.GetClusterVersion(); err != nil {
+			log.Warnf("Unable to discover the OCP version, ignoring catalog overrides: %v", err)
+		}
+	}
+
+	gatherer := &metrics.Gatherer{
+		Client:  client,
+		DestDir: opts.DestDir,
+		Version: version,
+	}
+	summary, err := gatherer.Gather(context.Background(), catalog)
+	if err != nil {
+		return err
+	}
+	if len(summary.Results) > 0 && summary.Failed() == len(summary.Results) {
+		return fmt.Errorf("all %d queries failed, see %s", len(summary.Results), metrics.SummaryFile)
+	}
+	return nil
+}
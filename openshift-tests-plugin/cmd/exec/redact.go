@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/redact"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type OptionsRedact struct {
+	Config string
+	Input  string
+	Output string
+	Report string
+}
+
+func NewCmdRedact() *cobra.Command {
+	opts := OptionsRedact{}
+
+	cmd := &cobra.Command{
+		Use:   "redact",
+		Short: "Redact sensitive data from a file, directory or tar.gz archive.",
+		Long: `Redact sensitive data with the rules from a must-gather-clean config (mgc-config-*.yaml),
+		streaming the contents of directories, tar.gz archives (including nested archives) and gzip files.
+		The output is created only when all files are redacted, and the number of replacements per
+		rule per file is saved in the redaction report.
+		Example:
+		$ openshift-tests-plugin exec redact --config mgc-config-e2e.yaml --input artifacts_e2e-metadata.tar.gz`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartRedact(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Config, "config", "", "Redaction config file (must-gather-clean format).")
+	cmd.Flags().StringVar(&opts.Input, "input", "", "File, directory or tar.gz archive to redact.")
+	cmd.Flags().StringVar(&opts.Output, "output", "", "Redacted output path. Default: replace the input.")
+	cmd.Flags().StringVar(&opts.Report, "report", "", "Redaction report file (JSON). Default: print the totals.")
+	_ = cmd.MarkFlagRequired("config")
+	_ = cmd.MarkFlagRequired("input")
+
+	return cmd
+}
+
+func StartRedact(opts *OptionsRedact) error {
+	redactor, err := redact.LoadConfig(opts.Config)
+	if err != nil {
+		return err
+	}
+	output := opts.Output
+	if len(output) == 0 {
+		output = opts.Input
+	}
+	report, err := redactor.Redact(opts.Input, output)
+	if err != nil {
+		return fmt.Errorf("redaction failed, output not created: %w", err)
+	}
+	log.Infof("Redacted %d files, %d replacements", report.Scanned, report.Total)
+	for rule, count := range report.Rules {
+		log.Infof("Rule %s: %d replacements", rule, count)
+	}
+	if len(opts.Report) > 0 {
+		return report.Save(opts.Report)
+	}
+	return nil
+}
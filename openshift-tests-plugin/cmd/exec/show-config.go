@@ -0,0 +1,102 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/matrix"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type OptionsShowConfig struct {
+	OCPVersion string
+	Plugins    []string
+	Matrix     string
+	Output     string
+}
+
+// pluginConfig is the effective configuration of the plugin.
+type pluginConfig struct {
+	Plugin         string `json:"plugin"`
+	*matrix.Config `json:",inline"`
+	// Command is the openshift-tests command executed by the plugin.
+	Command []string `json:"command,omitempty"`
+}
+
+func NewCmdShowConfig() *cobra.Command {
+	opts := OptionsShowConfig{}
+
+	cmd := &cobra.Command{
+		Use:   "show-config",
+		Short: "Show the effective configuration of the plugins for the OpenShift version.",
+		Long: `Show the configuration of the plugins resolved by the version matrix for the OpenShift
+		version: the suite, the openshift-tests monitors, the tests excluded, the max parallel tests,
+		the timeout, and the openshift-tests command. The cluster version is read from the ClusterVersion
+		when --ocp-version is not set.
+		Example:
+		$ openshift-tests-plugin exec show-config --ocp-version 4.20.0 --plugin openshift-kube-conformance`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartShowConfig(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OCPVersion, "ocp-version", "", "OpenShift version (example: 4.20.0). Default: discovered from ClusterVersion")
+	cmd.Flags().StringSliceVar(&opts.Plugins, "plugin", []string{}, "Plugin names. Default: all plugins")
+	cmd.Flags().StringVar(&opts.Matrix, "matrix", "", "Version matrix file. Default: config versionMatrixFile (env VERSION_MATRIX_FILE), or the built-in matrix")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "yaml", "Output format: yaml or json")
+
+	return cmd
+}
+
+func StartShowConfig(opts *OptionsShowConfig) error {
+	if opts.Output != "yaml" && opts.Output != "json" {
+		return fmt.Errorf("invalid output format %q, valid values: yaml, json", opts.Output)
+	}
+	if len(opts.Matrix) == 0 {
+		opts.Matrix = config.Current().VersionMatrixFile
+	}
+	vm, err := matrix.LoadMatrix(opts.Matrix)
+	if err != nil {
+		return err
+	}
+	if len(opts.OCPVersion) == 0 {
+		if opts.OCPVersion, err = plugin.GetClusterVersion(); err != nil {
+			log.Warnf("unable to discover the cluster version, showing the defaults: %v", err)
+		}
+	}
+	if len(opts.Plugins) == 0 {
+		opts.Plugins = []string{plugin.PluginName05, plugin.PluginName10, plugin.PluginName20, plugin.PluginName80, plugin.PluginName99}
+	}
+
+	configs := make([]*pluginConfig, 0, len(opts.Plugins))
+	for _, name := range opts.Plugins {
+		p, err := plugin.NewPlugin(name)
+		if err != nil {
+			return err
+		}
+		p.VersionMatrix = vm
+		cfg := &pluginConfig{Plugin: p.FullName(), Config: p.ResolveVersionConfig(opts.OCPVersion)}
+		if p.OTRunner != nil {
+			cfg.Command = p.OTRunner.Args()
+		}
+		configs = append(configs, cfg)
+	}
+
+	var data []byte
+	if opts.Output == "json" {
+		data, err = json.MarshalIndent(configs, "", "  ")
+	} else {
+		data, err = yaml.Marshal(configs)
+	}
+	if err != nil {
+		return fmt.Errorf("error serializing the configuration: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,132 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/simulator"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type OptionsSimulateRun struct {
+	SharedDir      string
+	SuiteList      string
+	Output         string
+	Distribution   string
+	DurationMedian time.Duration
+	DurationMax    time.Duration
+	Parallelism    int
+	Seed           int64
+	StartTime      string
+	Speed          float64
+	Count          int
+}
+
+func NewCmdSimulateRun() *cobra.Command {
+	opts := OptionsSimulateRun{}
+
+	cmd := &cobra.Command{
+		Use:   "simulate-run",
+		Short: "Simulate the openshift-tests execution of the suite list, for load testing.",
+		Long: `Simulate the openshift-tests execution of the suite list in place of the tests container: the
+		outcome and the duration of each test are drawn from the distribution, with the seed, and the
+		openshift-tests output is written to the FIFO read by the plugin, followed by the JUnit and the
+		done files in the shared directory. The output is the same for the same options, with the
+		start time set (--start-time).
+		Example:
+		$ openshift-tests-plugin exec simulate-run --count 20000 --distribution passed=90,failed=2,skipped=7,flaky=1
+		$ openshift-tests-plugin exec simulate-run --suite-list ./suite.list --output ./openshift-tests.log --start-time 2024-07-05T20:00:00Z`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartSimulateRun(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	d := simulator.DefaultDistribution()
+	cmd.Flags().StringVar(&opts.SharedDir, "shared-dir", plugin.SharedDir, "Directory shared with the plugin (FIFO, suite list, JUnit and done files)")
+	cmd.Flags().StringVar(&opts.SuiteList, "suite-list", "", "Suite list with the tests to simulate. Default: suite.list in the shared directory")
+	cmd.Flags().IntVar(&opts.Count, "count", 0, "Simulate a suite of generated tests, in place of the suite list")
+	cmd.Flags().StringVar(&opts.Output, "output", "", "Output of openshift-tests, a FIFO or a file. Default: the FIFO in the shared directory")
+	cmd.Flags().StringVar(&opts.Distribution, "distribution", fmt.Sprintf("passed=%g,failed=%g,skipped=%g,flaky=%g", d.Passed, d.Failed, d.Skipped, d.Flaky), "Ratio of the outcomes of the tests")
+	cmd.Flags().DurationVar(&opts.DurationMedian, "duration-median", d.DurationMedian, "Median duration of the tests")
+	cmd.Flags().DurationVar(&opts.DurationMax, "duration-max", d.DurationMax, "Maximum duration of a test")
+	cmd.Flags().IntVar(&opts.Parallelism, "parallelism", simulator.DefaultParallelism, "Number of tests running at the same time")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 1, "Seed of the outcomes and the durations")
+	cmd.Flags().StringVar(&opts.StartTime, "start-time", "", "Start time (RFC3339) of the simulated execution, example: 2024-07-05T20:00:00Z. Default: now")
+	cmd.Flags().Float64Var(&opts.Speed, "speed", 0, "Ratio of the simulated time to the wall clock, example: 60 writes one minute of execution per second. Default: no wait")
+
+	return cmd
+}
+
+func StartSimulateRun(opts *OptionsSimulateRun) error {
+	ws := plugin.DefaultWorkspace()
+	ws.SharedDir = opts.SharedDir
+	if len(opts.SuiteList) == 0 {
+		opts.SuiteList = ws.SuiteList()
+	}
+	if len(opts.Output) == 0 {
+		opts.Output = ws.FiFo()
+	}
+
+	d, err := simulator.ParseDistribution(opts.Distribution)
+	if err != nil {
+		return err
+	}
+	d.DurationMedian = opts.DurationMedian
+	d.DurationMax = opts.DurationMax
+
+	var startTime time.Time
+	if len(opts.StartTime) > 0 {
+		if startTime, err = time.Parse(time.RFC3339, opts.StartTime); err != nil {
+			return fmt.Errorf("invalid start time %q: %w", opts.StartTime, err)
+		}
+	}
+
+	var tests []string
+	if opts.Count > 0 {
+		for i := 0; i < opts.Count; i++ {
+			tests = append(tests, fmt.Sprintf(`"[sig-simulated] simulated test %d [Suite:openshift/conformance/parallel]"`, i))
+		}
+	} else if tests, err = simulator.ReadSuiteList(opts.SuiteList); err != nil {
+		return err
+	}
+	if len(tests) == 0 {
+		return fmt.Errorf("no tests to simulate in the suite list %s", opts.SuiteList)
+	}
+
+	// Opening the FIFO blocks until the plugin reads the output.
+	log.Infof("Simulating %d tests, writing the output to %s", len(tests), opts.Output)
+	out, err := os.OpenFile(opts.Output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open the output: %w", err)
+	}
+	defer out.Close()
+
+	sim := simulator.New(simulator.Options{
+		Tests:        tests,
+		Distribution: d,
+		Parallelism:  opts.Parallelism,
+		Seed:         opts.Seed,
+		StartTime:    startTime.UTC(),
+		Speed:        opts.Speed,
+	})
+	report, err := sim.Run(context.Background(), out)
+	if err != nil {
+		return fmt.Errorf("simulated run failed: %w", err)
+	}
+	if err := os.MkdirAll(ws.SharedDir, 0755); err != nil {
+		return fmt.Errorf("unable to create the shared directory: %w", err)
+	}
+	if err := report.WriteFiles(ws); err != nil {
+		return err
+	}
+	log.Infof("Simulated run finished: passed=%d failed=%d skipped=%d flaky=%d (%s)",
+		report.Summary.Passed, report.Summary.Failed, report.Summary.Skipped, report.Summary.Flaky,
+		report.FinishedAt.Sub(report.StartedAt).Round(time.Second))
+	return nil
+}
@@ -0,0 +1,43 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStartSimulateRun tests the output is the same for the same options with the start time.
+func TestStartSimulateRun(t *testing.T) {
+	run := func(startTime string) (string, error) {
+		dir := t.TempDir()
+		opts := OptionsSimulateRun{
+			SharedDir:      dir,
+			Output:         filepath.Join(dir, "openshift-tests.log"),
+			Distribution:   "passed=90,failed=2,skipped=7,flaky=1",
+			DurationMedian: 10 * time.Second,
+			DurationMax:    time.Minute,
+			Parallelism:    5,
+			Seed:           1,
+			StartTime:      startTime,
+			Count:          50,
+		}
+		if err := StartSimulateRun(&opts); err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(opts.Output)
+		return string(data), err
+	}
+
+	first, err := run("2024-07-05T20:00:00Z")
+	assert.NoError(t, err)
+	second, err := run("2024-07-05T20:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Contains(t, first, "2024-07-05T20:00:")
+
+	_, err = run("2024-07-05 20:00")
+	assert.ErrorContains(t, err, "invalid start time")
+}
@@ -0,0 +1,104 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/collector"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type OptionsVerifyResults struct {
+	Results        string
+	ManifestSHA256 string
+	PluginResults  []string
+}
+
+func NewCmdVerifyResults() *cobra.Command {
+	opts := OptionsVerifyResults{}
+
+	cmd := &cobra.Command{
+		Use:   "verify-results",
+		Short: "Verify the artifacts collector and the plugin results against their manifests.",
+		Long: `Verify the raw result file of the artifacts collector (raw-results.tar.gz) against the
+		manifest packed in it (artifacts_collector_manifest.json), checking every artifact is listed
+		with the same size and SHA-256. The digest of the manifest is compared with --manifest-sha256,
+		the digest recorded by the collector out of the results (ConfigMap plugin-results-99).
+		The results of the conformance plugins (--plugin-results, extracted from the sonobuoy archive)
+		are verified against the results manifest of each plugin (results-manifest.json), which digest
+		must match the digest recorded in the collector manifest.
+		Example:
+		$ openshift-tests-plugin exec verify-results --results ./raw-results.tar.gz
+		$ openshift-tests-plugin exec verify-results --results ./raw-results.tar.gz \
+			--manifest-sha256 $(oc get cm -n opct plugin-results-99 -o jsonpath='{.data.manifest\.sha256}') \
+			--plugin-results ./plugins/10-openshift-kube-conformance/results/global`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := StartVerifyResults(&opts); err != nil {
+				log.Fatalf("command finished with errors: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Results, "results", collector.RawResultsFile, "Raw result file (tar.gz) to verify.")
+	cmd.Flags().StringVar(&opts.ManifestSHA256, "manifest-sha256", "", "Digest (SHA-256) of the collector manifest recorded by the collector.")
+	cmd.Flags().StringSliceVar(&opts.PluginResults, "plugin-results", []string{}, "Results directory of a conformance plugin to verify. Can be set multiple times.")
+
+	return cmd
+}
+
+func StartVerifyResults(opts *OptionsVerifyResults) error {
+	res, err := collector.VerifyResults(opts.Results)
+	if err != nil {
+		return err
+	}
+	log.Infof("Collector manifest sha256: %s", res.ManifestSHA256)
+	if len(opts.ManifestSHA256) > 0 && opts.ManifestSHA256 != res.ManifestSHA256 {
+		return fmt.Errorf("collector manifest sha256 %s, want %s", res.ManifestSHA256, opts.ManifestSHA256)
+	}
+	digests := map[string]string{}
+	if p := res.Manifest.Provenance; p != nil {
+		log.Infof("Manifest created at %s by %s (%s), cluster version %q", res.Manifest.CreatedAt, p.Plugin, p.PluginVersion, p.ClusterVersion)
+		for plugin, suite := range p.Suites {
+			log.Infof("Plugin %s suite: %s", plugin, suite)
+		}
+		digests = p.Results
+	}
+	if err := reportVerifyResult(res, "artifacts"); err != nil {
+		return err
+	}
+
+	for _, dir := range opts.PluginResults {
+		pres, err := collector.VerifyPluginResults(dir)
+		if err != nil {
+			return err
+		}
+		plugin := pres.PluginManifest.Plugin
+		if want, ok := digests[plugin]; !ok || want != pres.ManifestSHA256 {
+			return fmt.Errorf("plugin %s results manifest sha256 %s, want %q recorded by the collector", plugin, pres.ManifestSHA256, want)
+		}
+		if err := reportVerifyResult(pres, fmt.Sprintf("plugin %s files", plugin)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportVerifyResult logs the verification issues, returning an error when the results
+// do not match the manifest.
+func reportVerifyResult(res *collector.VerifyResult, kind string) error {
+	for _, name := range res.Missing {
+		log.Errorf("Missing artifact: %s", name)
+	}
+	for _, name := range res.Unlisted {
+		log.Errorf("Artifact not listed in the manifest: %s", name)
+	}
+	for _, msg := range res.Mismatched {
+		log.Errorf("Artifact mismatch: %s", msg)
+	}
+	if !res.Valid() {
+		return fmt.Errorf("results verification failed: %d missing, %d unlisted, %d mismatched",
+			len(res.Missing), len(res.Unlisted), len(res.Mismatched))
+	}
+	log.Infof("Results verified: %d %s", len(res.Verified), kind)
+	return nil
+}
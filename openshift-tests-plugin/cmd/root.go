@@ -7,14 +7,18 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/cmd/exec"
-	log "github.com/sirupsen/logrus"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
+// annotationConfigFlags marks the commands with local flags loaded to the plugin
+// configuration (example: run --name). Other commands load only the root flags.
+const annotationConfigFlags = "config-flags"
+
 var pluginConfigFile string
 
 var rootCmd = &cobra.Command{
@@ -26,6 +30,9 @@ examples and usage of using your application. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig(cmd)
+	},
 }
 
 func Execute() {
@@ -35,23 +42,29 @@ func Execute() {
 	}
 }
 
-func initBindFlag(flag string) {
-	err := viper.BindPFlag(flag, rootCmd.PersistentFlags().Lookup(flag))
+// initConfig loads the plugin configuration from the config file, the environment
+// and the flags, failing when the settings are invalid.
+func initConfig(cmd *cobra.Command) error {
+	file := pluginConfigFile
+	if len(file) == 0 {
+		file = config.DefaultFilePath()
+	}
+	flags := cmd.InheritedFlags()
+	if _, ok := cmd.Annotations[annotationConfigFlags]; ok {
+		flags = cmd.Flags()
+	}
+	cfg, err := config.Load(&config.LoadOptions{File: file, Flags: flags})
 	if err != nil {
-		log.Warnf("Unable to bind flag %s\n", flag)
+		cmd.SilenceUsage = true
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
-}
-
-func initConfig() {
-	viper.AutomaticEnv() // read in environment variables that match
+	config.SetCurrent(cfg)
+	return nil
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
-
 	rootCmd.PersistentFlags().StringVar(&pluginConfigFile, "config", "", "config file (default is $PWD/.openshift-tests-plugin.yaml)")
 	rootCmd.PersistentFlags().String("kubeconfig", "", "kubeconfig for target OpenShift cluster")
-	initBindFlag("kubeconfig")
 
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
@@ -5,7 +5,9 @@ import (
 	"os"
 	"time"
 
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
 	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/sandbox"
 	v "github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/version"
 	log "github.com/sirupsen/logrus"
 
@@ -15,6 +17,16 @@ import (
 type OptionsRun struct {
 	Name string
 	ID   string
+
+	ShardIndex int
+	ShardCount int
+
+	// SandboxDir runs the plugin locally against fakes, see package sandbox.
+	SandboxDir string
+	SandboxLog string
+	// Sandbox runs the plugin in the sandbox, example: the plugins of a workflow
+	// sharing the fake cluster in tests.
+	Sandbox *sandbox.Sandbox
 }
 
 func init() {
@@ -34,6 +46,8 @@ func NewCmdRun() *cobra.Command {
 		Use:   "run",
 		Short: "Execute the default workflow for openshift-tests plugin",
 		Long:  ``,
+		// the flags --name, --shard-index and --shard-count are loaded to the configuration.
+		Annotations: map[string]string{annotationConfigFlags: ""},
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := StartRun(&opts); err != nil {
 				// TODO create JUnit err
@@ -45,13 +59,20 @@ func NewCmdRun() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.Name, "name", "", "Plugin name")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "Plugin name. Default: env PLUGIN_NAME")
 	cmd.Flags().StringVar(&opts.ID, "id", "", "Plugin ID")
+	cmd.Flags().IntVar(&opts.ShardIndex, "shard-index", 0, "Index (0-based) of the suite shard to run. Requires --shard-count.")
+	cmd.Flags().IntVar(&opts.ShardCount, "shard-count", 0, "Total of shards to partition the suite. Default: SHARD_COUNT env var or disabled.")
+	cmd.Flags().StringVar(&opts.SandboxDir, "sandbox", "", "Run the plugin locally in the directory, against a fake cluster, sonobuoy worker and tests container.")
+	cmd.Flags().StringVar(&opts.SandboxLog, "sandbox-log", "", "openshift-tests log replayed by the tests container in sandbox mode. Default: built-in sample log.")
 
 	return cmd
 }
 
 func StartRun(opt *OptionsRun) error {
+	if len(opt.Name) == 0 && len(opt.ID) == 0 {
+		opt.Name = config.Current().Plugin
+	}
 	pluginName, err := opt.GetPluginName()
 	if err != nil {
 		return fmt.Errorf("plugin name not found: %w", err)
@@ -65,6 +86,24 @@ func StartRun(opt *OptionsRun) error {
 	}
 	defer pl.Done()
 
+	if opt.ShardCount > 0 {
+		pl.ShardIndex = opt.ShardIndex
+		pl.ShardCount = opt.ShardCount
+	}
+
+	sb := opt.Sandbox
+	if sb == nil && len(opt.SandboxDir) > 0 {
+		if sb, err = sandbox.New(opt.SandboxDir, opt.SandboxLog); err != nil {
+			return fmt.Errorf("unable to create sandbox: %w", err)
+		}
+		defer sb.Close()
+	}
+	if sb != nil {
+		if err := sb.Setup(pl); err != nil {
+			return fmt.Errorf("unable to setup sandbox: %w", err)
+		}
+	}
+
 	if err = pl.Initialize(); err != nil {
 		return fmt.Errorf("unable to initialize plugin %s: %w", pluginName, err)
 	}
@@ -77,15 +116,21 @@ func StartRun(opt *OptionsRun) error {
 	go pl.RunReportProgress()
 	go pl.RunReportProgressUpgrade()
 
-	if err = pl.Run(); err != nil {
+	err = pl.Run()
+	if serr := pl.SnapshotInventory(plugin.InventoryStageEnd); serr != nil {
+		log.Errorf("unable to snapshot the cluster inventory: %v", serr)
+	}
+	if err != nil {
 		return fmt.Errorf("error running plugin: %w", err)
 	}
 
-	pl.Summary()
 	log.Info("Processing JUnit")
 
 	// Read/parse JUnit, processing failures to be used in the pipelien (replay).
-	if err = pl.ProcessJUnit(); err != nil {
+	// The summary shows the failure analysis of the JUnit processor.
+	err = pl.ProcessJUnit()
+	pl.Summary()
+	if err != nil {
 		return fmt.Errorf("error processing JUnits: %w", err)
 	}
 
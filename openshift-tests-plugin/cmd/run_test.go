@@ -1,14 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/sandbox"
 	tdata "github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/test"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	sbaggregation "github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestStartRun(t *testing.T) {
@@ -204,3 +210,169 @@ func TestStartRun(t *testing.T) {
 		})
 	}
 }
+
+// TestStartRunWorkflow runs the plugins of the workflow in sandboxes of the same fake
+// cluster, checking each plugin starts once the blocker plugin is done in the aggregator.
+func TestStartRunWorkflow(t *testing.T) {
+	type step struct {
+		id, name string
+		// blocker is the full name of the blocker plugin.
+		blocker string
+	}
+	workflow := []step{
+		{id: plugin.PluginId05, name: plugin.PluginName05},
+		{id: plugin.PluginId10, name: plugin.PluginName10, blocker: "05-" + plugin.PluginName05},
+		{id: plugin.PluginId20, name: plugin.PluginName20, blocker: "10-" + plugin.PluginName10},
+		{id: plugin.PluginId80, name: plugin.PluginName80, blocker: "20-" + plugin.PluginName20},
+		{id: plugin.PluginId99, name: plugin.PluginName99, blocker: "80-" + plugin.PluginName80},
+	}
+	cases := []struct {
+		name string
+		// failed are the plugins failed in the aggregator before the workflow starts.
+		failed []string
+		// wantErrors are the errors returned by the plugins, by plugin ID.
+		wantErrors map[string]string
+	}{
+		{
+			name:       "default mode",
+			wantErrors: map[string]string{},
+		},
+		{
+			name:   "upgrade plugin failed",
+			failed: []string{plugin.PluginId05},
+			wantErrors: map[string]string{
+				plugin.PluginId10: "error running dependency waiter: blocker plugin openshift-cluster-upgrade failed, stopping execution of dependent plugin openshift-kube-conformance",
+				plugin.PluginId20: "error running dependency waiter: blocker plugin openshift-kube-conformance failed, stopping execution of dependent plugin openshift-conformance-validated",
+				plugin.PluginId80: "error running dependency waiter: blocker plugin openshift-conformance-validated failed, stopping execution of dependent plugin openshift-tests-replay",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster, err := sandbox.NewCluster(sandbox.DefaultVersion)
+			if err != nil {
+				t.Fatalf("NewCluster() unexpected error: %v", err)
+			}
+			skip := map[string]bool{}
+			for _, id := range tc.failed {
+				skip[id] = true
+			}
+
+			type result struct {
+				step step
+				err  error
+			}
+			results := make(chan result, len(workflow))
+			running := 0
+			for _, s := range workflow {
+				fullName := fmt.Sprintf("%s-%s", s.id, s.name)
+				if skip[s.id] {
+					if err := cluster.SetStatus(fullName, sbaggregation.FailedStatus); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				sb, err := cluster.NewSandbox(filepath.Join(t.TempDir(), s.id), "")
+				if err != nil {
+					t.Fatalf("NewSandbox() unexpected error: %v", err)
+				}
+				defer sb.Close()
+				running++
+				go func(s step, sb *sandbox.Sandbox) {
+					err := StartRun(&OptionsRun{ID: s.id, Sandbox: sb})
+					if err != nil {
+						// sonobuoy reports the plugin failed when the plugin container exits with error.
+						if serr := cluster.SetStatus(fullName, sbaggregation.FailedStatus); serr != nil {
+							t.Error(serr)
+						}
+					}
+					results <- result{step: s, err: err}
+				}(s, sb)
+			}
+
+			timeout := time.After(3 * time.Minute)
+			for i := 0; i < running; i++ {
+				select {
+				case r := <-results:
+					if want, ok := tc.wantErrors[r.step.id]; ok {
+						if assert.Error(t, r.err, "plugin %s", r.step.id) {
+							assert.Equal(t, want, r.err.Error())
+						}
+						continue
+					}
+					assert.NoError(t, r.err, "plugin %s", r.step.id)
+				case <-timeout:
+					t.Fatalf("timeout waiting for the workflow, timeline: %+v", cluster.Timeline())
+				}
+			}
+
+			// timeline: the plugin runs after the blocker plugin finished.
+			for _, s := range workflow {
+				fullName := fmt.Sprintf("%s-%s", s.id, s.name)
+				if skip[s.id] {
+					continue
+				}
+				if _, failed := tc.wantErrors[s.id]; failed {
+					ps, _ := cluster.Status(fullName)
+					assert.Equal(t, sbaggregation.FailedStatus, ps.Status, "plugin %s status", fullName)
+					_, started := cluster.FirstEvent(fullName, sandbox.EventStarted, "")
+					assert.False(t, started, "plugin %s started with the blocker failed", fullName)
+					continue
+				}
+				// the fake sonobuoy worker reports the plugin complete once the results are written.
+				assert.Eventually(t, func() bool {
+					ps, _ := cluster.Status(fullName)
+					return ps.Status == sbaggregation.CompleteStatus
+				}, 5*time.Second, 100*time.Millisecond, "plugin %s status", fullName)
+				done, ok := cluster.FirstEvent(fullName, sandbox.EventStatus, sbaggregation.CompleteStatus)
+				if !assert.True(t, ok, "plugin %s complete", fullName) || len(s.blocker) == 0 {
+					continue
+				}
+				blockerDone, ok := cluster.FirstEvent(s.blocker, sandbox.EventStatus, "")
+				if !assert.True(t, ok, "blocker plugin %s done", s.blocker) {
+					continue
+				}
+				assert.True(t, blockerDone.Time.Before(done.Time), "plugin %s done before the blocker plugin %s", fullName, s.blocker)
+				if started, ok := cluster.FirstEvent(fullName, sandbox.EventStarted, ""); ok {
+					assert.True(t, blockerDone.Time.Before(started.Time), "plugin %s started before the blocker plugin %s is done", fullName, s.blocker)
+				}
+			}
+
+			// the conformance plugins run the tests and save the failures for the replay.
+			for _, id := range []string{plugin.PluginId10, plugin.PluginId20, plugin.PluginId80} {
+				if _, failed := tc.wantErrors[id]; failed {
+					continue
+				}
+				_, err := cluster.KubeClient().CoreV1().ConfigMaps(plugin.EnvNamespace).Get(context.TODO(), "plugin-failures-"+id, kmmetav1.GetOptions{})
+				assert.NoError(t, err, "plugin %s failures ConfigMap", id)
+			}
+		})
+	}
+}
+
+// TestStartRunCollectorSandbox tests the plugin 99 started by 'run' reports the tests
+// runner skipped, as the artifacts are collected by the collector workflow.
+func TestStartRunCollectorSandbox(t *testing.T) {
+	sb, err := sandbox.New(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("sandbox.New() unexpected error: %v", err)
+	}
+	defer sb.Close()
+	if err := StartRun(&OptionsRun{Name: plugin.PluginName99, Sandbox: sb}); err != nil {
+		t.Fatalf("StartRun() unexpected error: %v", err)
+	}
+	result, err := sb.ResultFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	junit, err := sandbox.ResultJUnit(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(junit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(data), "[opct] artifacts collected by the collector workflow")
+	assert.Contains(t, string(data), "skipped")
+}
@@ -0,0 +1,231 @@
+/*
+Package analysis clusters the failures of the tests by signature.
+
+Failures sharing the root cause (example: an image pull error or an API timeout) are
+reported with messages differing only by the values of the run: IDs, timestamps, pod
+and namespace names, addresses and durations. The key line of each failure message
+(the "fail [file:line]: message" line printed by the e2e framework, or the first line)
+is normalized, replacing the values by placeholders, and the failures with the same
+normalized message are grouped in a signature, with the affected tests, the SIGs and
+the code locations reporting the failure.
+*/
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxSignatureLength is the length limit of the signature and the example message.
+const maxSignatureLength = 256
+
+// NoMessage is the signature of the failures without message.
+const NoMessage = "<no failure message>"
+
+// Failure is a failed test.
+type Failure struct {
+	Test    string
+	Message string
+}
+
+// Signature is a normalized failure message shared by the failures of the tests.
+type Signature struct {
+	// ID is the hash of the signature, stable between runs.
+	ID        string `json:"id"`
+	Signature string `json:"signature"`
+	// Example is the key line of the first failure, not normalized.
+	Example string `json:"example"`
+	// Count is the count of failed tests.
+	Count int      `json:"count"`
+	Tests []string `json:"tests"`
+	SIGs  []string `json:"sigs,omitempty"`
+	// Locations are the code locations reporting the failure (file:line).
+	Locations []string `json:"locations,omitempty"`
+}
+
+// Report is the failures clustered by signature, the most frequent first.
+type Report struct {
+	Failures   int          `json:"failures"`
+	Signatures []*Signature `json:"signatures"`
+}
+
+var (
+	// reLocation matches the failure line of the e2e framework: fail [file:line]: message.
+	reLocation = regexp.MustCompile(`^fail \[([^\]]+)\]:\s*(.*)$`)
+	// reSIG matches the SIG of the test name, example: [sig-apps].
+	reSIG = regexp.MustCompile(`\[(sig-[a-z0-9-]+)\]`)
+	// reSpaces matches the sequences of spaces.
+	reSpaces = regexp.MustCompile(`\s+`)
+
+	// podSuffix is the alphabet of the random suffixes of the generated names.
+	podSuffix = `[bcdfghjklmnpqrstvwxz2456789]`
+
+	// normalizers replace the values of the run by placeholders, in order.
+	normalizers = []struct {
+		re   *regexp.Regexp
+		repl string
+	}{
+		{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+		{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?( [+-]\d{4}( [A-Z]+)?)?`), "<time>"},
+		{regexp.MustCompile(`\b[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2}(\.\d+)?`), "<time>"},
+		{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+		{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+		{regexp.MustCompile(`(?i)\b(sha256:)?[0-9a-f]{16,}\b`), "<hash>"},
+		{regexp.MustCompile(`\be2e-[-a-z0-9]+`), "<namespace>"},
+		{regexp.MustCompile(`\b[a-z0-9][-a-z0-9]*?-(` + podSuffix + `{6,10}-)?` + podSuffix + `{5}\b`), "<pod>"},
+		{regexp.MustCompile(`\b(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`), "<duration>"},
+		{regexp.MustCompile(`(^|[^\w.:])\d+(\.\d+)?\b`), "${1}<n>"},
+	}
+)
+
+// Normalize returns the message with the values of the run replaced by placeholders.
+func Normalize(message string) string {
+	for _, n := range normalizers {
+		message = n.re.ReplaceAllString(message, n.repl)
+	}
+	return truncate(strings.TrimSpace(reSpaces.ReplaceAllString(message, " ")))
+}
+
+// keyLine returns the line describing the failure: the failure line of the e2e
+// framework, or the first line of the message.
+func keyLine(message string) string {
+	first := ""
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if reLocation.MatchString(line) {
+			return line
+		}
+		if len(first) == 0 {
+			first = line
+		}
+	}
+	return first
+}
+
+// Extract returns the signature of the failure message, and the code location when
+// reported by the e2e framework.
+func Extract(message string) (signature, location string) {
+	line := keyLine(message)
+	if m := reLocation.FindStringSubmatch(line); m != nil {
+		location, line = m[1], m[2]
+	}
+	if signature = Normalize(line); len(signature) == 0 {
+		signature = NoMessage
+	}
+	return signature, location
+}
+
+// Analyze clusters the failures by signature.
+func Analyze(failures []Failure) *Report {
+	report := &Report{Signatures: []*Signature{}}
+	bySignature := map[string]*Signature{}
+	tests := map[string]map[string]struct{}{}
+	for _, f := range failures {
+		signature, location := Extract(f.Message)
+		s, ok := bySignature[signature]
+		if !ok {
+			sum := sha256.Sum256([]byte(signature))
+			s = &Signature{ID: hex.EncodeToString(sum[:6]), Signature: signature, Example: truncate(keyLine(f.Message))}
+			bySignature[signature] = s
+			tests[signature] = map[string]struct{}{}
+			report.Signatures = append(report.Signatures, s)
+		}
+		// the failures of the flaky tests are reported once.
+		if _, ok := tests[signature][f.Test]; ok {
+			continue
+		}
+		tests[signature][f.Test] = struct{}{}
+		report.Failures++
+		s.Count++
+		s.Tests = append(s.Tests, f.Test)
+		for _, m := range reSIG.FindAllStringSubmatch(f.Test, -1) {
+			s.SIGs = appendUnique(s.SIGs, m[1])
+		}
+		if len(location) > 0 {
+			s.Locations = appendUnique(s.Locations, location)
+		}
+	}
+	for _, s := range report.Signatures {
+		sort.Strings(s.Tests)
+		sort.Strings(s.SIGs)
+		sort.Strings(s.Locations)
+	}
+	sort.SliceStable(report.Signatures, func(i, j int) bool {
+		a, b := report.Signatures[i], report.Signatures[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Signature < b.Signature
+	})
+	return report
+}
+
+// ReadJUnitFailures reads the failed tests of the JUnit file, with one or multiple
+// test suites.
+func ReadJUnitFailures(file string) ([]Failure, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading JUnit file: %w", err)
+	}
+	type testCase struct {
+		Name    string `xml:"name,attr"`
+		Failure *struct {
+			Message string `xml:"message,attr"`
+			Text    string `xml:",chardata"`
+		} `xml:"failure"`
+	}
+	type testSuite struct {
+		TestCases []testCase `xml:"testcase"`
+	}
+	var root struct {
+		XMLName   xml.Name
+		TestCases []testCase  `xml:"testcase"`
+		Suites    []testSuite `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error parsing JUnit file: %w", err)
+	}
+	cases := root.TestCases
+	for _, suite := range root.Suites {
+		cases = append(cases, suite.TestCases...)
+	}
+	failures := []Failure{}
+	for _, tc := range cases {
+		if tc.Failure == nil {
+			continue
+		}
+		message := tc.Failure.Text
+		if len(strings.TrimSpace(message)) == 0 {
+			message = tc.Failure.Message
+		}
+		failures = append(failures, Failure{Test: tc.Name, Message: message})
+	}
+	return failures, nil
+}
+
+// truncate limits the text to maxSignatureLength.
+func truncate(text string) string {
+	if len(text) <= maxSignatureLength {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxSignatureLength], "") + "..."
+}
+
+// appendUnique appends the value when not in the list.
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{
+			message: `Timed out after 300.000s.`,
+			want:    `Timed out after <duration>.`,
+		},
+		{
+			message: `pod "pod-disruption-target-x2k9z" in namespace "e2e-job-4721" is not running`,
+			want:    `pod "<pod>" in namespace "<namespace>" is not running`,
+		},
+		{
+			message: `pod "router-default-7d9f8b6c4b-x2x9z" failed at 2024-07-05T20:27:49Z`,
+			want:    `pod "<pod>" failed at <time>`,
+		},
+		{
+			message: `Get "https://172.30.0.1:443/api/v1/namespaces": dial tcp 172.30.0.1:443: i/o timeout`,
+			want:    `Get "https://<ip>/api/v1/namespaces": dial tcp <ip>: i/o timeout`,
+		},
+		{
+			message: `uid 3f0c5a1e-8d2b-4c7e-9a61-0b7d2f1e4c3a: image quay.io/ocp@sha256:0123456789abcdef0123456789abcdef not found`,
+			want:    `uid <uuid>: image quay.io/ocp@<hash> not found`,
+		},
+		{
+			message: "Jul  5 20:27:58.101: waited  5m0s for 3 replicas",
+			want:    "<time>: waited <duration> for <n> replicas",
+		},
+		{
+			message: `error at job.go:211 in e2e test`,
+			want:    `error at job.go:211 in e2e test`,
+		},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.message); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	message := `Jul  5 20:27:58.101: INFO: Waiting up to 5m0s for pod
+fail [k8s.io/kubernetes/test/e2e/apps/job.go:211]: Timed out after 300.000s.
+Expected pod "pod-disruption-target" to be "Failed"`
+	signature, location := Extract(message)
+	if signature != "Timed out after <duration>." || location != "k8s.io/kubernetes/test/e2e/apps/job.go:211" {
+		t.Errorf("Extract() = %q, %q, want the failure line of the e2e framework", signature, location)
+	}
+	if signature, location := Extract("\n  image pull error: 503\nmore"); signature != "image pull error: <n>" || len(location) != 0 {
+		t.Errorf("Extract() = %q, %q, want the first line", signature, location)
+	}
+	if signature, _ := Extract("  "); signature != NoMessage {
+		t.Errorf("Extract() = %q, want %q", signature, NoMessage)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	pull := func(pod string) string {
+		return `fail [github.com/openshift/origin/test/extended/util/client.go:100]: pod "` + pod + `" failed: ErrImagePull: registry.example.com:5000 timeout`
+	}
+	failures := []Failure{
+		{Test: "[sig-apps] test a [Suite:k8s]", Message: pull("a-x2k9z")},
+		{Test: "[sig-network] test b [Suite:k8s]", Message: pull("b-7d9f8b6c4b-x2x9z")},
+		{Test: "[sig-apps] test a [Suite:k8s]", Message: pull("a-q8t7z")},
+		{Test: "[sig-cli] test c", Message: "fail [cli.go:10]: unexpected exit code 1"},
+		{Test: "[sig-storage] test d", Message: `fail [k8s.io/kubernetes/test/e2e/storage/volumes.go:42]: pod "d-b7c9z" failed: ErrImagePull: registry.example.com:5000 timeout`},
+	}
+	report := Analyze(failures)
+	if report.Failures != 4 || len(report.Signatures) != 2 {
+		t.Fatalf("Analyze() = %d failures in %d signatures, want 4 in 2", report.Failures, len(report.Signatures))
+	}
+	top := report.Signatures[0]
+	if top.Signature != `pod "<pod>" failed: ErrImagePull: registry.example.com:5000 timeout` || strings.Join(top.SIGs, ",") != "sig-apps,sig-network,sig-storage" || len(top.Locations) != 2 {
+		t.Errorf("Analyze() top signature = %+v, want the image pull error of 3 tests", top)
+	}
+	if len(top.ID) != 12 || top.Example != strings.TrimSpace(pull("a-x2k9z")) {
+		t.Errorf("Analyze() top signature id %q, example %q", top.ID, top.Example)
+	}
+	if again := Analyze(failures); again.Signatures[0].ID != top.ID {
+		t.Errorf("Analyze() signature id = %s, want stable %s", again.Signatures[0].ID, top.ID)
+	}
+}
+
+func TestReadJUnitFailures(t *testing.T) {
+	dir := t.TempDir()
+	junits := map[string]string{
+		"suite.xml": `<testsuite name="openshift-tests" tests="3">
+  <testcase name="test a"><failure message="">fail [a.go:1]: error a</failure></testcase>
+  <testcase name="test b"></testcase>
+  <testcase name="test c"><failure message="error c"></failure></testcase>
+</testsuite>`,
+		"suites.xml": `<testsuites>
+  <testsuite name="one"><testcase name="test a"><failure>fail [a.go:1]: error a</failure></testcase></testsuite>
+  <testsuite name="two"><testcase name="test c"><failure message="error c"></failure></testcase></testsuite>
+</testsuites>`,
+	}
+	for name, data := range junits {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		failures, err := ReadJUnitFailures(file)
+		if err != nil {
+			t.Fatalf("ReadJUnitFailures(%s) unexpected error: %v", name, err)
+		}
+		if len(failures) != 2 || failures[0].Message != "fail [a.go:1]: error a" || failures[1].Message != "error c" {
+			t.Errorf("ReadJUnitFailures(%s) = %+v, want the failures of test a and c", name, failures)
+		}
+	}
+	if _, err := ReadJUnitFailures(filepath.Join(dir, "missing.xml")); err == nil {
+		t.Errorf("ReadJUnitFailures() with missing file, want error")
+	}
+}
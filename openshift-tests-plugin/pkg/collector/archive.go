@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// createTarGz creates the gzip compressed tarball dest with the entries (files
+// or directories) relative to baseDir. The tarball is written to a temporary
+// file and renamed when completed.
+func createTarGz(dest, baseDir string, entries []string) error {
+	tmp := dest + ".tmp"
+	fd, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error creating tarball %s: %w", dest, err)
+	}
+	defer os.Remove(tmp)
+	defer fd.Close()
+
+	gw := gzip.NewWriter(fd)
+	tw := tar.NewWriter(gw)
+	for _, entry := range entries {
+		err := filepath.WalkDir(filepath.Join(baseDir, entry), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return addTarEntry(tw, baseDir, path)
+		})
+		if err != nil {
+			return fmt.Errorf("error adding %s to tarball %s: %w", entry, dest, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("error closing tarball %s: %w", dest, err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("error closing tarball %s: %w", dest, err)
+	}
+	if err := fd.Close(); err != nil {
+		return fmt.Errorf("error closing tarball %s: %w", dest, err)
+	}
+	return os.Rename(tmp, dest)
+}
+
+// addTarEntry writes the file or directory header, and the file content, to the tarball.
+func addTarEntry(tw *tar.Writer, baseDir, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		return nil
+	}
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return nil
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = "./" + filepath.ToSlash(rel)
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = io.Copy(tw, fd)
+	return err
+}
+
+// readTarGzFile returns the contents of the file name in the gzip compressed tarball.
+func readTarGzFile(src, name string) ([]byte, error) {
+	fd, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	gr, err := gzip.NewReader(fd)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tarball %s: %w", src, err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("file %s not found in %s", name, filepath.Base(src))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading tarball %s: %w", src, err)
+		}
+		if tarEntryName(hdr.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// tarEntryName returns the entry name without the leading './' or '/'.
+func tarEntryName(name string) string {
+	return path.Clean(strings.TrimLeft(strings.TrimPrefix(name, "./"), "/"))
+}
@@ -0,0 +1,330 @@
+/*
+Package collector implements the workflow of the plugin 99-openshift-artifacts-collector.
+
+The collector is the last plugin executed in the conformance workflow. It runs a
+set of steps (must-gather, performance, metrics, kube-burner, etc) collecting
+artifacts into the results directory, then packs all artifacts (files prefixed
+by 'artifacts_') into the raw result file sent to the sonobuoy aggregator.
+
+Each step has its own timeout, and the result is reported to the aggregator as
+progress, recorded as a test case in the collector JUnit, and the artifacts
+produced by the step are recorded in the collector manifest.
+*/
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// DefaultResultsDir is the sonobuoy results directory where the artifacts are saved.
+	DefaultResultsDir = plugin.ResultsDir
+	// ArtifactPrefix is the prefix of the files packed in the raw result file.
+	ArtifactPrefix = "artifacts_"
+	// RawResultsFile is the file sent to the sonobuoy aggregator.
+	RawResultsFile = "raw-results.tar.gz"
+
+	// JUnitFile holds the result of each collector step.
+	JUnitFile = "artifacts_collector_junit.xml"
+	// ManifestFile holds the artifacts produced by each collector step.
+	ManifestFile = "artifacts_collector_manifest.json"
+
+	// E2EMetadataPrefix is the prefix of the e2e metadata archives sent by the conformance plugins.
+	E2EMetadataPrefix = "artifacts_e2e-metadata-"
+	// E2ETestsPrefix is the prefix of the suite lists sent by the conformance plugins.
+	E2ETestsPrefix = "artifacts_e2e-tests_"
+	// E2EMetadataSuiteFile is the suite name file in the e2e metadata archives.
+	E2EMetadataSuiteFile = "tmp/shared/suite.name"
+
+	DefaultOcBin = "/usr/bin/oc"
+)
+
+// Step is a collector step producing artifacts into the results directory.
+type Step interface {
+	// Name returns the step name used in the progress, JUnit and manifest.
+	Name() string
+	// Run executes the step. The context is cancelled when the step timeout is reached,
+	// the step must return when the context is done.
+	Run(ctx context.Context, c *Collector) error
+}
+
+// StepConfig holds the execution options of a step.
+type StepConfig struct {
+	Step    Step
+	Timeout time.Duration
+	// Skip reports the step as skipped, without running it.
+	Skip bool
+}
+
+// Collector runs the collector steps.
+type Collector struct {
+	ResultsDir string
+	Steps      []*StepConfig
+
+	// OcBin is the oc binary used by the steps.
+	OcBin string
+	// MirrorRepository overrides the images in disconnected environments (env MIRROR_IMAGE_REPOSITORY).
+	MirrorRepository string
+	// ClusterVersion is recorded in the manifest. Discovered from the cluster when empty.
+	ClusterVersion string
+
+	// KubeClient is used by steps discovering cluster resources. Created on demand when nil.
+	KubeClient kubernetes.Interface
+
+	// ManifestDigest is the digest (SHA-256) of the collector manifest, set when the
+	// manifest is written.
+	ManifestDigest string
+
+	// ReportProgress sends the progress to the aggregator. Default to the worker progress API.
+	ReportProgress func(message string, completed, total int64)
+
+	// mu guards the progress counters, updated while steps may run in background.
+	mu        sync.Mutex
+	completed int64
+	total     int64
+	manifest  *Manifest
+	results   []*StepResult
+}
+
+// NewCollector creates the collector with the default steps and options from
+// the environment.
+func NewCollector() *Collector {
+	resultsDir := os.Getenv("RESULTS_DIR")
+	if len(resultsDir) == 0 {
+		resultsDir = DefaultResultsDir
+	}
+	return &Collector{
+		ResultsDir:       resultsDir,
+		Steps:            DefaultSteps(),
+		OcBin:            DefaultOcBin,
+		MirrorRepository: os.Getenv("MIRROR_IMAGE_REPOSITORY"),
+		ReportProgress:   sendProgress,
+	}
+}
+
+// sendProgress sends the progress message to the worker progress API.
+func sendProgress(message string, completed, total int64) {
+	progress := plugin.NewPluginProgress()
+	progress.Set(&plugin.PluginProgress{
+		ProgressMessage: ptr.To(message),
+		CompleteCount:   ptr.To(completed),
+		TotalCount:      ptr.To(total),
+	})
+	progress.UpdateAndSend()
+}
+
+// Progress reports the message with the current step counters.
+func (c *Collector) Progress(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ReportProgress == nil {
+		return
+	}
+	c.ReportProgress(message, c.completed, c.total)
+}
+
+// Run executes the collector steps, writes the JUnit and manifest, and packs the
+// artifacts into the raw result file.
+func (c *Collector) Run(ctx context.Context) error {
+	log.Infof("Starting artifacts collector in %s", c.ResultsDir)
+	if err := os.MkdirAll(c.ResultsDir, 0755); err != nil {
+		return fmt.Errorf("error creating results directory %s: %w", c.ResultsDir, err)
+	}
+
+	c.mu.Lock()
+	c.completed = 0
+	c.total = 0
+	for _, sc := range c.Steps {
+		if !sc.Skip {
+			c.total++
+		}
+	}
+	c.mu.Unlock()
+	c.results = []*StepResult{}
+	c.manifest = &Manifest{}
+	if len(c.ClusterVersion) == 0 {
+		clusterVersion, err := plugin.GetClusterVersion()
+		if err != nil {
+			log.Warnf("unable to discover the cluster version: %v", err)
+		}
+		c.ClusterVersion = clusterVersion
+	}
+
+	// Artifacts received from other plugins (e2e metadata) before the collector steps.
+	snapshot, err := c.snapshotArtifacts()
+	if err != nil {
+		return err
+	}
+	c.manifest.addArtifacts(snapshot, nil, ArtifactSourceReceived)
+
+	for _, sc := range c.Steps {
+		res := c.runStep(ctx, sc)
+		c.results = append(c.results, res)
+
+		after, err := c.snapshotArtifacts()
+		if err != nil {
+			return err
+		}
+		c.manifest.addArtifacts(after, snapshot, sc.Step.Name())
+		snapshot = after
+	}
+
+	c.Progress("status=running=saving artifacts")
+	if err := c.writeJUnit(); err != nil {
+		log.Errorf("unable to write collector JUnit: %v", err)
+	}
+	if err := c.writeManifest(); err != nil {
+		log.Errorf("unable to write collector manifest: %v", err)
+	} else if err := c.saveManifestDigest(); err != nil {
+		log.Errorf("unable to save the digest of the collector manifest: %v", err)
+	}
+	if err := c.packResults(); err != nil {
+		return err
+	}
+	c.Progress("status=done")
+	log.Info("Artifacts collector finished.")
+	return nil
+}
+
+// runStep runs the step enforcing the timeout, returning the step result.
+func (c *Collector) runStep(ctx context.Context, sc *StepConfig) *StepResult {
+	name := sc.Step.Name()
+	res := &StepResult{Name: name, Status: StepStatusSkipped}
+	if sc.Skip {
+		log.Infof("Collector step %s: skipped", name)
+		res.Message = "step disabled"
+		return res
+	}
+
+	log.Infof("Collector step %s: starting (timeout %v)", name, sc.Timeout)
+	c.Progress(fmt.Sprintf("status=running=%s", name))
+	res.StartedAt = time.Now()
+
+	stepCtx := ctx
+	if sc.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, sc.Timeout)
+		defer cancel()
+	}
+
+	// The steps run the commands with the step context, the timeout kills the
+	// command and the step returns before the next step starts writing to the
+	// results directory.
+	err := sc.Step.Run(stepCtx, c)
+	if err != nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("step timed out after %v: %w", sc.Timeout, err)
+	}
+
+	res.FinishedAt = time.Now()
+	c.mu.Lock()
+	c.completed++
+	c.mu.Unlock()
+	if err != nil {
+		log.Errorf("Collector step %s: failed after %v: %v", name, res.Duration(), err)
+		res.Status = StepStatusFailed
+		res.Message = err.Error()
+		c.Progress(fmt.Sprintf("status=failed=%s", name))
+		return res
+	}
+	log.Infof("Collector step %s: done in %v", name, res.Duration())
+	res.Status = StepStatusPassed
+	c.Progress(fmt.Sprintf("status=done=%s", name))
+	return res
+}
+
+// Path returns the path of the file in the results directory.
+func (c *Collector) Path(name string) string {
+	return filepath.Join(c.ResultsDir, name)
+}
+
+// Command creates the command running in the results directory, sending the
+// output to the process stdout/stderr when stdout is nil.
+func (c *Collector) Command(ctx context.Context, stdout io.Writer, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = c.ResultsDir
+	cmd.Stdout = os.Stdout
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// Exec runs the command in the results directory.
+func (c *Collector) Exec(ctx context.Context, stdout io.Writer, name string, args ...string) error {
+	log.Infof("Running: %s %s", name, strings.Join(args, " "))
+	if err := c.Command(ctx, stdout, name, args...).Run(); err != nil {
+		return fmt.Errorf("error running %s: %w", name, err)
+	}
+	return nil
+}
+
+// Oc runs the oc command in the results directory.
+func (c *Collector) Oc(ctx context.Context, stdout io.Writer, args ...string) error {
+	return c.Exec(ctx, stdout, c.OcBin, args...)
+}
+
+// Image returns the image name, overridden by the mirror repository in
+// disconnected environments.
+func (c *Collector) Image(image, mirrorName string) string {
+	if len(c.MirrorRepository) == 0 {
+		return image
+	}
+	mirror := fmt.Sprintf("%s/%s", c.MirrorRepository, mirrorName)
+	log.Infof("Image overrided for disconnected. from=[%s] to=[%s]", image, mirror)
+	return mirror
+}
+
+// Kube returns the kubernetes client, creating it when not set.
+func (c *Collector) Kube() (kubernetes.Interface, error) {
+	if c.KubeClient != nil {
+		return c.KubeClient, nil
+	}
+	kclient, _, err := plugin.CreateClients()
+	if err != nil {
+		return nil, err
+	}
+	c.KubeClient = kclient
+	return c.KubeClient, nil
+}
+
+// Results returns the results of the steps executed by Run.
+func (c *Collector) Results() []*StepResult {
+	return c.results
+}
+
+// Manifest returns the manifest of the artifacts recorded by Run.
+func (c *Collector) Manifest() *Manifest {
+	return c.manifest
+}
+
+// packResults creates the raw result file with all artifacts.
+func (c *Collector) packResults() error {
+	files, err := filepath.Glob(c.Path(ArtifactPrefix + "*"))
+	if err != nil {
+		return fmt.Errorf("error listing artifacts: %w", err)
+	}
+	names := []string{}
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	log.Infof("Packing %d artifacts into %s", len(names), c.Path(RawResultsFile))
+	if err := createTarGz(c.Path(RawResultsFile), c.ResultsDir, names); err != nil {
+		return fmt.Errorf("error packing results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+package collector
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeStep is a collector step writing artifacts, or returning an error.
+type fakeStep struct {
+	name      string
+	artifacts []string
+	err       error
+	block     bool
+	// delay is the time to finish the step ignoring the context.
+	delay time.Duration
+	// partial is the artifact written when the context is done.
+	partial string
+}
+
+func (s *fakeStep) Name() string { return s.name }
+
+func (s *fakeStep) Run(ctx context.Context, c *Collector) error {
+	for _, name := range s.artifacts {
+		if err := os.WriteFile(c.Path(name), []byte(s.name), 0644); err != nil {
+			return err
+		}
+	}
+	c.Progress("status=running=" + s.name + "=substep")
+	if s.block {
+		<-ctx.Done()
+		if len(s.partial) > 0 {
+			time.Sleep(50 * time.Millisecond)
+			if err := os.WriteFile(c.Path(s.partial), []byte(s.name), 0644); err != nil {
+				return err
+			}
+		}
+		return ctx.Err()
+	}
+	time.Sleep(s.delay)
+	return s.err
+}
+
+type progressMessage struct {
+	message          string
+	completed, total int64
+}
+
+func newTestCollector(t *testing.T, steps []*StepConfig) (*Collector, *[]progressMessage) {
+	t.Helper()
+	messages := []progressMessage{}
+	c := &Collector{
+		ResultsDir:     t.TempDir(),
+		Steps:          steps,
+		ClusterVersion: "4.18.0",
+		KubeClient:     kfake.NewSimpleClientset(),
+		ReportProgress: func(message string, completed, total int64) {
+			messages = append(messages, progressMessage{message, completed, total})
+		},
+	}
+	return c, &messages
+}
+
+// TestCollectorRun tests the steps results, progress, JUnit, manifest and raw results.
+func TestCollectorRun(t *testing.T) {
+	c, messages := newTestCollector(t, []*StepConfig{
+		{Step: &fakeStep{name: "step-a", artifacts: []string{"artifacts_a.txt"}}, Timeout: time.Minute},
+		{Step: &fakeStep{name: "step-b", err: errors.New("command failed")}, Timeout: time.Minute},
+		{Step: &fakeStep{name: "step-c", block: true}, Timeout: 100 * time.Millisecond},
+		{Step: &fakeStep{name: "step-d", artifacts: []string{"artifacts_d.txt"}}, Skip: true},
+	})
+	writeE2EMetadata(t, c.Path("artifacts_e2e-metadata-plugin.tar.gz"), "openshift/conformance")
+	_, err := c.KubeClient.CoreV1().ConfigMaps(plugin.EnvNamespace).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "plugin-results-10", Labels: map[string]string{plugin.ResultsDigestLabel: "true"}},
+		Data:       map[string]string{plugin.ResultsDigestPluginKey: "10-openshift-kube-conformance", plugin.ResultsDigestKey: "abc"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	// step results
+	wantStatus := map[string]string{
+		"step-a": StepStatusPassed,
+		"step-b": StepStatusFailed,
+		"step-c": StepStatusFailed,
+		"step-d": StepStatusSkipped,
+	}
+	for _, res := range c.Results() {
+		if res.Status != wantStatus[res.Name] {
+			t.Errorf("step %s status = %s, want %s", res.Name, res.Status, wantStatus[res.Name])
+		}
+	}
+	if res := c.Results()[2]; !strings.Contains(res.Message, "timed out") {
+		t.Errorf("step-c message = %q, want timed out", res.Message)
+	}
+
+	// progress: total is the number of enabled steps, completed is incremented by step.
+	last := (*messages)[len(*messages)-1]
+	if last.message != "status=done" || last.completed != 3 || last.total != 3 {
+		t.Errorf("last progress = %+v, want status=done 3/3", last)
+	}
+	for _, msg := range *messages {
+		if msg.message == "status=running=step-b=substep" && msg.completed != 1 {
+			t.Errorf("progress %q completed = %d, want 1", msg.message, msg.completed)
+		}
+	}
+
+	// JUnit
+	junit, err := os.ReadFile(c.Path(JUnitFile))
+	if err != nil {
+		t.Fatalf("error reading JUnit: %v", err)
+	}
+	for _, want := range []string{`tests="4" failures="2" skipped="1"`, `name="[opct][collector] step step-b"`, `<failure message="command failed">`, `<skipped message="step disabled">`} {
+		if !strings.Contains(string(junit), want) {
+			t.Errorf("JUnit = %s, want to contain %q", string(junit), want)
+		}
+	}
+
+	// manifest
+	data, err := os.ReadFile(c.Path(ManifestFile))
+	if err != nil {
+		t.Fatalf("error reading manifest: %v", err)
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		t.Fatalf("error parsing manifest: %v", err)
+	}
+	gotArtifacts := map[string]string{}
+	for _, art := range manifest.Artifacts {
+		gotArtifacts[art.Name] = art.Plugin + "/" + art.Step
+		if len(art.SHA256) != 64 || art.Size == 0 {
+			t.Errorf("manifest artifact %s sha256=%q size=%d, want checksum and size", art.Name, art.SHA256, art.Size)
+		}
+	}
+	wantArtifacts := map[string]string{
+		"artifacts_e2e-metadata-plugin.tar.gz": "plugin/" + ArtifactSourceReceived,
+		"artifacts_a.txt":                      "99-openshift-artifacts-collector/step-a",
+		JUnitFile:                              "99-openshift-artifacts-collector/" + ArtifactSourceCollector,
+	}
+	if len(gotArtifacts) != len(wantArtifacts) {
+		t.Errorf("manifest artifacts = %v, want %v", gotArtifacts, wantArtifacts)
+	}
+	for name, step := range wantArtifacts {
+		if gotArtifacts[name] != step {
+			t.Errorf("manifest artifact %s step = %q, want %q", name, gotArtifacts[name], step)
+		}
+	}
+	if len(manifest.Steps) != 4 {
+		t.Errorf("manifest steps = %d, want 4", len(manifest.Steps))
+	}
+	if p := manifest.Provenance; manifest.Version != ManifestVersion || p == nil || p.ClusterVersion != "4.18.0" ||
+		p.Plugin != "99-openshift-artifacts-collector" || p.Suites["plugin"] != "openshift/conformance" {
+		t.Errorf("manifest version=%s provenance=%+v, want cluster version and suites", manifest.Version, p)
+	}
+	if p := manifest.Provenance; p == nil || len(p.Results) != 1 || p.Results["10-openshift-kube-conformance"] != "abc" {
+		t.Errorf("manifest provenance=%+v, want the results digest of the plugins", p)
+	}
+	cm, err := c.KubeClient.CoreV1().ConfigMaps(plugin.EnvNamespace).Get(context.TODO(), "plugin-results-99", metav1.GetOptions{})
+	if err != nil || cm.Data[plugin.ResultsDigestKey] != c.ManifestDigest || len(c.ManifestDigest) != 64 {
+		t.Errorf("collector digest ConfigMap = %+v (%v), want the digest %s", cm, err, c.ManifestDigest)
+	}
+
+	// raw results
+	got := listTarGz(t, c.Path(RawResultsFile))
+	want := []string{"./artifacts_a.txt", "./artifacts_collector_junit.xml", "./artifacts_collector_manifest.json", "./artifacts_e2e-metadata-plugin.tar.gz"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("raw results = %v, want %v", got, want)
+	}
+	res, err := VerifyResults(c.Path(RawResultsFile))
+	if err != nil || !res.Valid() || len(res.Verified) != 3 || res.ManifestSHA256 != c.ManifestDigest {
+		t.Errorf("VerifyResults() = %+v (%v), want 3 artifacts verified", res, err)
+	}
+}
+
+// TestCollectorRunStep tests the step result when the timeout is reached.
+func TestCollectorRunStep(t *testing.T) {
+	tests := []struct {
+		name        string
+		step        *fakeStep
+		wantStatus  string
+		wantMessage string
+		wantFile    string
+	}{
+		{
+			name:        "timeout waits the step",
+			step:        &fakeStep{name: "step-a", block: true, partial: "artifacts_a.txt"},
+			wantStatus:  StepStatusFailed,
+			wantMessage: "step timed out after 50ms: context deadline exceeded",
+			wantFile:    "artifacts_a.txt",
+		},
+		{
+			name:       "succeeded at the deadline",
+			step:       &fakeStep{name: "step-b", delay: 100 * time.Millisecond},
+			wantStatus: StepStatusPassed,
+		},
+		{
+			name:        "failed at the deadline",
+			step:        &fakeStep{name: "step-c", delay: 100 * time.Millisecond, err: errors.New("command failed")},
+			wantStatus:  StepStatusFailed,
+			wantMessage: "step timed out after 50ms: command failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCollector(t, nil)
+			res := c.runStep(context.Background(), &StepConfig{Step: tt.step, Timeout: 50 * time.Millisecond})
+			if res.Status != tt.wantStatus || res.Message != tt.wantMessage {
+				t.Errorf("runStep() = %s %q, want %s %q", res.Status, res.Message, tt.wantStatus, tt.wantMessage)
+			}
+			if len(tt.wantFile) == 0 {
+				return
+			}
+			if _, err := os.Stat(c.Path(tt.wantFile)); err != nil {
+				t.Errorf("artifact %s written by the step = %v, want written before runStep returns", tt.wantFile, err)
+			}
+		})
+	}
+}
+
+// writeE2EMetadata creates the e2e metadata archive sent by conformance plugins.
+func writeE2EMetadata(t *testing.T, archive, suite string) {
+	t.Helper()
+	src := t.TempDir()
+	suiteFile := filepath.Join(src, filepath.FromSlash(E2EMetadataSuiteFile))
+	if err := os.MkdirAll(filepath.Dir(suiteFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(suiteFile, []byte(suite+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTarGz(archive, src, []string{"tmp"}); err != nil {
+		t.Fatalf("error writing e2e metadata: %v", err)
+	}
+}
+
+// TestVerifyResults tests the tampered, missing and unlisted artifacts are detected.
+func TestVerifyResults(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"artifacts_a.txt": "a", "artifacts_b.txt": "b", "artifacts_c.txt": "c"}
+	manifest := &Manifest{Version: ManifestVersion}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum, size, err := plugin.FileSHA256(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest.Artifacts = append(manifest.Artifacts, &Artifact{Name: name, Size: size, SHA256: sum})
+	}
+	manifest.Artifacts = append(manifest.Artifacts, &Artifact{Name: "artifacts_missing.txt", Size: 1})
+	data, _ := json.Marshal(manifest)
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// tampered and unlisted artifacts.
+	if err := os.WriteFile(filepath.Join(dir, "artifacts_b.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "artifacts_d.txt"), []byte("d"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), RawResultsFile)
+	if err := createTarGz(archive, dir, []string{"."}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := VerifyResults(archive)
+	if err != nil {
+		t.Fatalf("VerifyResults() unexpected error: %v", err)
+	}
+	if res.Valid() {
+		t.Errorf("VerifyResults() must be invalid")
+	}
+	got := strings.Join([]string{
+		strings.Join(res.Verified, ","), strings.Join(res.Missing, ","),
+		strings.Join(res.Unlisted, ","), strings.Join(res.Mismatched, ","),
+	}, "|")
+	if !strings.HasPrefix(got, "artifacts_a.txt,artifacts_c.txt|artifacts_missing.txt|artifacts_d.txt|artifacts_b.txt: sha256") {
+		t.Errorf("VerifyResults() = %s", got)
+	}
+
+	// results without manifest.
+	if err := os.Remove(filepath.Join(dir, ManifestFile)); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTarGz(archive, dir, []string{"."}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyResults(archive); err == nil || !strings.Contains(err.Error(), "manifest") {
+		t.Errorf("VerifyResults() error = %v, want manifest not found", err)
+	}
+}
+
+// TestVerifyPluginResults tests the plugin results are verified against the results
+// manifest written by the plugin.
+func TestVerifyPluginResults(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"junit_e2e.xml": "<testsuite/>", "failure-excerpts/0001.txt": "test a"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := plugin.ListResultsFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(&plugin.ResultsManifest{Version: plugin.ResultsManifestVersion, Plugin: "10-openshift-kube-conformance", Files: files})
+	if err := os.WriteFile(filepath.Join(dir, plugin.ResultsManifestName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := VerifyPluginResults(dir)
+	if err != nil || !res.Valid() || len(res.Verified) != 2 || res.PluginManifest.Plugin != "10-openshift-kube-conformance" {
+		t.Fatalf("VerifyPluginResults() = %+v (%v), want 2 files verified", res, err)
+	}
+	digest := res.ManifestSHA256
+
+	// tampered and unlisted files.
+	if err := os.WriteFile(filepath.Join(dir, "junit_e2e.xml"), []byte("<testsuite></testsuite>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "extra.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = VerifyPluginResults(dir)
+	if err != nil || res.Valid() || strings.Join(res.Unlisted, ",") != "extra.txt" ||
+		len(res.Mismatched) != 1 || !strings.HasPrefix(res.Mismatched[0], "junit_e2e.xml: size") || res.ManifestSHA256 != digest {
+		t.Errorf("VerifyPluginResults() = %+v (%v), want the tampered and unlisted files", res, err)
+	}
+}
+
+// TestEnvOptions tests the step options from the environment.
+func TestEnvOptions(t *testing.T) {
+	t.Setenv("SKIP_MUST_GATHER", "true")
+	t.Setenv("SKIP_KUBE_BURNER", "false")
+	t.Setenv("COLLECTOR_TIMEOUT_METRICS", "5m")
+	t.Setenv("COLLECTOR_TIMEOUT_PERFORMANCE", "invalid")
+
+	steps := map[string]*StepConfig{}
+	for _, sc := range DefaultSteps() {
+		steps[sc.Step.Name()] = sc
+	}
+	if !steps["must-gather"].Skip {
+		t.Errorf("must-gather Skip = false, want true")
+	}
+	if steps["kube-burner"].Skip {
+		t.Errorf("kube-burner Skip = true, want false")
+	}
+	if steps["metrics"].Skip {
+		t.Errorf("metrics Skip = true, want false (default)")
+	}
+	if steps["metrics"].Timeout != 5*time.Minute {
+		t.Errorf("metrics Timeout = %v, want 5m", steps["metrics"].Timeout)
+	}
+	if steps["performance"].Timeout != 30*time.Minute {
+		t.Errorf("performance Timeout = %v, want default 30m", steps["performance"].Timeout)
+	}
+}
+
+// TestTarGz tests the tarball is created.
+func TestTarGz(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "junit"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "junit", "junit_e2e.xml"), []byte("<testsuite/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "e2e.tar.gz")
+	if err := createTarGz(archive, src, []string{"."}); err != nil {
+		t.Fatalf("createTarGz() unexpected error: %v", err)
+	}
+	if got := strings.Join(listTarGz(t, archive), ","); got != "./junit/,./junit/junit_e2e.xml" {
+		t.Errorf("createTarGz() entries = %s", got)
+	}
+
+}
+
+// listTarGz returns the sorted entries of the tarball.
+func listTarGz(t *testing.T, path string) []string {
+	t.Helper()
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("error opening tarball: %v", err)
+	}
+	defer fd.Close()
+	gr, err := gzip.NewReader(fd)
+	if err != nil {
+		t.Fatalf("error reading tarball: %v", err)
+	}
+	tr := tar.NewReader(gr)
+	names := []string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error reading tarball: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	return names
+}
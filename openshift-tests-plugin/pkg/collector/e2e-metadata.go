@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CleanE2EMetadataStep cleans sensitive data from the e2e metadata archives
+// (artifacts_e2e-metadata-*.tar.gz) sent by the conformance plugins.
+type CleanE2EMetadataStep struct{}
+
+func (s *CleanE2EMetadataStep) Name() string { return "clean-e2e-metadata" }
+
+// Run redacts the archives in place, removing the archives which cannot be redacted.
+func (s *CleanE2EMetadataStep) Run(ctx context.Context, c *Collector) error {
+	archives, err := filepath.Glob(c.Path(E2EMetadataPrefix + "*.tar.gz"))
+	if err != nil {
+		return fmt.Errorf("error listing e2e metadata: %w", err)
+	}
+	log.Infof("Redacting %d e2e metadata archives", len(archives))
+	return redactArtifacts(c, MGCConfigE2E, "artifacts_redaction_e2e-metadata.json", archives...)
+}
@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultKubeBurnerVersion    = "1.6.2"
+	DefaultKubeBurnerCommands   = "node-density node-density-cni cluster-density-v2"
+	DefaultKubeBurnerResultsDir = "/tmp/kube-burner"
+	DefaultKubeBurnerBin        = "/usr/local/bin/kube-burner-ocp"
+)
+
+// kubeBurnerExtraArgs holds the custom arguments for kube-burner profiles.
+var kubeBurnerExtraArgs = map[string][]string{
+	"cluster-density-v2": {"--iterations=1", "--churn-duration=2m0s", "--churn-cycles=2"},
+}
+
+// KubeBurnerStep runs kube-burner-ocp workloads for performance and scale testing,
+// saving the results (local indexing) into the artifacts.
+// https://kube-burner.github.io/kube-burner-ocp/latest/
+type KubeBurnerStep struct {
+	Version    string
+	Commands   []string
+	ResultsDir string
+	Bin        string
+}
+
+// NewKubeBurnerStep creates the kube-burner step with the options from the
+// environment KUBE_BURNER_VERSION and KUBE_BURNER_COMMANDS.
+func NewKubeBurnerStep() *KubeBurnerStep {
+	s := &KubeBurnerStep{
+		Version:    DefaultKubeBurnerVersion,
+		Commands:   strings.Fields(DefaultKubeBurnerCommands),
+		ResultsDir: DefaultKubeBurnerResultsDir,
+		Bin:        DefaultKubeBurnerBin,
+	}
+	if version := os.Getenv("KUBE_BURNER_VERSION"); len(version) > 0 {
+		s.Version = version
+	}
+	if commands := os.Getenv("KUBE_BURNER_COMMANDS"); len(commands) > 0 {
+		s.Commands = strings.Fields(commands)
+	}
+	return s
+}
+
+func (s *KubeBurnerStep) Name() string { return "kube-burner" }
+
+// Run runs each kube-burner profile, saving the results into the artifact
+// artifacts_kube-burner.tar.gz.
+func (s *KubeBurnerStep) Run(ctx context.Context, c *Collector) error {
+	if err := os.MkdirAll(s.ResultsDir, 0755); err != nil {
+		return fmt.Errorf("error creating kube-burner results directory: %w", err)
+	}
+	if err := s.install(ctx, c); err != nil {
+		return err
+	}
+	errs := []error{}
+	for _, command := range s.Commands {
+		c.Progress(fmt.Sprintf("status=running=kube-burner=%s", command))
+		if err := s.runCommand(ctx, c, command); err != nil {
+			errs = append(errs, err)
+		}
+		c.Progress(fmt.Sprintf("status=done=kube-burner=%s", command))
+	}
+
+	log.Info("Saving kube-burner results")
+	entries, err := os.ReadDir(s.ResultsDir)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("error reading kube-burner results: %w", err))...)
+	}
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if err := createTarGz(c.Path("artifacts_kube-burner.tar.gz"), s.ResultsDir, names); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// runCommand runs the kube-burner profile, saving the index into the
+// consolidated results directory.
+func (s *KubeBurnerStep) runCommand(ctx context.Context, c *Collector, command string) error {
+	log.Infof("Running kube-burner %s", command)
+	logFD, err := os.OpenFile(c.Path(fmt.Sprintf("artifacts_kube-burner_%s.log", command)), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening kube-burner log: %w", err)
+	}
+	defer logFD.Close()
+
+	out := io.MultiWriter(os.Stdout, logFD)
+	args := append([]string{command, "--local-indexing"}, kubeBurnerExtraArgs[command]...)
+	cmd := c.Command(ctx, out, s.Bin, args...)
+	cmd.Stderr = out
+	runErr := cmd.Run()
+	if runErr != nil {
+		runErr = fmt.Errorf("kube-burner %s failed: %w", command, runErr)
+	}
+
+	// kube-burner-ocp saves the index to directory collected-metrics-<uuid>
+	indexDirs, _ := filepath.Glob(c.Path("collected-metrics*"))
+	if len(indexDirs) == 0 {
+		return errors.Join(runErr, fmt.Errorf("kube-burner %s index not found", command))
+	}
+	jobID := strings.TrimPrefix(filepath.Base(indexDirs[0]), "collected-metrics-")
+	if err := os.Rename(indexDirs[0], filepath.Join(s.ResultsDir, command)); err != nil {
+		return errors.Join(runErr, fmt.Errorf("error saving kube-burner %s index: %w", command, err))
+	}
+	if err := os.WriteFile(filepath.Join(s.ResultsDir, fmt.Sprintf("id_%s.txt", command)), []byte(jobID+"\n"), 0644); err != nil {
+		return errors.Join(runErr, err)
+	}
+	return runErr
+}
+
+// install downloads kube-burner-ocp when it is not installed.
+func (s *KubeBurnerStep) install(ctx context.Context, c *Collector) error {
+	if _, err := os.Stat(s.Bin); err == nil {
+		return nil
+	}
+	c.Progress("status=running=kube-burner=install")
+	arch := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[runtime.GOARCH]
+	if len(arch) == 0 {
+		arch = runtime.GOARCH
+	}
+	url := fmt.Sprintf("https://github.com/kube-burner/kube-burner-ocp/releases/download/v%s/kube-burner-ocp-V%s-linux-%s.tar.gz",
+		s.Version, s.Version, arch)
+	log.Infof("Installing kube-burner-ocp version %s for %s from %s", s.Version, arch, url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating kube-burner download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading kube-burner: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error downloading kube-burner: unexpected status %s", resp.Status)
+	}
+	return extractFileFromTarGz(resp.Body, "kube-burner-ocp", s.Bin)
+}
+
+// extractFileFromTarGz extracts the file name from the gzip compressed tarball into dest.
+func extractFileFromTarGz(r io.Reader, name, dest string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("error reading tarball: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("file %s not found in tarball", name)
+		}
+		if err != nil {
+			return fmt.Errorf("error reading tarball: %w", err)
+		}
+		if filepath.Base(hdr.Name) != name || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", dest, err)
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return fmt.Errorf("error writing %s: %w", dest, err)
+		}
+		return out.Close()
+	}
+}
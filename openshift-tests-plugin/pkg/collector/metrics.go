@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultMustGatherMonitoringImage   = "quay.io/opct/must-gather-monitoring:v0.5.0"
+	DefaultMustGatherMonitoringVersion = "v0.5.0"
+
+	MetricsDir = "must-gather-metrics"
+)
+
+// MetricsStep extracts metrics from Prometheus within the time frame the OPCT
+// was executed, using the must-gather-monitoring image, saving it as raw data
+// into the artifacts. The Prometheus expressions are extracted from OpenShift Dashboards.
+// The collector script (must-gather-monitoring) was adapted from the original proposal:
+// https://github.com/openshift/must-gather/pull/214
+type MetricsStep struct {
+	// Image is the must-gather-monitoring image (env IMAGE_OVERRIDE_MUST_GATHER).
+	Image string
+	// Version is the must-gather-monitoring image tag used in mirrored
+	// environments (env VERSION_IMAGE_MUST_GATHER).
+	Version string
+	// Dir is the must-gather directory, relative to the results directory.
+	Dir string
+}
+
+// NewMetricsStep creates the metrics step with the image from the environment.
+func NewMetricsStep() *MetricsStep {
+	s := &MetricsStep{
+		Image:   DefaultMustGatherMonitoringImage,
+		Version: DefaultMustGatherMonitoringVersion,
+		Dir:     MetricsDir,
+	}
+	if image := os.Getenv("IMAGE_OVERRIDE_MUST_GATHER"); len(image) > 0 {
+		s.Image = image
+	}
+	if version := os.Getenv("VERSION_IMAGE_MUST_GATHER"); len(version) > 0 {
+		s.Version = version
+	}
+	return s
+}
+
+func (s *MetricsStep) Name() string { return "metrics" }
+
+// Run collects the metrics, cleaning the sensitive data, then packs the data into
+// the artifact artifacts_must-gather-metrics.tar.xz.
+func (s *MetricsStep) Run(ctx context.Context, c *Collector) error {
+	image := c.Image(s.Image, "must-gather-monitoring:"+s.Version)
+	log.Info("Collecting metrics")
+	if err := c.Oc(ctx, nil, "adm", "must-gather", "--dest-dir="+s.Dir, "--image="+image); err != nil {
+		log.Warnf("must-gather metrics finished with errors: %v", err)
+	}
+
+	// must-gather saves the data in a directory with the image name.
+	monitoringDirs, _ := filepath.Glob(filepath.Join(c.Path(s.Dir), "*", "monitoring"))
+	if len(monitoringDirs) == 0 {
+		return fmt.Errorf("must-gather metrics not found in %s", s.Dir)
+	}
+	srcDir := filepath.Dir(monitoringDirs[0])
+
+	log.Info("Redacting must-gather-metrics")
+	if err := redactArtifacts(c, MGCConfigMustGather, "artifacts_redaction_must-gather-metrics.json", srcDir); err != nil {
+		return fmt.Errorf("redaction failed for metrics, skipping metrics packing: %w", err)
+	}
+	for _, name := range []string{"timestamp", "event-filter.html"} {
+		if err := copyFile(filepath.Join(c.Path(s.Dir), name), filepath.Join(srcDir, "monitoring", name)); err != nil {
+			log.Warnf("unable to copy %s to metrics: %v", name, err)
+		}
+	}
+
+	log.Info("Packing must-gather-metrics")
+	return c.Exec(ctx, nil, "tar", "cfJ", "artifacts_must-gather-metrics.tar.xz", "-C", srcDir, "monitoring/")
+}
+
+// copyFile copies the file src to dest.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
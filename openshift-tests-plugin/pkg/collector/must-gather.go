@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/redact"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MustGatherDir = "must-gather-opct"
+
+	// mcoRedactionConfig holds the redaction rules of the MCO resources, applied with the
+	// must-gather rules when mco-sanitize is not available or failed. MachineConfigs
+	// contain pull secrets, kubeconfigs and keys in the contents of the ignition files
+	// (data URLs), and the password hashes of the users.
+	mcoRedactionConfig = `config:
+  obfuscate:
+  - type: Regex
+    target: FileContents
+    regex: 'data:[^\s"'',]*,[^\s"'']+'
+  - type: Regex
+    target: FileContents
+    regex: 'passwordHash"?:\s*"?[^\s",]+'
+`
+)
+
+// reInternalRegistryPullSecret matches the pull secret in the controllerconfig.
+var reInternalRegistryPullSecret = regexp.MustCompile(`(internalRegistryPullSecret:\s*).*`)
+
+// MustGatherStep collects must-gather, cleaning the sensitive data, and
+// generating reports from it.
+type MustGatherStep struct {
+	// Dir is the must-gather directory, relative to the results directory.
+	Dir string
+}
+
+// NewMustGatherStep creates the must-gather step.
+func NewMustGatherStep() *MustGatherStep {
+	return &MustGatherStep{Dir: MustGatherDir}
+}
+
+func (s *MustGatherStep) Name() string { return "must-gather" }
+
+// Run collects must-gather, the opct namespace inspect, then packs the data into
+// the artifact artifacts_must-gather.tar.xz.
+func (s *MustGatherStep) Run(ctx context.Context, c *Collector) error {
+	errs := []error{}
+	log.Info("Collecting must-gather")
+	if err := c.Oc(ctx, nil, "adm", "must-gather", "--dest-dir="+s.Dir); err != nil {
+		errs = append(errs, err)
+	}
+
+	log.Info("Inspecting Namespace opct")
+	if err := c.Oc(ctx, nil, "adm", "inspect", "namespace/opct", "--dest-dir="+filepath.Join(s.Dir, "inspect-opct")); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Pre-process data from must-gather to avoid client-side extra steps.
+	if err := s.clean(ctx, c); err != nil {
+		errs = append(errs, err)
+	}
+
+	log.Info("Generating camgi report")
+	camgi, err := os.Create(c.Path("artifacts_must-gather_camgi.html"))
+	if err == nil {
+		if err := c.Exec(ctx, camgi, "camgi", s.Dir+"/"); err != nil {
+			log.Warnf("unable to generate camgi report: %v", err)
+		}
+		camgi.Close()
+	}
+
+	log.Info("Packing must-gather")
+	dirs, err := filepath.Glob(c.Path(s.Dir + "*"))
+	if err != nil || len(dirs) == 0 {
+		return errors.Join(append(errs, fmt.Errorf("must-gather directory %s not found", s.Dir))...)
+	}
+	args := []string{"cfJ", "artifacts_must-gather.tar.xz"}
+	for _, dir := range dirs {
+		args = append(args, filepath.Base(dir))
+	}
+	if err := c.Exec(ctx, nil, "tar", args...); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// clean removes sensitive data from must-gather. Sensitive data is by default
+// cleaned by must-gather, the steps here must ensure edge scenarios not covered
+// in must-gather workflow. The must-gather is removed when the redaction fails.
+func (s *MustGatherStep) clean(ctx context.Context, c *Collector) error {
+	mgDir := c.Path(s.Dir)
+
+	// always clean internalRegistryPullSecret first, the value is not matched by the rules.
+	controllerConfigs, _ := filepath.Glob(filepath.Join(mgDir, "*", "cluster-scoped-resources",
+		"machineconfiguration.openshift.io", "controllerconfigs", "machine-config-controller.yaml"))
+	for _, file := range controllerConfigs {
+		if err := redactInternalRegistryPullSecret(file); err != nil {
+			return fmt.Errorf("unable to redact %s: %w", file, err)
+		}
+	}
+
+	// sanitize MCO resources, by the redaction engine when mco-sanitize is not available.
+	sanitized := false
+	if _, err := exec.LookPath("mco-sanitize"); err != nil {
+		log.Warn("mco-sanitize not available, redacting the MCO resources by the redaction engine")
+	} else if err := c.Exec(ctx, nil, "mco-sanitize", "--input="+s.Dir); err != nil {
+		log.Warnf("mco-sanitize failed, redacting the MCO resources by the redaction engine: %v", err)
+	} else {
+		sanitized = true
+	}
+	var rules []*redact.Rule
+	if !sanitized {
+		var err error
+		if rules, err = mcoRules(); err != nil {
+			log.Errorf("Removing %s, redaction failed: %v", s.Dir, err)
+			return errors.Join(err, os.RemoveAll(mgDir))
+		}
+	}
+
+	log.Info("Redacting must-gather")
+	return redactArtifactsWithRules(c, MGCConfigMustGather, "artifacts_redaction_must-gather.json", rules, mgDir)
+}
+
+// mcoRules returns the redaction rules of the MCO resources, reported with the prefix
+// 'mco-' in the redaction report.
+func mcoRules() ([]*redact.Rule, error) {
+	red, err := redact.ParseConfig([]byte(mcoRedactionConfig))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MCO redaction config: %w", err)
+	}
+	for _, rule := range red.Rules {
+		rule.Name = "mco-" + rule.Name
+	}
+	return red.Rules, nil
+}
+
+// redactInternalRegistryPullSecret replaces the internalRegistryPullSecret value in the file.
+func redactInternalRegistryPullSecret(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	redacted := reInternalRegistryPullSecret.ReplaceAll(data, []byte(`${1}"<sensitive>"`))
+	return os.WriteFile(file, redacted, 0644)
+}
@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultEtcdFioImage = "quay.io/openshift-scale/etcd-perf:latest"
+
+	// etcdFioFsyncPrefix is the line reporting the fsync p99 in the etcd-perf output.
+	etcdFioFsyncPrefix = "INFO: 99th percentile of fsync is "
+)
+
+// etcdFioNodeGroup is the group of nodes where the etcd fio is executed.
+type etcdFioNodeGroup struct {
+	Role     string
+	Selector string
+	MaxNodes int
+	// Script is the command executed in the node host.
+	Script string
+}
+
+// PerformanceStep runs the recommended method to measure the disk performance
+// for etcd deployments (etcd fio), in control plane and a sample of worker nodes.
+type PerformanceStep struct {
+	Image string
+}
+
+// NewPerformanceStep creates the performance step.
+func NewPerformanceStep() *PerformanceStep {
+	return &PerformanceStep{Image: DefaultEtcdFioImage}
+}
+
+func (s *PerformanceStep) Name() string { return "performance" }
+
+// Run runs the etcd fio in up to three control plane nodes, and two worker nodes.
+// The step runs after must-gather to prevent impacting in etcd logs.
+func (s *PerformanceStep) Run(ctx context.Context, c *Collector) error {
+	image := c.Image(s.Image, "etcd-perf:latest")
+	groups := []etcdFioNodeGroup{
+		{
+			Role:     "controlplane",
+			Selector: "node-role.kubernetes.io/master",
+			MaxNodes: 3,
+			Script:   fmt.Sprintf("podman run --volume /var/lib/etcd:/var/lib/etcd:Z %s", image),
+		},
+		{
+			Role:     "worker",
+			Selector: "!node-role.kubernetes.io/master",
+			MaxNodes: 2,
+			Script:   fmt.Sprintf("mkdir /var/cache/opct; podman run --volume /var/cache/opct:/var/lib/etcd:Z %s", image),
+		},
+	}
+	kclient, err := c.Kube()
+	if err != nil {
+		return fmt.Errorf("unable to create kube client: %w", err)
+	}
+
+	errs := []error{}
+	for _, group := range groups {
+		log.Infof("Running etcd fio on %s nodes", group.Role)
+		nodes, err := kclient.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: group.Selector})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to list %s nodes: %w", group.Role, err))
+			continue
+		}
+		for idx, node := range nodes.Items {
+			if idx >= group.MaxNodes {
+				break
+			}
+			log.Infof("etcd fio %s#%d: %s", group.Role, idx, node.Name)
+			c.Progress(fmt.Sprintf("status=running=collecting performance data=fio=%s[%d]=%s", group.Role, idx, node.Name))
+			resultFile := c.Path(fmt.Sprintf("artifacts_performance_etcdfio_%s-%d.txt", group.Role, idx))
+			if err := s.runNode(ctx, c, node.Name, group.Script, resultFile); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runNode runs the etcd fio in the node, saving the output in resultFile,
+// appended by the summary line etcdfio=<node>=<fsync p99>.
+func (s *PerformanceStep) runNode(ctx context.Context, c *Collector, node, script, resultFile string) error {
+	fd, err := os.Create(resultFile)
+	if err != nil {
+		return fmt.Errorf("error creating result file %s: %w", resultFile, err)
+	}
+	defer fd.Close()
+	runErr := c.Oc(ctx, fd, "debug", "node/"+node, "--", "chroot", "/host", "/bin/bash", "-c", script)
+
+	p99, err := parseEtcdFioFsync(resultFile)
+	if err != nil {
+		return errors.Join(runErr, err)
+	}
+	if _, err := fmt.Fprintf(fd, "etcdfio=%s=%s\n", node, p99); err != nil {
+		return errors.Join(runErr, fmt.Errorf("error writing result file %s: %w", resultFile, err))
+	}
+	return runErr
+}
+
+// parseEtcdFioFsync returns the fsync p99 reported by etcd-perf.
+func parseEtcdFioFsync(resultFile string) (string, error) {
+	fd, err := os.Open(resultFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading result file %s: %w", resultFile, err)
+	}
+	defer fd.Close()
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, etcdFioFsyncPrefix) {
+			return strings.TrimPrefix(line, etcdFioFsyncPrefix), nil
+		}
+	}
+	return "", scanner.Err()
+}
@@ -0,0 +1,326 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/version"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	StepStatusPassed  = "passed"
+	StepStatusFailed  = "failed"
+	StepStatusSkipped = "skipped"
+
+	// ArtifactSourceReceived is the source of artifacts sent by other plugins
+	// to the collector before the steps start (e.g. e2e metadata).
+	ArtifactSourceReceived = "received"
+	// ArtifactSourceCollector is the source of the artifacts created by the
+	// collector after the steps (JUnit).
+	ArtifactSourceCollector = "collector"
+
+	// ManifestVersion is the version of the manifest format.
+	ManifestVersion = "v1"
+)
+
+// StepResult holds the result of a collector step.
+type StepResult struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// Duration returns the step execution time.
+func (r *StepResult) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt).Truncate(time.Millisecond)
+}
+
+// Artifact is a file produced in the results directory.
+type Artifact struct {
+	Name string `json:"name"`
+	// Plugin is the plugin which produced the artifact.
+	Plugin string `json:"plugin,omitempty"`
+	// Step is the collector step which produced the artifact, 'received' or 'collector'.
+	Step    string    `json:"step"`
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256,omitempty"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Provenance describes the environment which produced the artifacts.
+type Provenance struct {
+	// Plugin is the plugin which created the manifest.
+	Plugin string `json:"plugin"`
+	// PluginVersion is the openshift-tests-plugin version.
+	PluginVersion  string `json:"pluginVersion"`
+	ClusterVersion string `json:"clusterVersion,omitempty"`
+	// Suites is the suite executed by each plugin, read from the e2e metadata.
+	Suites map[string]string `json:"suites,omitempty"`
+	// Results is the digest (SHA-256) of the results manifest of each plugin, read from
+	// the results digest ConfigMaps.
+	Results map[string]string `json:"results,omitempty"`
+}
+
+// Manifest holds the collector steps and the artifacts produced by each step.
+// The artifacts are packed with the manifest into the raw result file, and are
+// validated against the manifest with VerifyResults.
+type Manifest struct {
+	Version    string        `json:"version"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	Provenance *Provenance   `json:"provenance,omitempty"`
+	Steps      []*StepResult `json:"steps"`
+	Artifacts  []*Artifact   `json:"artifacts"`
+}
+
+// addArtifacts records the artifacts created or updated since the previous snapshot.
+func (m *Manifest) addArtifacts(current, previous map[string]*Artifact, step string) {
+	names := []string{}
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		art := current[name]
+		if prev, ok := previous[name]; ok {
+			if prev.Size == art.Size && prev.ModTime.Equal(art.ModTime) {
+				continue
+			}
+			// Artifact updated by the step (e.g. redacted), keeping the origin.
+			for _, recorded := range m.Artifacts {
+				if recorded.Name == name {
+					recorded.Size = art.Size
+					recorded.ModTime = art.ModTime
+				}
+			}
+			continue
+		}
+		m.Artifacts = append(m.Artifacts, &Artifact{
+			Name:    name,
+			Plugin:  artifactPlugin(name, step),
+			Step:    step,
+			Size:    art.Size,
+			ModTime: art.ModTime,
+		})
+	}
+}
+
+// artifactPlugin returns the plugin which produced the artifact: the plugin
+// name (with the shard suffix) of the e2e artifacts received from the conformance
+// plugins, or the collector plugin.
+func artifactPlugin(name, step string) string {
+	if step != ArtifactSourceReceived {
+		return plugin.PluginAlias99
+	}
+	for _, prefix := range []string{E2EMetadataPrefix, E2ETestsPrefix} {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimPrefix(name, prefix)
+			return strings.TrimSuffix(strings.TrimSuffix(name, ".tar.gz"), ".txt")
+		}
+	}
+	return ""
+}
+
+// readSuites returns the suite executed by each plugin, read from the e2e metadata.
+func (c *Collector) readSuites() map[string]string {
+	suites := map[string]string{}
+	for _, art := range c.manifest.Artifacts {
+		if !strings.HasPrefix(art.Name, E2EMetadataPrefix) {
+			continue
+		}
+		data, err := readTarGzFile(c.Path(art.Name), E2EMetadataSuiteFile)
+		if err != nil {
+			log.Warnf("unable to read the suite from %s: %v", art.Name, err)
+			continue
+		}
+		suites[art.Plugin] = strings.TrimSpace(string(data))
+	}
+	return suites
+}
+
+// snapshotArtifacts lists the artifacts in the results directory.
+func (c *Collector) snapshotArtifacts() (map[string]*Artifact, error) {
+	files, err := filepath.Glob(c.Path(ArtifactPrefix + "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing artifacts: %w", err)
+	}
+	snapshot := make(map[string]*Artifact, len(files))
+	for _, f := range files {
+		name := filepath.Base(f)
+		if name == JUnitFile || name == ManifestFile {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		snapshot[name] = &Artifact{Name: name, Size: info.Size(), ModTime: info.ModTime()}
+	}
+	return snapshot, nil
+}
+
+// writeManifest writes the collector manifest into the results directory, with
+// the checksum and the provenance of each artifact.
+func (c *Collector) writeManifest() error {
+	c.manifest.Version = ManifestVersion
+	c.manifest.CreatedAt = time.Now().UTC()
+	c.manifest.Steps = c.results
+	c.manifest.Provenance = &Provenance{
+		Plugin:         plugin.PluginAlias99,
+		PluginVersion:  version.GetFullVersion(),
+		ClusterVersion: c.ClusterVersion,
+		Suites:         c.readSuites(),
+		Results:        c.readResultsDigests(),
+	}
+	c.manifest.Artifacts = append(c.manifest.Artifacts, &Artifact{
+		Name:   JUnitFile,
+		Plugin: plugin.PluginAlias99,
+		Step:   ArtifactSourceCollector,
+	})
+	for _, art := range c.manifest.Artifacts {
+		sum, size, err := plugin.FileSHA256(c.Path(art.Name))
+		if err != nil {
+			return fmt.Errorf("error computing checksum of %s: %w", art.Name, err)
+		}
+		info, err := os.Stat(c.Path(art.Name))
+		if err != nil {
+			return err
+		}
+		art.SHA256, art.Size, art.ModTime = sum, size, info.ModTime()
+	}
+	data, err := json.MarshalIndent(c.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing manifest: %w", err)
+	}
+	if err := os.WriteFile(c.Path(ManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	c.ManifestDigest = hex.EncodeToString(sum[:])
+	log.Infof("Collector manifest created at %s (sha256 %s)", c.Path(ManifestFile), c.ManifestDigest)
+	return nil
+}
+
+// readResultsDigests returns the digest of the results manifest of each plugin, read
+// from the results digest ConfigMaps saved by the plugins.
+func (c *Collector) readResultsDigests() map[string]string {
+	digests := map[string]string{}
+	kclient, err := c.Kube()
+	if err != nil {
+		log.Warnf("unable to read the results digests: %v", err)
+		return digests
+	}
+	cms, err := kclient.CoreV1().ConfigMaps(plugin.EnvNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: plugin.ResultsDigestLabel})
+	if err != nil {
+		log.Warnf("unable to read the results digests: %v", err)
+		return digests
+	}
+	for _, cm := range cms.Items {
+		name := cm.Data[plugin.ResultsDigestPluginKey]
+		if len(name) == 0 || name == plugin.PluginAlias99 {
+			continue
+		}
+		digests[name] = cm.Data[plugin.ResultsDigestKey]
+	}
+	return digests
+}
+
+// saveManifestDigest saves the digest of the collector manifest to the results digest
+// ConfigMap of the collector, recording the digest out of the results.
+func (c *Collector) saveManifestDigest() error {
+	kclient, err := c.Kube()
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "plugin-results-" + plugin.PluginId99,
+			Namespace: plugin.EnvNamespace,
+			Labels:    map[string]string{plugin.ResultsDigestLabel: "true"},
+		},
+		Data: map[string]string{
+			plugin.ResultsDigestPluginKey: plugin.PluginAlias99,
+			plugin.ResultsDigestKey:       c.ManifestDigest,
+		},
+	}
+	cms := kclient.CoreV1().ConfigMaps(plugin.EnvNamespace)
+	_, err = cms.Create(context.TODO(), configMap, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = cms.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name         `xml:"testsuite"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    string        `xml:"time,attr"`
+	Skipped *junitMessage `xml:"skipped,omitempty"`
+	Failure *junitMessage `xml:"failure,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes the result of each step as a test case into the results directory.
+func (c *Collector) writeJUnit() error {
+	suite := &junitTestSuite{Name: "opct-artifacts-collector"}
+	total := time.Duration(0)
+	for _, res := range c.results {
+		tc := &junitTestCase{
+			Name: fmt.Sprintf("[opct][collector] step %s", res.Name),
+			Time: "0.0",
+		}
+		switch res.Status {
+		case StepStatusSkipped:
+			tc.Skipped = &junitMessage{Message: res.Message}
+			suite.Skipped++
+		case StepStatusFailed:
+			tc.Failure = &junitMessage{Message: res.Message}
+			suite.Failures++
+		}
+		if res.Status != StepStatusSkipped {
+			tc.Time = fmt.Sprintf("%.1f", res.Duration().Seconds())
+			total += res.Duration()
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+	suite.Time = fmt.Sprintf("%.1f", total.Seconds())
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing JUnit: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(c.Path(JUnitFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing JUnit: %w", err)
+	}
+	log.Infof("JUnit file created at %s", c.Path(JUnitFile))
+	return nil
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/redact"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MGCConfigMustGather is the redaction config (must-gather-clean format) of must-gather data.
+	MGCConfigMustGather = "/plugin/mgc-config-mustgather.yaml"
+	// MGCConfigE2E is the redaction config (must-gather-clean format) of e2e metadata.
+	MGCConfigE2E = "/plugin/mgc-config-e2e.yaml"
+)
+
+// DefaultSteps returns the collector steps in the execution order, with the
+// options from the environment:
+// - SKIP_MUST_GATHER, SKIP_PERFORMANCE, SKIP_METRICS: skip the step when not 'false'. Default: false.
+// - SKIP_KUBE_BURNER: skip kube-burner when not 'false'. Default: true (experimental).
+// - COLLECTOR_TIMEOUT_<STEP>: step timeout (duration), example: COLLECTOR_TIMEOUT_MUST_GATHER=90m.
+func DefaultSteps() []*StepConfig {
+	steps := []*StepConfig{
+		// must-gather runs first to not collect non-e2e workloads (performance tests) in the reports.
+		{Step: NewMustGatherStep(), Timeout: 60 * time.Minute, Skip: envSkip("SKIP_MUST_GATHER", false)},
+		{Step: NewPerformanceStep(), Timeout: 30 * time.Minute, Skip: envSkip("SKIP_PERFORMANCE", false)},
+		{Step: NewMetricsStep(), Timeout: 30 * time.Minute, Skip: envSkip("SKIP_METRICS", false)},
+		{Step: NewKubeBurnerStep(), Timeout: 120 * time.Minute, Skip: envSkip("SKIP_KUBE_BURNER", true)},
+		{Step: &CleanE2EMetadataStep{}, Timeout: 15 * time.Minute},
+	}
+	for _, sc := range steps {
+		sc.Timeout = envTimeout(sc.Step.Name(), sc.Timeout)
+	}
+	return steps
+}
+
+// envSkip returns true when the environment variable is set to a value other than 'false'.
+func envSkip(name string, def bool) bool {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return def
+	}
+	return value != "false"
+}
+
+// envTimeout returns the step timeout from the environment COLLECTOR_TIMEOUT_<STEP>.
+func envTimeout(step string, def time.Duration) time.Duration {
+	name := "COLLECTOR_TIMEOUT_" + strings.ToUpper(strings.ReplaceAll(step, "-", "_"))
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return def
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		log.Errorf("invalid %s %q, using default %v", name, value, def)
+		return def
+	}
+	return timeout
+}
+
+// redactArtifacts redacts in place the files or directories with the config,
+// saving the redaction report into reportFile. The redaction fails closed: an
+// artifact which cannot be redacted is removed, so it is never collected.
+func redactArtifacts(c *Collector, config, reportFile string, paths ...string) error {
+	return redactArtifactsWithRules(c, config, reportFile, nil, paths...)
+}
+
+// redactArtifactsWithRules redacts the artifacts as redactArtifacts, applying the rules
+// in addition to the rules of the config.
+func redactArtifactsWithRules(c *Collector, config, reportFile string, rules []*redact.Rule, paths ...string) error {
+	redactor, cfgErr := redact.LoadConfig(config)
+	if cfgErr == nil {
+		redactor.Rules = append(redactor.Rules, rules...)
+	}
+	errs := []error{}
+	report := redact.NewReport()
+	for _, p := range paths {
+		err := cfgErr
+		if err == nil {
+			var r *redact.Report
+			if r, err = redactor.Redact(p, p); err == nil {
+				report.Merge(r)
+				continue
+			}
+		}
+		log.Errorf("Removing %s, redaction failed: %v", filepath.Base(p), err)
+		if rerr := os.RemoveAll(p); rerr != nil {
+			err = errors.Join(err, fmt.Errorf("error removing unredacted %s: %w", p, rerr))
+		}
+		errs = append(errs, err)
+	}
+	if err := report.Save(c.Path(reportFile)); err != nil {
+		errs = append(errs, err)
+	}
+	log.Infof("Redacted %d files, %d replacements (see %s)", report.Scanned, report.Total, reportFile)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRedactMustGather tests the sensitive data is removed from MCO resources, and the
+// replacements are recorded in the redaction report.
+func TestRedactMustGather(t *testing.T) {
+	mgDir := t.TempDir()
+	mcoDir := filepath.Join(mgDir, "quay-io-image", "cluster-scoped-resources", "machineconfiguration.openshift.io")
+	ccDir := filepath.Join(mcoDir, "controllerconfigs")
+	if err := os.MkdirAll(ccDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	ccFile := filepath.Join(ccDir, "machine-config-controller.yaml")
+	if err := os.WriteFile(ccFile, []byte("spec:\n  internalRegistryPullSecret: eyJhdXRocyI6e30=\n  ipFamilies: IPv4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := redactInternalRegistryPullSecret(ccFile); err != nil {
+		t.Fatalf("redactInternalRegistryPullSecret() unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(ccFile)
+	want := "spec:\n  internalRegistryPullSecret: \"<sensitive>\"\n  ipFamilies: IPv4\n"
+	if string(data) != want {
+		t.Errorf("redactInternalRegistryPullSecret() = %q, want %q", string(data), want)
+	}
+
+	// MCO resources redacted by the redaction engine, reported in the redaction report.
+	mcFile := filepath.Join(mcoDir, "machineconfigs", "00-worker.yaml")
+	if err := os.MkdirAll(filepath.Dir(mcFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mc := "spec:\n  config:\n    passwd:\n      users:\n      - name: core\n        passwordHash: $6$abc\n" +
+		"    storage:\n      files:\n      - contents:\n          source: data:,%7B%22auths%22%3A%7B%7D%7D\n        path: /var/lib/kubelet/config.json\n" +
+		"      - contents:\n          source: data:text/plain;charset=utf-8;base64,c2VjcmV0\n        path: /etc/kubernetes/kubeconfig\n"
+	if err := os.WriteFile(mcFile, []byte(mc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(t.TempDir(), "mgc-config.yaml")
+	if err := os.WriteFile(config, []byte("config:\n  obfuscate:\n  - type: Regex\n    target: FileContents\n    regex: \"sha256~[A-Za-z0-9_-]+\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := mcoRules()
+	if err != nil {
+		t.Fatalf("mcoRules() unexpected error: %v", err)
+	}
+	c := &Collector{ResultsDir: filepath.Dir(mgDir)}
+	if err := redactArtifactsWithRules(c, config, "artifacts_redaction_must-gather.json", rules, mgDir); err != nil {
+		t.Fatalf("redactArtifactsWithRules() unexpected error: %v", err)
+	}
+	data, err = os.ReadFile(mcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"$6$abc", "auths", "c2VjcmV0", "data:"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("MCO resource = %s, want %q redacted", string(data), secret)
+		}
+	}
+	if !strings.Contains(string(data), "path: /etc/kubernetes/kubeconfig") {
+		t.Errorf("MCO resource = %s, want the file paths kept", string(data))
+	}
+	report, err := os.ReadFile(c.Path("artifacts_redaction_must-gather.json"))
+	if err != nil || !strings.Contains(string(report), `"mco-Regex#0": 2`) || !strings.Contains(string(report), `"mco-Regex#1": 1`) {
+		t.Errorf("redaction report = %s (%v), want the MCO replacements", string(report), err)
+	}
+}
+
+// TestParseEtcdFioFsync tests the fsync p99 is extracted from etcd-perf output.
+func TestParseEtcdFioFsync(t *testing.T) {
+	resultFile := filepath.Join(t.TempDir(), "artifacts_performance_etcdfio_controlplane-0.txt")
+	output := "INFO: Running fio...\nINFO: 99th percentile of fsync is 2637824 ns\nINFO: 99th percentile of the fsync is within the suggested threshold\n"
+	if err := os.WriteFile(resultFile, []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseEtcdFioFsync(resultFile)
+	if err != nil {
+		t.Fatalf("parseEtcdFioFsync() unexpected error: %v", err)
+	}
+	if got != "2637824 ns" {
+		t.Errorf("parseEtcdFioFsync() = %q, want %q", got, "2637824 ns")
+	}
+}
+
+// TestRedactArtifacts tests the artifacts are redacted in place, removing the
+// artifacts which cannot be redacted.
+func TestRedactArtifacts(t *testing.T) {
+	c := &Collector{ResultsDir: t.TempDir()}
+	config := filepath.Join(t.TempDir(), "mgc-config.yaml")
+	if err := os.WriteFile(config, []byte("config:\n  obfuscate:\n  - type: Regex\n    target: FileContents\n    regex: \"sha256~[A-Za-z0-9_-]+\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgDir := c.Path("must-gather")
+	if err := os.MkdirAll(mgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mgDir, "oauth.yaml"), []byte("token: sha256~abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := c.Path("artifacts_e2e-metadata-plugin.tar.gz")
+	if err := os.WriteFile(corrupted, []byte("not a gzip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := redactArtifacts(c, config, "artifacts_redaction.json", mgDir, corrupted); err == nil {
+		t.Errorf("redactArtifacts() must fail with the corrupted archive")
+	}
+	if data, err := os.ReadFile(filepath.Join(mgDir, "oauth.yaml")); err != nil || string(data) != "token: xxxxxxxxxx\n" {
+		t.Errorf("redacted file = %q (%v), want token redacted", string(data), err)
+	}
+	if _, err := os.Stat(corrupted); !os.IsNotExist(err) {
+		t.Errorf("unredacted archive must be removed")
+	}
+	if data, err := os.ReadFile(c.Path("artifacts_redaction.json")); err != nil || !strings.Contains(string(data), `"oauth.yaml"`) {
+		t.Errorf("redaction report = %s (%v), want oauth.yaml", string(data), err)
+	}
+
+	// invalid config removes all artifacts.
+	if err := redactArtifacts(c, filepath.Join(c.ResultsDir, "missing.yaml"), "artifacts_redaction.json", mgDir); err == nil {
+		t.Errorf("redactArtifacts() must fail with missing config")
+	}
+	if _, err := os.Stat(mgDir); !os.IsNotExist(err) {
+		t.Errorf("artifact must be removed when the config is invalid")
+	}
+}
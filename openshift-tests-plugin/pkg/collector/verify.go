@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+// VerifyResult holds the validation of the raw result file against its manifest.
+type VerifyResult struct {
+	Manifest *Manifest
+	// PluginManifest is the manifest of the plugin results, verified by VerifyPluginResults.
+	PluginManifest *plugin.ResultsManifest
+	// ManifestSHA256 is the digest of the manifest.
+	ManifestSHA256 string
+	// Verified are the artifacts matching the manifest size and checksum.
+	Verified []string
+	// Missing are the artifacts listed in the manifest not found in the tarball.
+	Missing []string
+	// Unlisted are the files in the tarball not listed in the manifest.
+	Unlisted []string
+	// Mismatched are the artifacts with size or checksum different of the manifest.
+	Mismatched []string
+}
+
+// Valid returns true when all files in the tarball match the manifest.
+func (r *VerifyResult) Valid() bool {
+	return len(r.Missing) == 0 && len(r.Unlisted) == 0 && len(r.Mismatched) == 0
+}
+
+// tarEntrySum is the checksum of a tarball entry, or of a file of the results.
+type tarEntrySum struct {
+	size   int64
+	sha256 string
+}
+
+// VerifyResults validates the raw result file (raw-results.tar.gz) against the
+// manifest packed in it, checking the size and SHA-256 of each artifact.
+func VerifyResults(tarball string) (*VerifyResult, error) {
+	fd, err := os.Open(tarball)
+	if err != nil {
+		return nil, fmt.Errorf("error opening results %s: %w", tarball, err)
+	}
+	defer fd.Close()
+	gr, err := gzip.NewReader(fd)
+	if err != nil {
+		return nil, fmt.Errorf("error reading results %s: %w", tarball, err)
+	}
+	defer gr.Close()
+
+	var manifestData []byte
+	entries := map[string]*tarEntrySum{}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading results %s: %w", tarball, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := tarEntryName(hdr.Name)
+		if name == ManifestFile {
+			if manifestData, err = io.ReadAll(tr); err != nil {
+				return nil, fmt.Errorf("error reading manifest: %w", err)
+			}
+			continue
+		}
+		h := sha256.New()
+		size, err := io.Copy(h, tr)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+		entries[name] = &tarEntrySum{size: size, sha256: hex.EncodeToString(h.Sum(nil))}
+	}
+	if manifestData == nil {
+		return nil, fmt.Errorf("manifest %s not found in %s", ManifestFile, tarball)
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(manifestData, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", ManifestFile, err)
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %q, want %q", manifest.Version, ManifestVersion)
+	}
+
+	sum := sha256.Sum256(manifestData)
+	res := &VerifyResult{Manifest: manifest, ManifestSHA256: hex.EncodeToString(sum[:])}
+	for _, art := range manifest.Artifacts {
+		res.verify(entries, art.Name, art.Size, art.SHA256)
+	}
+	res.finish(entries)
+	return res, nil
+}
+
+// VerifyPluginResults validates the results of a conformance plugin (the results package
+// extracted by the aggregator, plugins/<plugin>/results/global) against the results
+// manifest in it, checking the size and SHA-256 of each file.
+func VerifyPluginResults(dir string) (*VerifyResult, error) {
+	manifest, digest, err := plugin.ReadResultsManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := plugin.ListResultsFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading results %s: %w", dir, err)
+	}
+	entries := make(map[string]*tarEntrySum, len(files))
+	for _, f := range files {
+		entries[f.Name] = &tarEntrySum{size: f.Size, sha256: f.SHA256}
+	}
+	res := &VerifyResult{PluginManifest: manifest, ManifestSHA256: digest}
+	for _, f := range manifest.Files {
+		res.verify(entries, f.Name, f.Size, f.SHA256)
+	}
+	res.finish(entries)
+	return res, nil
+}
+
+// verify checks the file listed in the manifest against the entry found in the results.
+func (r *VerifyResult) verify(entries map[string]*tarEntrySum, name string, size int64, sum string) {
+	entry, ok := entries[name]
+	if !ok {
+		r.Missing = append(r.Missing, name)
+		return
+	}
+	delete(entries, name)
+	switch {
+	case entry.size != size:
+		r.Mismatched = append(r.Mismatched, fmt.Sprintf("%s: size %d, manifest %d", name, entry.size, size))
+	case entry.sha256 != sum:
+		r.Mismatched = append(r.Mismatched, fmt.Sprintf("%s: sha256 %s, manifest %s", name, entry.sha256, sum))
+	default:
+		r.Verified = append(r.Verified, name)
+	}
+}
+
+// finish records the entries not listed in the manifest, sorting the results.
+func (r *VerifyResult) finish(entries map[string]*tarEntrySum) {
+	for name := range entries {
+		r.Unlisted = append(r.Unlisted, name)
+	}
+	sort.Strings(r.Verified)
+	sort.Strings(r.Missing)
+	sort.Strings(r.Unlisted)
+	sort.Strings(r.Mismatched)
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(`
+runMode: upgrade
+upgradeReleases: quay.io/openshift-release-dev/ocp-release:4.20.1-x86_64
+devModeCount: 10
+blocker:
+  stallTimeout: 30m
+  stallPolicy: fail
+shard:
+  count: 4
+  index: 1
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEV_MODE_COUNT", "20")
+	t.Setenv("BLOCKER_STALL_POLICY", "")
+	t.Setenv("GINKGO_SKIP", "")
+	t.Setenv("OPENSHIFT_TESTS_MONITORS", "")
+	t.Setenv("SHARD_INDEX", "2")
+
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	flags.String("name", "", "")
+	flags.Int("shard-index", 0, "")
+	flags.Int("shard-count", 0, "")
+	if err := flags.Parse([]string{"--name", "openshift-kube-conformance", "--shard-index", "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(&LoadOptions{File: file, Flags: flags})
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	// file
+	if cfg.RunMode != "upgrade" || cfg.Blocker.StallPolicy != "fail" || cfg.Blocker.StallTimeout.Duration != 30*time.Minute {
+		t.Errorf("Load() = %+v, want the settings of the file", cfg)
+	}
+	// env over file, empty env kept as unset.
+	if cfg.DevModeCount != 20 {
+		t.Errorf("DevModeCount = %d, want 20 from env", cfg.DevModeCount)
+	}
+	if cfg.Runner.GinkgoSkip == nil || *cfg.Runner.GinkgoSkip != "" || cfg.Runner.GinkgoFocus != nil {
+		t.Errorf("GinkgoSkip = %v, GinkgoFocus = %v, want the empty skip set and focus unset", cfg.Runner.GinkgoSkip, cfg.Runner.GinkgoFocus)
+	}
+	if cfg.Runner.Monitors == nil || *cfg.Runner.Monitors != "" {
+		t.Errorf("Monitors = %v, want the monitors disabled", cfg.Runner.Monitors)
+	}
+	// flags over env, only the flags changed.
+	if cfg.Plugin != "openshift-kube-conformance" || cfg.Shard.Index != 3 || cfg.Shard.Count != 4 {
+		t.Errorf("Plugin = %q, shard = %+v, want the flags over env", cfg.Plugin, cfg.Shard)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		wantErr []string
+	}{
+		{
+			name:    "unknown field",
+			file:    "suite: openshift/conformance",
+			wantErr: []string{"invalid keys: suite"},
+		},
+		{
+			name:    "invalid env values",
+			env:     map[string]string{"DEV_MODE_COUNT": "ten", "BLOCKER_STALL_TIMEOUT": "1 hour"},
+			wantErr: []string{"devModeCount", "blocker.stallTimeout"},
+		},
+		{
+			name: "invalid policies",
+			env: map[string]string{"BLOCKER_FAILURE_POLICY": "retry",
+				"DISCONNECTED_EGRESS_POLICY": "ignore", "OPENSHIFT_TESTS_RUNNER_MODE": "pod"},
+			wantErr: []string{"invalid blocker.failurePolicy", "invalid disconnected.egressPolicy", "invalid runner.mode"},
+		},
+		{
+			name:    "invalid progress URL",
+			env:     map[string]string{"PROGRESS_URL": "127.0.0.1:8099/progress"},
+			wantErr: []string{"invalid workspace.progressURL"},
+		},
+		{
+			name:    "invalid monitors",
+			env:     map[string]string{"OPENSHIFT_TESTS_MONITORS": "etcd-log-analyzer,,Audit Log"},
+			wantErr: []string{"invalid runner.monitors"},
+		},
+		{
+			name:    "invalid shard",
+			file:    "shard: {count: 2, index: 2}",
+			wantErr: []string{"invalid shard.index 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &LoadOptions{}
+			if len(tt.file) > 0 {
+				opts.File = filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(opts.File, []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(opts)
+			if err == nil || cfg == nil {
+				t.Fatalf("Load() = %v, want errors %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestLoadRunMode tests the run modes of the manifests (example: default) and the
+// unknown modes are loaded, running the default mode.
+func TestLoadRunMode(t *testing.T) {
+	for _, mode := range []string{"default", "normal", "upgrade", "legacy"} {
+		t.Setenv("RUN_MODE", mode)
+		cfg, err := Load(&LoadOptions{})
+		if err != nil || cfg.RunMode != mode {
+			t.Errorf("Load() with RUN_MODE=%s = %q, %v, want the mode loaded", mode, cfg.RunMode, err)
+		}
+	}
+}
+
+func TestMasked(t *testing.T) {
+	cfg := &Config{
+		MirrorImageRepository: "admin:secret@mirror.example.com:5000/ocp",
+		UpgradeReleases:       "quay.io/openshift-release-dev/ocp-release@sha256:1234",
+		Shard:                 Shard{DurationsFile: "https://token@example.com/durations.json"},
+		Workspace:             Workspace{ProgressURL: "https://progress.example.com/progress?access_token=abc&plugin=10"},
+	}
+	masked := cfg.Masked()
+	tests := [][2]string{
+		{masked.MirrorImageRepository, "*****@mirror.example.com:5000/ocp"},
+		{masked.UpgradeReleases, "quay.io/openshift-release-dev/ocp-release@sha256:1234"},
+		{masked.Shard.DurationsFile, "https://*****@example.com/durations.json"},
+		{masked.Workspace.ProgressURL, "https://progress.example.com/progress?access_token=*****&plugin=10"},
+	}
+	for _, tt := range tests {
+		if got, want := tt[0], tt[1]; got != want {
+			t.Errorf("Masked() = %q, want %q", got, want)
+		}
+	}
+	if cfg.MirrorImageRepository != "admin:secret@mirror.example.com:5000/ocp" {
+		t.Errorf("Masked() changed the configuration: %q", cfg.MirrorImageRepository)
+	}
+}
+
+func TestMaskSecretFields(t *testing.T) {
+	token := "sha256~abc"
+	cfg := struct {
+		Token      string  `json:"token"`
+		PullSecret *string `json:"pullSecret,omitempty"`
+		Auth       string  `json:"auth" secret:"true"`
+		Name       string  `json:"name"`
+		Empty      string  `json:"password"`
+	}{Token: token, PullSecret: &token, Auth: "user:password", Name: "plugin"}
+	mask(reflect.ValueOf(&cfg).Elem())
+	if cfg.Token != "*****" || *cfg.PullSecret != "*****" || cfg.Auth != "*****" || cfg.Name != "plugin" || cfg.Empty != "" {
+		t.Errorf("mask() = %+v, want the token and secret fields masked", cfg)
+	}
+	if token != "sha256~abc" {
+		t.Errorf("mask() changed the optional field: %q", token)
+	}
+}
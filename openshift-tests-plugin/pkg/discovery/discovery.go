@@ -0,0 +1,137 @@
+/*
+Package discovery discovers the suite and the tests executed by the plugins.
+
+The suites are queried from the test binaries available in the tests container:
+openshift-tests, and the OpenShift Tests Extension (OTE) binaries (example: the
+Kubernetes conformance tests of k8s-tests-ext, on OpenShift 4.20+). The first
+candidate suite, by priority, available in a source is selected, and the test list
+is saved to the suite list consumed by the plugin.
+*/
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// Result is the suite discovered for the plugin, shared with the plugin container.
+type Result struct {
+	Plugin     string   `json:"plugin"`
+	OCPVersion string   `json:"ocpVersion,omitempty"`
+	Candidates []string `json:"candidates"`
+
+	Suite      string `json:"suite,omitempty"`
+	Source     string `json:"source,omitempty"`
+	SourceKind string `json:"sourceKind,omitempty"`
+	// RunWithFile is set when openshift-tests must run the suite with the test
+	// list (--file), as the tests were not listed by openshift-tests.
+	RunWithFile bool   `json:"runWithFile,omitempty"`
+	SuiteList   string `json:"suiteList,omitempty"`
+	Count       int    `json:"count"`
+
+	// Suites are the suites available by source.
+	Suites map[string][]Suite `json:"suites,omitempty"`
+	Errors []string           `json:"errors,omitempty"`
+}
+
+// Failed returns true when no tests were discovered.
+func (r *Result) Failed() bool {
+	return r.Count == 0
+}
+
+// Save writes the result as JSON.
+func (r *Result) Save(file string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// ReadResult reads the result saved by Save.
+func ReadResult(file string) (*Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("invalid discovery result %s: %w", file, err)
+	}
+	return result, nil
+}
+
+// Discoverer selects the suite from the sources, by priority.
+type Discoverer struct {
+	Sources []Source
+}
+
+// Discover returns the tests of the first candidate suite available in a source.
+// The candidates are checked by priority, and for each candidate the sources by
+// order. Sources not able to list the suites are queried by the tests of each
+// candidate. The errors of the sources are reported in the result.
+func (d *Discoverer) Discover(ctx context.Context, candidates []string) (*Result, []string) {
+	result := &Result{Candidates: candidates, Suites: map[string][]Suite{}}
+	available := make(map[string]map[string]struct{}, len(d.Sources))
+	for _, src := range d.Sources {
+		suites, err := src.Suites(ctx)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("unable to list the suites of %s: %v", src.Name(), err))
+			continue
+		}
+		result.Suites[src.Name()] = suites
+		available[src.Name()] = make(map[string]struct{}, len(suites))
+		for _, suite := range suites {
+			available[src.Name()][suite.Name] = struct{}{}
+		}
+	}
+
+	for _, suite := range candidates {
+		for _, src := range d.Sources {
+			if names, ok := available[src.Name()]; ok {
+				if _, ok := names[suite]; !ok {
+					continue
+				}
+			}
+			tests, err := src.Tests(ctx, suite)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("unable to list the tests of suite %s from %s: %v", suite, src.Name(), err))
+				continue
+			}
+			if len(tests) == 0 {
+				result.Errors = append(result.Errors, fmt.Sprintf("suite %s from %s has no tests", suite, src.Name()))
+				continue
+			}
+			slices.Sort(tests)
+			tests = slices.Compact(tests)
+			result.Suite = suite
+			result.Source = src.Name()
+			result.SourceKind = src.Kind()
+			result.RunWithFile = src.Kind() != SourceKindOpenShiftTests
+			result.Count = len(tests)
+			return result, tests
+		}
+	}
+	result.Errors = append(result.Errors, fmt.Sprintf("none of the suites %v is available", candidates))
+	return result, nil
+}
+
+// WriteSuiteList writes the tests to the suite list, with one quoted test name by line,
+// the format of 'openshift-tests run --dry-run'.
+func WriteSuiteList(tests []string, file string) error {
+	fd, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	w := bufio.NewWriter(fd)
+	for _, test := range tests {
+		fmt.Fprintln(w, strconv.Quote(test))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,165 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeCommand returns the output of the command by the arguments, or an error when
+// the command is not known.
+func fakeCommand(outputs map[string]string) CommandFunc {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		out, ok := outputs[strings.Join(args, " ")]
+		if !ok {
+			return nil, errors.New("exit status 1")
+		}
+		return []byte(out), nil
+	}
+}
+
+const openshiftTestsSuites = `[{"name":"openshift/conformance","description":"conformance"},{"name":"kubernetes/conformance"}]`
+
+const openshiftTestsDryRun = `I0101 00:00:00.000000 1 test_context.go:567] Tolerating taints
+"[sig-apps] test b"
+"[sig-apps] test a"
+"[sig-apps] test b"
+`
+
+func TestDiscover(t *testing.T) {
+	ote := &OpenShiftTests{Path: "openshift-tests", Run: fakeCommand(map[string]string{
+		"list suites --output=json":            openshiftTestsSuites,
+		"run openshift/conformance --dry-run":  openshiftTestsDryRun,
+		"run kubernetes/conformance --dry-run": openshiftTestsDryRun,
+	})}
+	ext := &Extension{Path: "k8s-tests-ext", Run: fakeCommand(map[string]string{
+		"info": `{"component":{"product":"openshift"},"suites":[{"name":"kubernetes/conformance/parallel","parents":["kubernetes/conformance"]}]}`,
+		"list tests --suite=kubernetes/conformance/parallel --output=json": `[{"name":"[sig-node] test c"},{"name":"[sig-node] test d"}]`,
+	})}
+	broken := &Extension{Path: "broken-ext", Run: fakeCommand(map[string]string{})}
+
+	tests := []struct {
+		name        string
+		sources     []Source
+		candidates  []string
+		wantSuite   string
+		wantSource  string
+		wantRunWith bool
+		wantTests   []string
+		wantErrors  int
+		wantFailed  bool
+	}{
+		{
+			name:       "openshift-tests",
+			sources:    []Source{ext, ote},
+			candidates: []string{"openshift/conformance"},
+			wantSuite:  "openshift/conformance",
+			wantSource: "openshift-tests",
+			wantTests:  []string{"[sig-apps] test a", "[sig-apps] test b"},
+		},
+		{
+			name:        "extension by candidate priority",
+			sources:     []Source{ote, ext},
+			candidates:  []string{"kubernetes/conformance/parallel", "kubernetes/conformance"},
+			wantSuite:   "kubernetes/conformance/parallel",
+			wantSource:  "k8s-tests-ext",
+			wantRunWith: true,
+			wantTests:   []string{"[sig-node] test c", "[sig-node] test d"},
+		},
+		{
+			name:       "fallback to the next candidate",
+			sources:    []Source{broken, ote},
+			candidates: []string{"kubernetes/conformance/parallel", "kubernetes/conformance"},
+			wantSuite:  "kubernetes/conformance",
+			wantSource: "openshift-tests",
+			wantTests:  []string{"[sig-apps] test a", "[sig-apps] test b"},
+			// broken-ext: unable to list the suites, and the tests of both candidates.
+			wantErrors: 3,
+		},
+		{
+			name:       "no suite available",
+			sources:    []Source{ote},
+			candidates: []string{"openshift/unknown"},
+			wantErrors: 1,
+			wantFailed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Discoverer{Sources: tt.sources}
+			result, tests := d.Discover(context.Background(), tt.candidates)
+			if result.Failed() != tt.wantFailed {
+				t.Fatalf("Failed() = %v, want %v (errors: %v)", result.Failed(), tt.wantFailed, result.Errors)
+			}
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("Errors = %v, want %d errors", result.Errors, tt.wantErrors)
+			}
+			if tt.wantFailed {
+				return
+			}
+			if result.Suite != tt.wantSuite || result.Source != tt.wantSource || result.RunWithFile != tt.wantRunWith {
+				t.Errorf("Discover() = suite %q source %q runWithFile %v, want %q %q %v",
+					result.Suite, result.Source, result.RunWithFile, tt.wantSuite, tt.wantSource, tt.wantRunWith)
+			}
+			if !reflect.DeepEqual(tests, tt.wantTests) || result.Count != len(tt.wantTests) {
+				t.Errorf("Discover() tests = %q (count %d), want %q", tests, result.Count, tt.wantTests)
+			}
+		})
+	}
+}
+
+func TestTestsFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tests.list")
+	if err := os.WriteFile(file, []byte("\"[sig-node] test a\"\n\n\"[sig-node] test b\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := &Discoverer{Sources: []Source{&TestsFile{Path: file, Suite: "kubernetes/conformance/parallel"}}}
+	result, tests := d.Discover(context.Background(), []string{"kubernetes/conformance/parallel"})
+	if result.Failed() || result.SourceKind != SourceKindTestsFile || !result.RunWithFile {
+		t.Fatalf("Discover() = %+v, want tests from the tests file", result)
+	}
+
+	// the suite list written is read back by the tests file source.
+	list := filepath.Join(dir, "suite.list")
+	if err := WriteSuiteList(tests, list); err != nil {
+		t.Fatalf("WriteSuiteList() unexpected error: %v", err)
+	}
+	got, err := (&TestsFile{Path: list}).Tests(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Tests() unexpected error: %v", err)
+	}
+	if want := []string{"[sig-node] test a", "[sig-node] test b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tests() = %q, want %q", got, want)
+	}
+
+	// the test names with quotes and backslashes are escaped.
+	escaped := []string{`[sig-cli] oc explain should contain "spec" [Suite:openshift/conformance/parallel]`, `[sig-storage] path C:\data`}
+	if err := WriteSuiteList(escaped, list); err != nil {
+		t.Fatalf("WriteSuiteList() unexpected error: %v", err)
+	}
+	got, err = (&TestsFile{Path: list}).Tests(context.Background(), "")
+	if err != nil || !reflect.DeepEqual(got, escaped) {
+		t.Errorf("Tests() = %q (%v), want %q", got, err, escaped)
+	}
+}
+
+func TestResultSaveRead(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "result.json")
+	want := &Result{Plugin: "openshift-kube-conformance", Candidates: []string{"a", "b"}, Suite: "b", Count: 2,
+		Errors: []string{"suite a from openshift-tests has no tests"}}
+	if err := want.Save(file); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+	got, err := ReadResult(file)
+	if err != nil {
+		t.Fatalf("ReadResult() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadResult() = %+v, want %+v", got, want)
+	}
+}
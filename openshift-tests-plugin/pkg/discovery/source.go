@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SourceKindOpenShiftTests is the openshift-tests binary, listing the tests
+	// of the suite with 'run --dry-run'.
+	SourceKindOpenShiftTests = "openshift-tests"
+	// SourceKindExtension is an OpenShift Tests Extension (OTE) binary, example:
+	// k8s-tests-ext, implementing the commands 'info' and 'list tests'.
+	SourceKindExtension = "extension"
+	// SourceKindTestsFile is a list of tests extracted before the plugin starts,
+	// example: the Kubernetes conformance tests extracted by the init container.
+	SourceKindTestsFile = "tests-file"
+)
+
+// Suite is a test suite available in the source.
+type Suite struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Parents     []string `json:"parents,omitempty"`
+}
+
+// Source lists the suites and the tests available in a test binary.
+type Source interface {
+	// Name returns the source path.
+	Name() string
+	// Kind returns the source kind.
+	Kind() string
+	// Suites returns the suites available in the source.
+	Suites(ctx context.Context) ([]Suite, error)
+	// Tests returns the test names of the suite.
+	Tests(ctx context.Context, suite string) ([]string, error)
+}
+
+// CommandFunc runs the command, returning the stdout.
+type CommandFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// ExecCommand runs the command, returning the stdout, and the last stderr lines in
+// the error when the command fails.
+func ExecCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if len(lines) > 5 {
+			lines = lines[len(lines)-5:]
+		}
+		return out, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.Join(lines, "; "))
+	}
+	return out, nil
+}
+
+// OpenShiftTests is the openshift-tests binary.
+type OpenShiftTests struct {
+	Path string
+	Run  CommandFunc
+}
+
+// NewOpenShiftTests creates the openshift-tests source.
+func NewOpenShiftTests(path string) *OpenShiftTests {
+	return &OpenShiftTests{Path: path, Run: ExecCommand}
+}
+
+func (o *OpenShiftTests) Name() string { return o.Path }
+func (o *OpenShiftTests) Kind() string { return SourceKindOpenShiftTests }
+
+// Suites returns the suites listed by 'openshift-tests list suites'.
+func (o *OpenShiftTests) Suites(ctx context.Context) ([]Suite, error) {
+	out, err := o.Run(ctx, o.Path, "list", "suites", "--output=json")
+	if err != nil {
+		return nil, err
+	}
+	suites := []Suite{}
+	if err := json.Unmarshal(out, &suites); err != nil {
+		return nil, fmt.Errorf("invalid suites from %s: %w", o.Path, err)
+	}
+	return suites, nil
+}
+
+// Tests returns the tests of the suite listed by 'openshift-tests run --dry-run'.
+func (o *OpenShiftTests) Tests(ctx context.Context, suite string) ([]string, error) {
+	out, err := o.Run(ctx, o.Path, "run", suite, "--dry-run")
+	if err != nil {
+		return nil, err
+	}
+	return parseTestNames(out), nil
+}
+
+// Extension is an OpenShift Tests Extension (OTE) binary.
+type Extension struct {
+	Path string
+	Run  CommandFunc
+}
+
+// NewExtension creates the extension source.
+func NewExtension(path string) *Extension {
+	return &Extension{Path: path, Run: ExecCommand}
+}
+
+func (e *Extension) Name() string { return e.Path }
+func (e *Extension) Kind() string { return SourceKindExtension }
+
+// Suites returns the suites reported by the extension 'info'.
+func (e *Extension) Suites(ctx context.Context) ([]Suite, error) {
+	out, err := e.Run(ctx, e.Path, "info")
+	if err != nil {
+		return nil, err
+	}
+	info := struct {
+		Suites []Suite `json:"suites"`
+	}{}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("invalid info from %s: %w", e.Path, err)
+	}
+	return info.Suites, nil
+}
+
+// Tests returns the tests of the suite listed by the extension 'list tests'.
+func (e *Extension) Tests(ctx context.Context, suite string) ([]string, error) {
+	out, err := e.Run(ctx, e.Path, "list", "tests", "--suite="+suite, "--output=json")
+	if err != nil {
+		return nil, err
+	}
+	specs := []struct {
+		Name string `json:"name"`
+	}{}
+	if err := json.Unmarshal(out, &specs); err != nil {
+		return nil, fmt.Errorf("invalid tests from %s: %w", e.Path, err)
+	}
+	tests := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		tests = append(tests, spec.Name)
+	}
+	return tests, nil
+}
+
+// TestsFile is the list of tests of a suite, with one quoted test name by line.
+type TestsFile struct {
+	Path  string
+	Suite string
+}
+
+func (f *TestsFile) Name() string { return f.Path }
+func (f *TestsFile) Kind() string { return SourceKindTestsFile }
+
+// Suites returns the suite of the tests file.
+func (f *TestsFile) Suites(ctx context.Context) ([]Suite, error) {
+	return []Suite{{Name: f.Suite}}, nil
+}
+
+// Tests returns the tests in the file.
+func (f *TestsFile) Tests(ctx context.Context, suite string) ([]string, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	return parseTestNames(data), nil
+}
+
+// parseTestNames returns the quoted test names of the suite list, ignoring the
+// other lines (example: logs).
+func parseTestNames(data []byte) []string {
+	tests := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, `"`) {
+			continue
+		}
+		if name, err := strconv.Unquote(line); err == nil && len(name) > 0 {
+			tests = append(tests, name)
+		}
+	}
+	return tests
+}
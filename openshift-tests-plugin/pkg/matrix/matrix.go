@@ -0,0 +1,261 @@
+/*
+Package matrix resolves the plugin settings by the OpenShift version of the cluster,
+such as the suite name, the openshift-tests monitors, the tests excluded from the
+suite, the max parallel tests and the timeouts, from a version matrix.
+*/
+package matrix
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultMatrix is the built-in version matrix.
+//
+//go:embed matrix.yaml
+var DefaultMatrix []byte
+
+// Matrix holds the plugin settings, and the settings overridden by version.
+type Matrix struct {
+	Layer    `json:",inline"`
+	Versions []*Version `json:"versions,omitempty"`
+}
+
+// Layer holds the settings of all plugins (defaults), and by plugin ID.
+type Layer struct {
+	Defaults *Settings            `json:"defaults,omitempty"`
+	Plugins  map[string]*Settings `json:"plugins,omitempty"`
+}
+
+// Version holds the settings overridden for the versions in the range [Since, Until).
+type Version struct {
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	Layer `json:",inline"`
+}
+
+// Settings holds the plugin settings. Unset fields keep the value of the previous layer.
+type Settings struct {
+	Suite         string   `json:"suite,omitempty"`
+	Monitors      *string  `json:"monitors,omitempty"`
+	MaxParallel   *int     `json:"maxParallel,omitempty"`
+	Timeout       string   `json:"timeout,omitempty"`
+	ExcludedTests []string `json:"excludedTests,omitempty"`
+}
+
+// Config is the plugin settings resolved for the version.
+type Config struct {
+	PluginID string `json:"pluginID"`
+	Version  string `json:"version,omitempty"`
+	// Matched are the version ranges applied.
+	Matched []string `json:"matched,omitempty"`
+
+	Suite string `json:"suite,omitempty"`
+	// Suites are the suite candidates, by priority: the suite of the version, or
+	// the suites of all versions, newest first, when the version is unknown.
+	Suites        []string          `json:"suites,omitempty"`
+	Monitors      string            `json:"monitors"`
+	MaxParallel   int               `json:"maxParallel"`
+	Timeout       kmmetav1.Duration `json:"timeout"`
+	ExcludedTests []string          `json:"excludedTests,omitempty"`
+}
+
+// LoadMatrix reads the matrix file, or the built-in matrix when path is empty.
+func LoadMatrix(path string) (*Matrix, error) {
+	data := DefaultMatrix
+	if len(path) > 0 {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("error reading version matrix %s: %w", path, err)
+		}
+	}
+	return ParseMatrix(data)
+}
+
+// ParseMatrix parses and validates the matrix (YAML or JSON).
+func ParseMatrix(data []byte) (*Matrix, error) {
+	m := &Matrix{}
+	if err := yaml.UnmarshalStrict(data, m); err != nil {
+		return nil, fmt.Errorf("error parsing version matrix: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate checks the version ranges, the durations and the test patterns are valid.
+func (m *Matrix) Validate() error {
+	if err := m.Layer.validate(); err != nil {
+		return err
+	}
+	for idx, v := range m.Versions {
+		for _, version := range []string{v.Since, v.Until} {
+			if len(version) == 0 {
+				continue
+			}
+			if _, _, err := ParseVersion(version); err != nil {
+				return fmt.Errorf("versions #%d: %w", idx, err)
+			}
+		}
+		if err := v.Layer.validate(); err != nil {
+			return fmt.Errorf("versions %s: %w", v, err)
+		}
+	}
+	return nil
+}
+
+func (l *Layer) validate() error {
+	if err := l.Defaults.validate(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	for id, s := range l.Plugins {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("plugin %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.MaxParallel != nil && *s.MaxParallel < 0 {
+		return fmt.Errorf("invalid maxParallel %d: must not be negative", *s.MaxParallel)
+	}
+	if len(s.Timeout) > 0 {
+		d, err := time.ParseDuration(s.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", s.Timeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid timeout %q: must be positive", s.Timeout)
+		}
+	}
+	for _, pattern := range s.ExcludedTests {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid excluded test pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// String returns the version range.
+func (v *Version) String() string {
+	switch {
+	case len(v.Since) > 0 && len(v.Until) > 0:
+		return fmt.Sprintf(">=%s,<%s", v.Since, v.Until)
+	case len(v.Since) > 0:
+		return ">=" + v.Since
+	case len(v.Until) > 0:
+		return "<" + v.Until
+	}
+	return "*"
+}
+
+// Matches returns true when the version is in the range.
+func (v *Version) Matches(major, minor int) bool {
+	if len(v.Since) > 0 {
+		if smajor, sminor, err := ParseVersion(v.Since); err != nil || compare(major, minor, smajor, sminor) < 0 {
+			return false
+		}
+	}
+	if len(v.Until) > 0 {
+		if umajor, uminor, err := ParseVersion(v.Until); err != nil || compare(major, minor, umajor, uminor) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns the settings of the plugin for the OpenShift version. Only the
+// matrix defaults are applied when the version is empty or invalid.
+func (m *Matrix) Resolve(pluginID, version string) *Config {
+	cfg := &Config{PluginID: pluginID, Version: version}
+	m.Layer.apply(cfg, pluginID)
+
+	major, minor, err := ParseVersion(version)
+	if err != nil {
+		for i := len(m.Versions) - 1; i >= 0; i-- {
+			if s := m.Versions[i].Plugins[pluginID]; s != nil && len(s.Suite) > 0 && !slices.Contains(cfg.Suites, s.Suite) {
+				cfg.Suites = append(cfg.Suites, s.Suite)
+			}
+		}
+		if len(cfg.Suite) > 0 && !slices.Contains(cfg.Suites, cfg.Suite) {
+			cfg.Suites = append(cfg.Suites, cfg.Suite)
+		}
+		return cfg
+	}
+	for _, v := range m.Versions {
+		if !v.Matches(major, minor) || (v.Defaults == nil && v.Plugins[pluginID] == nil) {
+			continue
+		}
+		v.Layer.apply(cfg, pluginID)
+		cfg.Matched = append(cfg.Matched, v.String())
+	}
+	if len(cfg.Suite) > 0 {
+		cfg.Suites = []string{cfg.Suite}
+	}
+	return cfg
+}
+
+func (l *Layer) apply(cfg *Config, pluginID string) {
+	l.Defaults.apply(cfg)
+	l.Plugins[pluginID].apply(cfg)
+}
+
+func (s *Settings) apply(cfg *Config) {
+	if s == nil {
+		return
+	}
+	if len(s.Suite) > 0 {
+		cfg.Suite = s.Suite
+	}
+	if s.Monitors != nil {
+		cfg.Monitors = *s.Monitors
+	}
+	if s.MaxParallel != nil {
+		cfg.MaxParallel = *s.MaxParallel
+	}
+	if len(s.Timeout) > 0 {
+		// validated when parsing the matrix.
+		cfg.Timeout.Duration, _ = time.ParseDuration(s.Timeout)
+	}
+	cfg.ExcludedTests = append(cfg.ExcludedTests, s.ExcludedTests...)
+}
+
+// ParseVersion returns the major and minor of the OpenShift version, example: 4.20.0-rc.1.
+func ParseVersion(version string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid version %q", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	return major, minor, nil
+}
+
+// compare returns -1, 0 or 1 when the version a is lower, equal or greater than b.
+func compare(amajor, aminor, bmajor, bminor int) int {
+	if c := cmp.Compare(amajor, bmajor); c != 0 {
+		return c
+	}
+	return cmp.Compare(aminor, bminor)
+}
@@ -0,0 +1,156 @@
+package matrix
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestDefaultMatrix tests the built-in matrix is valid, and sets the timeout of all plugins.
+func TestDefaultMatrix(t *testing.T) {
+	m, err := LoadMatrix("")
+	if err != nil {
+		t.Fatalf("LoadMatrix() unexpected error: %v", err)
+	}
+	for _, id := range []string{"05", "10", "20", "80", "99"} {
+		for _, version := range []string{"", "4.18.0", "4.20.0"} {
+			cfg := m.Resolve(id, version)
+			if cfg.Timeout.Duration <= 0 || cfg.Monitors != "etcd-log-analyzer" {
+				t.Errorf("Resolve(%s, %q) = %+v, want the timeout and monitors set", id, version, cfg)
+			}
+		}
+	}
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{version: "4.19.9", want: []string{"kubernetes/conformance"}},
+		{version: "4.20.0", want: []string{"kubernetes/conformance/parallel"}},
+		{version: "", want: []string{"kubernetes/conformance/parallel", "kubernetes/conformance"}},
+	}
+	for _, tt := range tests {
+		if got := m.Resolve("10", tt.version).Suites; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Resolve(10, %q).Suites = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m, err := ParseMatrix([]byte(`
+defaults:
+  monitors: etcd-log-analyzer
+  timeout: 1h
+  excludedTests: ['\[Serial\]']
+plugins:
+  "20":
+    suite: openshift/conformance
+    timeout: 4h
+versions:
+- until: "4.16"
+  plugins:
+    "20": {excludedTests: ['\[sig-storage\] old']}
+- since: "4.16"
+  until: "4.18"
+  defaults: {monitors: ""}
+- since: "4.18"
+  plugins:
+    "20": {suite: openshift/conformance/parallel, maxParallel: 10}
+`))
+	if err != nil {
+		t.Fatalf("ParseMatrix() unexpected error: %v", err)
+	}
+	tests := []struct {
+		name    string
+		id      string
+		version string
+		want    *Config
+	}{
+		{
+			name: "older version", id: "20", version: "4.15.3",
+			want: &Config{Matched: []string{"<4.16"}, Suite: "openshift/conformance", Suites: []string{"openshift/conformance"},
+				Monitors: "etcd-log-analyzer", Timeout: kmmetav1.Duration{Duration: 4 * time.Hour},
+				ExcludedTests: []string{`\[Serial\]`, `\[sig-storage\] old`}},
+		},
+		{
+			name: "monitors disabled", id: "20", version: "4.17.0",
+			want: &Config{Matched: []string{">=4.16,<4.18"}, Suite: "openshift/conformance", Suites: []string{"openshift/conformance"},
+				Timeout: kmmetav1.Duration{Duration: 4 * time.Hour}, ExcludedTests: []string{`\[Serial\]`}},
+		},
+		{
+			name: "newer version", id: "20", version: "v5.1.0",
+			want: &Config{Matched: []string{">=4.18"}, Suite: "openshift/conformance/parallel", Suites: []string{"openshift/conformance/parallel"},
+				Monitors: "etcd-log-analyzer", MaxParallel: 10, Timeout: kmmetav1.Duration{Duration: 4 * time.Hour}, ExcludedTests: []string{`\[Serial\]`}},
+		},
+		{
+			name: "unknown version", id: "20",
+			want: &Config{Suite: "openshift/conformance", Suites: []string{"openshift/conformance/parallel", "openshift/conformance"},
+				Monitors: "etcd-log-analyzer", Timeout: kmmetav1.Duration{Duration: 4 * time.Hour}, ExcludedTests: []string{`\[Serial\]`}},
+		},
+		{
+			name: "plugin without settings", id: "99", version: "4.19.0",
+			want: &Config{Monitors: "etcd-log-analyzer", Timeout: kmmetav1.Duration{Duration: time.Hour}, ExcludedTests: []string{`\[Serial\]`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.Resolve(tt.id, tt.version)
+			tt.want.PluginID, tt.want.Version = tt.id, tt.version
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMatrixInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "unknown field", data: "plugins: {\"10\": {suites: [a]}}", wantErr: "unknown field"},
+		{name: "invalid timeout", data: "plugins: {\"10\": {timeout: 2x}}", wantErr: "invalid timeout"},
+		{name: "negative timeout", data: "defaults: {timeout: -1h}", wantErr: "must be positive"},
+		{name: "negative max parallel", data: "defaults: {maxParallel: -1}", wantErr: "must not be negative"},
+		{name: "invalid pattern", data: "defaults: {excludedTests: ['[sig-']}", wantErr: "invalid excluded test pattern"},
+		{name: "invalid version", data: "versions: [{since: latest}]", wantErr: "invalid version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMatrix([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseMatrix() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		wantMajor int
+		wantMinor int
+		wantErr   bool
+	}{
+		{version: "4.20.0", wantMajor: 4, wantMinor: 20},
+		{version: "v4.18.3", wantMajor: 4, wantMinor: 18},
+		{version: "4.21-ec.2", wantMajor: 4, wantMinor: 21},
+		{version: "4", wantErr: true},
+		{version: "", wantErr: true},
+		{version: "latest.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, minor, err := ParseVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if major != tt.wantMajor || minor != tt.wantMinor {
+				t.Errorf("ParseVersion() = %d.%d, want %d.%d", major, minor, tt.wantMajor, tt.wantMinor)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Package metrics gathers metrics from the in-cluster Prometheus/Thanos API,
+running the range queries defined in a query catalog.
+*/
+package metrics
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	DefaultStep  = time.Minute
+	DefaultRange = 6 * time.Hour
+)
+
+// DefaultCatalog is the built-in query catalog.
+//
+//go:embed catalog.yaml
+var DefaultCatalog []byte
+
+// reVersionMinor extracts the <major>.<minor> from the OCP version.
+var reVersionMinor = regexp.MustCompile(`^v?(\d+\.\d+)`)
+
+// Catalog holds the range queries to gather.
+type Catalog struct {
+	// Step and Range are the defaults for queries without the field.
+	Step    string   `json:"step,omitempty"`
+	Range   string   `json:"range,omitempty"`
+	Queries []*Query `json:"queries"`
+}
+
+// Query is a PromQL range query in the catalog.
+type Query struct {
+	Name     string `json:"name"`
+	Query    string `json:"query"`
+	Step     string `json:"step,omitempty"`
+	Range    string `json:"range,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// Overrides holds the fields overridden by OCP version, keyed by <major>.<minor>.
+	Overrides map[string]*QueryOverride `json:"overrides,omitempty"`
+}
+
+// QueryOverride holds the query fields overridden for an OCP version.
+type QueryOverride struct {
+	Query    string `json:"query,omitempty"`
+	Step     string `json:"step,omitempty"`
+	Range    string `json:"range,omitempty"`
+	Disabled *bool  `json:"disabled,omitempty"`
+}
+
+// RangeQuery is the query resolved for an OCP version, ready to be executed.
+type RangeQuery struct {
+	Name  string
+	Query string
+	Step  time.Duration
+	Range time.Duration
+}
+
+// LoadCatalog reads the catalog file, or the built-in catalog when path is empty.
+func LoadCatalog(path string) (*Catalog, error) {
+	data := DefaultCatalog
+	if len(path) > 0 {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("error reading catalog %s: %w", path, err)
+		}
+	}
+	return ParseCatalog(data)
+}
+
+// ParseCatalog parses and validates the catalog (YAML or JSON).
+func ParseCatalog(data []byte) (*Catalog, error) {
+	catalog := &Catalog{}
+	if err := yaml.UnmarshalStrict(data, catalog); err != nil {
+		return nil, fmt.Errorf("error parsing catalog: %w", err)
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// Validate checks the query names are unique, and the expressions and durations are valid.
+func (c *Catalog) Validate() error {
+	if _, err := parseDuration(c.Step, DefaultStep); err != nil {
+		return fmt.Errorf("invalid catalog step: %w", err)
+	}
+	if _, err := parseDuration(c.Range, DefaultRange); err != nil {
+		return fmt.Errorf("invalid catalog range: %w", err)
+	}
+	names := make(map[string]struct{}, len(c.Queries))
+	for idx, q := range c.Queries {
+		if len(q.Name) == 0 {
+			return fmt.Errorf("query #%d: empty name", idx)
+		}
+		if sanitizeName(q.Name) != q.Name {
+			return fmt.Errorf("query %s: name must contain only alphanumeric, '-' or '_'", q.Name)
+		}
+		if _, ok := names[q.Name]; ok {
+			return fmt.Errorf("query %s: duplicated name", q.Name)
+		}
+		names[q.Name] = struct{}{}
+		if len(q.Query) == 0 {
+			return fmt.Errorf("query %s: empty query", q.Name)
+		}
+		fields := []string{q.Step, q.Range}
+		for version, ov := range q.Overrides {
+			if !reVersionMinor.MatchString(version) {
+				return fmt.Errorf("query %s: invalid override version %q, expected <major>.<minor>", q.Name, version)
+			}
+			fields = append(fields, ov.Step, ov.Range)
+		}
+		for _, field := range fields {
+			if _, err := parseDuration(field, DefaultStep); err != nil {
+				return fmt.Errorf("query %s: %w", q.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve returns the enabled queries applying the overrides of the OCP version.
+// Overrides are ignored when the version is empty.
+func (c *Catalog) Resolve(version string) []*RangeQuery {
+	minor := ""
+	if m := reVersionMinor.FindStringSubmatch(version); len(m) > 1 {
+		minor = m[1]
+	}
+	defaultStep, _ := parseDuration(c.Step, DefaultStep)
+	defaultRange, _ := parseDuration(c.Range, DefaultRange)
+
+	queries := []*RangeQuery{}
+	for _, q := range c.Queries {
+		expr, step, qrange, disabled := q.Query, q.Step, q.Range, q.Disabled
+		if ov, ok := q.Overrides[minor]; ok && len(minor) > 0 {
+			if len(ov.Query) > 0 {
+				expr = ov.Query
+			}
+			if len(ov.Step) > 0 {
+				step = ov.Step
+			}
+			if len(ov.Range) > 0 {
+				qrange = ov.Range
+			}
+			if ov.Disabled != nil {
+				disabled = *ov.Disabled
+			}
+		}
+		if disabled {
+			continue
+		}
+		rq := &RangeQuery{Name: q.Name, Query: expr}
+		rq.Step, _ = parseDuration(step, defaultStep)
+		rq.Range, _ = parseDuration(qrange, defaultRange)
+		queries = append(queries, rq)
+	}
+	return queries
+}
+
+// parseDuration parses the duration, returning the default when empty.
+func parseDuration(value string, def time.Duration) (time.Duration, error) {
+	if len(value) == 0 {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be positive", value)
+	}
+	return d, nil
+}
+
+// sanitizeName removes special chars from the name, keeping alphanumeric, '-' and '_'.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDefaultCatalog tests the built-in catalog is valid.
+func TestDefaultCatalog(t *testing.T) {
+	catalog, err := LoadCatalog("")
+	if err != nil {
+		t.Fatalf("LoadCatalog() unexpected error: %v", err)
+	}
+	queries := catalog.Resolve("4.18.1")
+	if len(queries) != len(catalog.Queries) || len(queries) == 0 {
+		t.Fatalf("Resolve() = %d queries, want %d", len(queries), len(catalog.Queries))
+	}
+	for _, q := range queries {
+		if q.Step != time.Minute || q.Range != 6*time.Hour {
+			t.Errorf("query %s step=%v range=%v, want 1m/6h", q.Name, q.Step, q.Range)
+		}
+	}
+}
+
+// TestCatalogResolve tests the per-version overrides.
+func TestCatalogResolve(t *testing.T) {
+	catalog, err := ParseCatalog([]byte(`
+step: 30s
+range: 1h
+queries:
+- name: up
+  query: up
+- name: etcd-fsync
+  query: etcd_disk_wal_fsync_duration_seconds_bucket
+  step: 1m
+  overrides:
+    "4.14": {query: 'etcd_new_metric', range: 2h}
+    "4.13": {disabled: true}
+- name: new-metric
+  query: new_metric
+  disabled: true
+  overrides:
+    "4.18": {disabled: false}
+`))
+	if err != nil {
+		t.Fatalf("ParseCatalog() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{version: "", want: []string{"up=up/30s/1h0m0s", "etcd-fsync=etcd_disk_wal_fsync_duration_seconds_bucket/1m0s/1h0m0s"}},
+		{version: "4.13.10", want: []string{"up=up/30s/1h0m0s"}},
+		{version: "4.14.0-rc.1", want: []string{"up=up/30s/1h0m0s", "etcd-fsync=etcd_new_metric/1m0s/2h0m0s"}},
+		{version: "4.18.2", want: []string{"up=up/30s/1h0m0s", "etcd-fsync=etcd_disk_wal_fsync_duration_seconds_bucket/1m0s/1h0m0s", "new-metric=new_metric/30s/1h0m0s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got := []string{}
+			for _, q := range catalog.Resolve(tt.version) {
+				got = append(got, q.Name+"="+q.Query+"/"+q.Step.String()+"/"+q.Range.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCatalogValidate tests invalid catalogs are rejected.
+func TestCatalogValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+		wantErr string
+	}{
+		{name: "duplicated", catalog: "queries: [{name: a, query: up}, {name: a, query: up}]", wantErr: "duplicated name"},
+		{name: "empty query", catalog: "queries: [{name: a}]", wantErr: "empty query"},
+		{name: "invalid name", catalog: "queries: [{name: 'a/b', query: up}]", wantErr: "name must contain"},
+		{name: "invalid step", catalog: "queries: [{name: a, query: up, step: 1x}]", wantErr: "invalid duration"},
+		{name: "invalid version", catalog: "queries: [{name: a, query: up, overrides: {latest: {step: 1m}}}]", wantErr: "invalid override version"},
+		{name: "unknown field", catalog: "queries: [{name: a, query: up, interval: 1m}]", wantErr: "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCatalog([]byte(tt.catalog))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCatalog() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
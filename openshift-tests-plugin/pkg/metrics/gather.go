@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	QueryStatusSuccess = "success"
+	QueryStatusFailed  = "failed"
+
+	// SummaryFile holds the result of each query in the destination directory.
+	SummaryFile = "gather-metrics.json"
+)
+
+// QueryResult is the result of a range query.
+type QueryResult struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	Step  string `json:"step"`
+	Range string `json:"range"`
+	// File is the result file relative to the destination directory.
+	File     string `json:"file,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Summary holds the results of the gathering.
+type Summary struct {
+	Version string         `json:"ocpVersion,omitempty"`
+	End     time.Time      `json:"end"`
+	Results []*QueryResult `json:"results"`
+}
+
+// Failed returns the number of failed queries.
+func (s *Summary) Failed() int {
+	failed := 0
+	for _, r := range s.Results {
+		if r.Status == QueryStatusFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Gatherer runs the range queries, saving each result in the destination directory.
+type Gatherer struct {
+	Client  *Client
+	DestDir string
+	// End is the end of the time range for all queries. Default: now.
+	End time.Time
+	// Version is the OCP version used to resolve the catalog overrides.
+	Version string
+}
+
+// Gather runs the queries from the catalog, writing each result into the file
+// query_range-<name>.json, and the summary with the failures per query.
+func (g *Gatherer) Gather(ctx context.Context, catalog *Catalog) (*Summary, error) {
+	if err := os.MkdirAll(g.DestDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating destination directory %s: %w", g.DestDir, err)
+	}
+	end := g.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	summary := &Summary{Version: g.Version, End: end.UTC()}
+
+	queries := catalog.Resolve(g.Version)
+	log.Infof("Gathering %d range queries (OCP version %q) from %s", len(queries), g.Version, g.Client.URL)
+	for _, q := range queries {
+		res := &QueryResult{
+			Name:   q.Name,
+			Query:  q.Query,
+			Step:   q.Step.String(),
+			Range:  q.Range.String(),
+			Status: QueryStatusSuccess,
+		}
+		start := time.Now()
+		if err := g.gatherQuery(ctx, q, end, res); err != nil {
+			log.Errorf("Query %s failed: %v", q.Name, err)
+			res.Status = QueryStatusFailed
+			res.Error = err.Error()
+		} else {
+			log.Infof("Query %s saved at %s", q.Name, res.File)
+		}
+		res.Duration = time.Since(start).Truncate(time.Millisecond).String()
+		summary.Results = append(summary.Results, res)
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return summary, fmt.Errorf("error serializing summary: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(g.DestDir, SummaryFile), data, 0644); err != nil {
+		return summary, fmt.Errorf("error writing summary: %w", err)
+	}
+	log.Infof("Gathered %d queries, %d failed. Summary saved at %s", len(summary.Results), summary.Failed(), filepath.Join(g.DestDir, SummaryFile))
+	return summary, nil
+}
+
+// gatherQuery runs the query, saving the response.
+func (g *Gatherer) gatherQuery(ctx context.Context, q *RangeQuery, end time.Time, res *QueryResult) error {
+	data, err := g.Client.QueryRange(ctx, q.Query, end.Add(-q.Range), end, q.Step)
+	if err != nil {
+		return err
+	}
+	res.File = fmt.Sprintf("query_range-%s.json", q.Name)
+	if err := os.WriteFile(filepath.Join(g.DestDir, res.File), data, 0644); err != nil {
+		res.File = ""
+		return fmt.Errorf("error writing result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newFakePrometheus creates a Prometheus API server answering the query_range
+// requests, failing the query 'invalid('.
+func newFakePrometheus(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"error","errorType":"unauthorized","error":"invalid token"}`)
+			return
+		}
+		if r.URL.Path != "/api/v1/query_range" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		query := r.PostForm.Get("query")
+		if query == "invalid(" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"query":%q,"start":%q,"end":%q,"step":%q},"values":[[1720210069,"1"]]}]}}`,
+			query, r.PostForm.Get("start"), r.PostForm.Get("end"), r.PostForm.Get("step"))
+	}))
+}
+
+// TestGather tests the results and failures are saved for each query.
+func TestGather(t *testing.T) {
+	server := newFakePrometheus(t, "sa-token")
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("sa-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(server.URL, tokenFile, "", false, 10*time.Second)
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	catalog, err := ParseCatalog([]byte(`
+queries:
+- name: up
+  query: up
+  step: 30s
+  range: 1h
+- name: broken
+  query: 'invalid('
+`))
+	if err != nil {
+		t.Fatalf("ParseCatalog() unexpected error: %v", err)
+	}
+
+	end := time.Unix(1720210069, 0)
+	g := &Gatherer{Client: client, DestDir: t.TempDir(), End: end, Version: "4.18.0"}
+	summary, err := g.Gather(context.Background(), catalog)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error: %v", err)
+	}
+	if len(summary.Results) != 2 || summary.Failed() != 1 {
+		t.Fatalf("Gather() results = %d failed = %d, want 2 results and 1 failure", len(summary.Results), summary.Failed())
+	}
+
+	// successful query saved as JSON with the time range.
+	up := summary.Results[0]
+	if up.Status != QueryStatusSuccess || up.File != "query_range-up.json" {
+		t.Errorf("query up = %+v, want success saved in query_range-up.json", up)
+	}
+	data, err := os.ReadFile(filepath.Join(g.DestDir, up.File))
+	if err != nil {
+		t.Fatalf("error reading query result: %v", err)
+	}
+	resp := struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+			} `json:"result"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Data.Result) != 1 {
+		t.Fatalf("invalid query result %s: %v", string(data), err)
+	}
+	want := map[string]string{"query": "up", "start": "1720206469", "end": "1720210069", "step": "30"}
+	for k, v := range want {
+		if got := resp.Data.Result[0].Metric[k]; got != v {
+			t.Errorf("request %s = %q, want %q", k, got, v)
+		}
+	}
+
+	// failed query recorded in the summary.
+	broken := summary.Results[1]
+	if broken.Status != QueryStatusFailed || broken.File != "" || broken.Error == "" {
+		t.Errorf("query broken = %+v, want failed with error", broken)
+	}
+	if _, err := os.Stat(filepath.Join(g.DestDir, "query_range-broken.json")); !os.IsNotExist(err) {
+		t.Errorf("failed query must not create the result file")
+	}
+	data, err = os.ReadFile(filepath.Join(g.DestDir, SummaryFile))
+	if err != nil {
+		t.Fatalf("error reading summary: %v", err)
+	}
+	saved := &Summary{}
+	if err := json.Unmarshal(data, saved); err != nil || saved.Failed() != 1 || saved.Version != "4.18.0" {
+		t.Errorf("summary = %s (%v), want 1 failure and version 4.18.0", string(data), err)
+	}
+}
+
+// TestGatherUnauthorized tests all queries fail with an invalid token.
+func TestGatherUnauthorized(t *testing.T) {
+	server := newFakePrometheus(t, "sa-token")
+	defer server.Close()
+
+	client := &Client{URL: server.URL, Token: "invalid"}
+	catalog, err := LoadCatalog("")
+	if err != nil {
+		t.Fatalf("LoadCatalog() unexpected error: %v", err)
+	}
+	g := &Gatherer{Client: client, DestDir: t.TempDir()}
+	summary, err := g.Gather(context.Background(), catalog)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error: %v", err)
+	}
+	if summary.Failed() != len(catalog.Queries) {
+		t.Errorf("Gather() failed = %d, want all %d queries failed", summary.Failed(), len(catalog.Queries))
+	}
+}
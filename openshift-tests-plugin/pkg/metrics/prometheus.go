@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPrometheusURL is the in-cluster Thanos Querier service.
+	DefaultPrometheusURL = "https://thanos-querier.openshift-monitoring.svc:9091"
+	// DefaultTokenFile is the service account token used to authenticate in the API.
+	DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// DefaultCAFile is the service CA signing the in-cluster services.
+	DefaultCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt"
+
+	DefaultQueryTimeout = time.Minute
+)
+
+// Client is the Prometheus HTTP API client.
+type Client struct {
+	URL        string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// apiResponse is the envelope of Prometheus API responses.
+type apiResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// NewClient creates the Prometheus client authenticated with the token file, and
+// trusting the CA file when it exists.
+func NewClient(apiURL, tokenFile, caFile string, insecure bool, timeout time.Duration) (*Client, error) {
+	c := &Client{URL: strings.TrimSuffix(apiURL, "/")}
+	if len(tokenFile) > 0 {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading token file %s: %w", tokenFile, err)
+		}
+		c.Token = strings.TrimSpace(string(token))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+	if len(caFile) > 0 && !insecure {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file %s: %w", caFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.HTTPClient = &http.Client{Transport: transport, Timeout: timeout}
+	return c, nil
+}
+
+// QueryRange runs the range query, returning the raw API response.
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]byte, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/api/v1/query_range", strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if len(c.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting query_range: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading query_range response: %w", err)
+	}
+
+	apiResp := &apiResponse{}
+	if err := json.Unmarshal(body, apiResp); err != nil {
+		return nil, fmt.Errorf("invalid query_range response (status %s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || apiResp.Status != "success" {
+		return nil, fmt.Errorf("query_range failed (status %s): %s: %s", resp.Status, apiResp.ErrorType, apiResp.Error)
+	}
+	return body, nil
+}
@@ -0,0 +1,163 @@
+/*
+Package mirror validates the image mirror of disconnected (restricted network)
+clusters before the conformance tests run.
+
+The images required by openshift-tests (openshift-tests images --to-repository)
+are resolved to the mirror repository and to the mirrors configured in the cluster
+(ImageContentSourcePolicy, ImageDigestMirrorSet and ImageTagMirrorSet), and each
+candidate is checked in the registry, reporting the images missing in the mirror.
+*/
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	operatorclient "github.com/openshift/client-go/operator/clientset/versioned"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RuleKindICSP = "ImageContentSourcePolicy"
+	RuleKindIDMS = "ImageDigestMirrorSet"
+	RuleKindITMS = "ImageTagMirrorSet"
+)
+
+// Rule is a mirror configuration of the cluster: images from the source
+// repository can be pulled from the mirrors.
+type Rule struct {
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Source  string   `json:"source"`
+	Mirrors []string `json:"mirrors"`
+}
+
+// appliesTo returns true when the rule kind mirrors the reference type: the digest
+// mirrors (ICSP and IDMS) apply to digests, and the tag mirrors (ITMS) apply to tags.
+func (r *Rule) appliesTo(ref *Reference) bool {
+	if r.Kind == RuleKindITMS {
+		return len(ref.Tag) > 0
+	}
+	return len(ref.Digest) > 0
+}
+
+// Resolve returns the mirrored references of the image, or nil when the rule
+// does not match the image repository.
+func (r *Rule) Resolve(ref *Reference) []string {
+	if !r.appliesTo(ref) {
+		return nil
+	}
+	var rest string
+	switch {
+	case strings.HasPrefix(r.Source, "*."):
+		// wildcard sources match the registry host, example: *.redhat.io
+		if !strings.HasSuffix(ref.Registry(), r.Source[1:]) {
+			return nil
+		}
+		rest = strings.TrimPrefix(ref.Repository, ref.Registry())
+	case ref.Repository == r.Source:
+		rest = ""
+	case strings.HasPrefix(ref.Repository, r.Source+"/"):
+		rest = strings.TrimPrefix(ref.Repository, r.Source)
+	default:
+		return nil
+	}
+	refs := make([]string, 0, len(r.Mirrors))
+	for _, m := range r.Mirrors {
+		refs = append(refs, strings.TrimSuffix(m, "/")+rest+ref.suffix())
+	}
+	return refs
+}
+
+// ListRules returns the mirror rules of the cluster. ImageContentSourcePolicy is
+// deprecated, and it is not reported when the operator client is not set.
+func ListRules(ctx context.Context, oclient configclient.Interface, opclient operatorclient.Interface) ([]Rule, error) {
+	rules := []Rule{}
+	idms, err := oclient.ConfigV1().ImageDigestMirrorSets().List(ctx, kmmetav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", RuleKindIDMS, err)
+	}
+	for _, obj := range idms.Items {
+		for _, m := range obj.Spec.ImageDigestMirrors {
+			rule := Rule{Kind: RuleKindIDMS, Name: obj.Name, Source: m.Source}
+			for _, mirror := range m.Mirrors {
+				rule.Mirrors = append(rule.Mirrors, string(mirror))
+			}
+			rules = append(rules, rule)
+		}
+	}
+	itms, err := oclient.ConfigV1().ImageTagMirrorSets().List(ctx, kmmetav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", RuleKindITMS, err)
+	}
+	for _, obj := range itms.Items {
+		for _, m := range obj.Spec.ImageTagMirrors {
+			rule := Rule{Kind: RuleKindITMS, Name: obj.Name, Source: m.Source}
+			for _, mirror := range m.Mirrors {
+				rule.Mirrors = append(rule.Mirrors, string(mirror))
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if opclient != nil {
+		icsp, err := opclient.OperatorV1alpha1().ImageContentSourcePolicies().List(ctx, kmmetav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %w", RuleKindICSP, err)
+		}
+		for _, obj := range icsp.Items {
+			for _, m := range obj.Spec.RepositoryDigestMirrors {
+				rules = append(rules, Rule{Kind: RuleKindICSP, Name: obj.Name, Source: m.Source, Mirrors: m.Mirrors})
+			}
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Kind != rules[j].Kind {
+			return rules[i].Kind < rules[j].Kind
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+// Image is an image required by the tests, and the target in the mirror repository.
+type Image struct {
+	Source string `json:"source"`
+	Target string `json:"target,omitempty"`
+}
+
+// ParseMapping reads the image mapping (format of 'oc image mirror -f'), with the
+// source and the target image in each line. Empty lines and comments are ignored.
+func ParseMapping(file string) ([]Image, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	images := []Image{}
+	scanner := bufio.NewScanner(fd)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: invalid mapping %q, want 'SOURCE [TARGET]'", file, n, line)
+		}
+		img := Image{Source: fields[0]}
+		if len(fields) == 2 {
+			img.Target = fields[1]
+		}
+		images = append(images, img)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	return images, nil
+}
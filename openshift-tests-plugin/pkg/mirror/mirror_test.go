@@ -0,0 +1,330 @@
+package mirror
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	ocfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	opfake "github.com/openshift/client-go/operator/clientset/versioned/fake"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+const testDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    *Reference
+		wantErr bool
+	}{
+		{image: "busybox", want: &Reference{Repository: "docker.io/library/busybox", Tag: "latest"}},
+		{image: "openshift/origin:v4", want: &Reference{Repository: "docker.io/openshift/origin", Tag: "v4"}},
+		{image: "quay.io/openshift/community-e2e-images:e2e-1", want: &Reference{Repository: "quay.io/openshift/community-e2e-images", Tag: "e2e-1"}},
+		{image: "mirror.local:5000/ocp/release@" + testDigest, want: &Reference{Repository: "mirror.local:5000/ocp/release", Digest: testDigest}},
+		{image: "localhost/app", want: &Reference{Repository: "localhost/app", Tag: "latest"}},
+		{image: "", wantErr: true},
+		{image: "quay.io/app@invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := ParseReference(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	rules := []Rule{
+		{Kind: RuleKindIDMS, Name: "release", Source: "quay.io/openshift-release-dev", Mirrors: []string{"mirror.local/ocp/"}},
+		{Kind: RuleKindITMS, Name: "e2e", Source: "quay.io/openshift/community-e2e-images", Mirrors: []string{"mirror.local/e2e"}},
+		{Kind: RuleKindICSP, Name: "redhat", Source: "*.redhat.io", Mirrors: []string{"mirror.local/redhat"}},
+	}
+	tests := []struct {
+		name string
+		img  Image
+		want []string
+	}{
+		{
+			name: "digest with prefix rule and target",
+			img:  Image{Source: "quay.io/openshift-release-dev/ocp-v4.0-art-dev@" + testDigest, Target: "mirror.local/e2e:art"},
+			want: []string{"mirror.local/e2e:art", "mirror.local/ocp/ocp-v4.0-art-dev@" + testDigest},
+		},
+		{
+			name: "tag ignored by digest rules",
+			img:  Image{Source: "quay.io/openshift-release-dev/ocp-v4.0-art-dev:latest"},
+			want: []string{},
+		},
+		{
+			name: "tag with exact rule",
+			img:  Image{Source: "quay.io/openshift/community-e2e-images:e2e-1"},
+			want: []string{"mirror.local/e2e:e2e-1"},
+		},
+		{
+			name: "wildcard rule",
+			img:  Image{Source: "registry.redhat.io/ubi9/ubi@" + testDigest},
+			want: []string{"mirror.local/redhat/ubi9/ubi@" + testDigest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Candidates(tt.img, rules); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListRules(t *testing.T) {
+	oclient := ocfake.NewSimpleClientset(
+		&configv1.ImageDigestMirrorSet{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "idms"},
+			Spec: configv1.ImageDigestMirrorSetSpec{ImageDigestMirrors: []configv1.ImageDigestMirrors{
+				{Source: "quay.io/openshift-release-dev", Mirrors: []configv1.ImageMirror{"mirror.local/ocp"}},
+			}},
+		},
+		&configv1.ImageTagMirrorSet{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "itms"},
+			Spec: configv1.ImageTagMirrorSetSpec{ImageTagMirrors: []configv1.ImageTagMirrors{
+				{Source: "quay.io/openshift", Mirrors: []configv1.ImageMirror{"mirror.local/openshift"}},
+			}},
+		},
+	)
+	opclient := opfake.NewSimpleClientset(&operatorv1alpha1.ImageContentSourcePolicy{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "icsp"},
+		Spec: operatorv1alpha1.ImageContentSourcePolicySpec{RepositoryDigestMirrors: []operatorv1alpha1.RepositoryDigestMirrors{
+			{Source: "registry.redhat.io", Mirrors: []string{"mirror.local/redhat"}},
+		}},
+	})
+
+	rules, err := ListRules(context.TODO(), oclient, opclient)
+	if err != nil {
+		t.Fatalf("ListRules() unexpected error: %v", err)
+	}
+	want := []Rule{
+		{Kind: RuleKindICSP, Name: "icsp", Source: "registry.redhat.io", Mirrors: []string{"mirror.local/redhat"}},
+		{Kind: RuleKindIDMS, Name: "idms", Source: "quay.io/openshift-release-dev", Mirrors: []string{"mirror.local/ocp"}},
+		{Kind: RuleKindITMS, Name: "itms", Source: "quay.io/openshift", Mirrors: []string{"mirror.local/openshift"}},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("ListRules() = %+v, want %+v", rules, want)
+	}
+
+	rules, err = ListRules(context.TODO(), oclient, nil)
+	if err != nil || len(rules) != 2 {
+		t.Errorf("ListRules() without operator client = %+v, %v, want 2 rules", rules, err)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "images.mapping")
+	data := "# images\nquay.io/e2e/agnhost:2.52 mirror.local/e2e:agnhost\n\nregistry.k8s.io/pause:3.9\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	images, err := ParseMapping(file)
+	if err != nil {
+		t.Fatalf("ParseMapping() unexpected error: %v", err)
+	}
+	want := []Image{
+		{Source: "quay.io/e2e/agnhost:2.52", Target: "mirror.local/e2e:agnhost"},
+		{Source: "registry.k8s.io/pause:3.9"},
+	}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("ParseMapping() = %+v, want %+v", images, want)
+	}
+
+	if err := os.WriteFile(file, []byte("a b c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseMapping(file); err == nil {
+		t.Error("ParseMapping() expected error for invalid mapping")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	images := []Image{
+		{Source: "quay.io/e2e/unverified:1", Target: "mirror.local/e2e:unverified"},
+		{Source: "quay.io/e2e/missing:1", Target: "mirror.local/e2e:missing"},
+		{Source: "quay.io/e2e/agnhost:1", Target: "mirror.local/e2e:agnhost"},
+	}
+	check := func(_ context.Context, image string) (bool, error) {
+		switch image {
+		case "mirror.local/e2e:agnhost":
+			return true, nil
+		case "mirror.local/e2e:unverified":
+			return false, errors.New("connection refused")
+		}
+		return false, nil
+	}
+	report := Validate(context.TODO(), images, nil, check)
+	if report.Available != 1 || report.Missing != 1 || report.Unverified != 1 {
+		t.Errorf("Validate() = %d available, %d missing, %d unverified, want 1 of each", report.Available, report.Missing, report.Unverified)
+	}
+	if got := report.Images[0]; got.Source != "quay.io/e2e/agnhost:1" || got.Mirror != "mirror.local/e2e:agnhost" {
+		t.Errorf("Validate() first image = %+v, want agnhost available", got)
+	}
+	if missing := report.MissingImages(); !reflect.DeepEqual(missing, []string{"quay.io/e2e/missing:1"}) {
+		t.Errorf("MissingImages() = %v", missing)
+	}
+}
+
+func TestParsePullSecret(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass:word"))
+	auths, err := ParsePullSecret([]byte(fmt.Sprintf(`{"auths":{"mirror.local":{"auth":%q}}}`, auth)))
+	if err != nil {
+		t.Fatalf("ParsePullSecret() unexpected error: %v", err)
+	}
+	if got := auths["mirror.local"]; got.Username != "user" || got.Password != "pass:word" {
+		t.Errorf("ParsePullSecret() = %+v", got)
+	}
+	if _, err := ParsePullSecret([]byte(`{"auths":{"mirror.local":{"auth":"!"}}}`)); err == nil {
+		t.Error("ParsePullSecret() expected error for invalid auth")
+	}
+}
+
+func TestRegistryCheckerExists(t *testing.T) {
+	const token = "test-token"
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	realm := ""
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if scope := r.URL.Query().Get("scope"); scope != "repository:e2e/agnhost:pull" && scope != "repository:e2e/missing:pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `{"token":%q}`, token)
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, realm))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/v2/e2e/agnhost/manifests/") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server = httptest.NewTLSServer(mux)
+	defer server.Close()
+	realm = server.URL
+	host := strings.TrimPrefix(server.URL, "https://")
+	auths := map[string]Auth{host: {Username: "user", Password: "pass"}}
+
+	// the registry certificate is trusted by the additional trusted CA of the cluster.
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if _, err := NewRegistryChecker(auths, &RegistryConfig{TrustedCAs: map[string]string{host: "invalid"}}); err == nil {
+		t.Error("NewRegistryChecker() expected error for invalid trusted CA")
+	}
+	checker, err := NewRegistryChecker(auths, &RegistryConfig{TrustedCAs: map[string]string{host: string(caPEM)}})
+	if err != nil {
+		t.Fatalf("NewRegistryChecker() unexpected error: %v", err)
+	}
+	tests := []struct {
+		image   string
+		want    bool
+		wantErr bool
+	}{
+		{image: host + "/e2e/agnhost:2.52", want: true},
+		{image: host + "/e2e/missing@" + testDigest, want: false},
+		{image: host + "/e2e/forbidden:1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := checker.Exists(context.TODO(), tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exists() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Exists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the credentials are sent to http token realms only when the registry is insecure.
+	insecureRealm := httptest.NewServer(mux)
+	defer insecureRealm.Close()
+	realm = insecureRealm.URL
+	if _, err := checker.Exists(context.TODO(), host+"/e2e/agnhost:2.52"); err == nil || !strings.Contains(err.Error(), "not https") {
+		t.Errorf("Exists() error = %v, want http token realm rejected", err)
+	}
+	checker.Insecure = []string{"*.example.com", host}
+	if got, err := checker.Exists(context.TODO(), host+"/e2e/agnhost:2.52"); err != nil || !got {
+		t.Errorf("Exists() = %v (%v), want true with insecure registry", got, err)
+	}
+}
+
+func TestReadRegistryConfig(t *testing.T) {
+	image := &configv1.Image{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.ImageSpec{
+			AdditionalTrustedCA: configv1.ConfigMapNameReference{Name: "registry-ca"},
+			RegistrySources:     configv1.RegistrySources{InsecureRegistries: []string{"mirror.local:5000"}},
+		},
+	}
+	ca := &kcorev1.ConfigMap{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "registry-ca", Namespace: "openshift-config"},
+		Data:       map[string]string{"mirror.local..5000": "ca"},
+	}
+	config, err := ReadRegistryConfig(context.TODO(), ocfake.NewSimpleClientset(image), kfake.NewSimpleClientset(ca))
+	if err != nil {
+		t.Fatalf("ReadRegistryConfig() unexpected error: %v", err)
+	}
+	want := &RegistryConfig{TrustedCAs: ca.Data, Insecure: []string{"mirror.local:5000"}}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("ReadRegistryConfig() = %+v, want %+v", config, want)
+	}
+	if _, err := ReadRegistryConfig(context.TODO(), ocfake.NewSimpleClientset(image), kfake.NewSimpleClientset()); err == nil {
+		t.Error("ReadRegistryConfig() expected error for missing trusted CA ConfigMap")
+	}
+}
+
+func TestRegistryCheckerIsInsecure(t *testing.T) {
+	checker := &RegistryChecker{Insecure: []string{"mirror.local:5000", "*.example.com"}}
+	for host, want := range map[string]bool{
+		"mirror.local:5000":     true,
+		"mirror.local":          false,
+		"registry.example.com":  true,
+		"example.com":           false,
+		"registry.example.comx": false,
+	} {
+		if got := checker.isInsecure(host); got != want {
+			t.Errorf("isInsecure(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.local/token",service="registry",scope="repository:a:pull"`)
+	want := map[string]string{"realm": "https://auth.local/token", "service": "registry", "scope": "repository:a:pull"}
+	if scheme != "bearer" || !reflect.DeepEqual(params, want) {
+		t.Errorf("parseChallenge() = %q %v, want bearer %v", scheme, params, want)
+	}
+	if scheme, _ := parseChallenge(`Basic realm="registry"`); scheme != "basic" {
+		t.Errorf("parseChallenge() scheme = %q, want basic", scheme)
+	}
+}
@@ -0,0 +1,84 @@
+package mirror
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubRegistry     = "docker.io"
+	dockerHubRegistryHost = "registry-1.docker.io"
+)
+
+// Reference is a parsed image reference: registry/repository[:tag][@digest].
+type Reference struct {
+	// Repository is the repository including the registry host.
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses the image reference. References without registry host
+// are resolved to docker.io, and references without tag and digest to 'latest'.
+func ParseReference(image string) (*Reference, error) {
+	if len(image) == 0 || strings.ContainsAny(image, " \t") {
+		return nil, fmt.Errorf("invalid image reference %q", image)
+	}
+	ref := &Reference{Repository: image}
+	if repo, digest, found := strings.Cut(ref.Repository, "@"); found {
+		if !strings.Contains(digest, ":") {
+			return nil, fmt.Errorf("invalid digest in image reference %q", image)
+		}
+		ref.Repository, ref.Digest = repo, digest
+	}
+	// the tag is after the last '/', to not match the registry port.
+	if i := strings.LastIndex(ref.Repository, ":"); i > strings.LastIndex(ref.Repository, "/") {
+		ref.Repository, ref.Tag = ref.Repository[:i], ref.Repository[i+1:]
+	}
+	if len(ref.Repository) == 0 {
+		return nil, fmt.Errorf("invalid image reference %q", image)
+	}
+	first, _, found := strings.Cut(ref.Repository, "/")
+	if !found || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		if !found {
+			ref.Repository = "library/" + ref.Repository
+		}
+		ref.Repository = dockerHubRegistry + "/" + ref.Repository
+	}
+	if len(ref.Tag) == 0 && len(ref.Digest) == 0 {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// Registry returns the registry host of the reference.
+func (r *Reference) Registry() string {
+	registry, _, _ := strings.Cut(r.Repository, "/")
+	return registry
+}
+
+// Name returns the repository name in the registry.
+func (r *Reference) Name() string {
+	_, name, _ := strings.Cut(r.Repository, "/")
+	return name
+}
+
+// Ref returns the digest when set, or the tag, used to query the manifest.
+func (r *Reference) Ref() string {
+	if len(r.Digest) > 0 {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// suffix returns the tag and digest of the reference.
+func (r *Reference) suffix() string {
+	if len(r.Digest) > 0 {
+		return "@" + r.Digest
+	}
+	return ":" + r.Tag
+}
+
+func (r *Reference) String() string {
+	return r.Repository + r.suffix()
+}
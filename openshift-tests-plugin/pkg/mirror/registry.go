@@ -0,0 +1,286 @@
+package mirror
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// manifestMediaTypes are the manifest types accepted when checking the images.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Auth is the registry credential of the pull secret.
+type Auth struct {
+	Username string
+	Password string
+}
+
+// ParsePullSecret returns the credentials by registry host of the pull secret
+// (docker config JSON).
+func ParsePullSecret(data []byte) (map[string]Auth, error) {
+	config := struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid pull secret: %w", err)
+	}
+	auths := make(map[string]Auth, len(config.Auths))
+	for registry, entry := range config.Auths {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pull secret auth for %s: %w", registry, err)
+		}
+		user, pass, _ := strings.Cut(string(decoded), ":")
+		auths[registry] = Auth{Username: user, Password: pass}
+	}
+	return auths, nil
+}
+
+// RegistryConfig is the registries configuration of the cluster (image config).
+type RegistryConfig struct {
+	// TrustedCAs are the PEM encoded CAs by registry (additionalTrustedCA).
+	TrustedCAs map[string]string
+	// Insecure are the registries configured as insecure (registrySources.insecureRegistries),
+	// example: mirror.local:5000 or *.example.com.
+	Insecure []string
+}
+
+// ReadRegistryConfig reads the additional trusted CAs and the insecure registries of
+// the cluster image config.
+func ReadRegistryConfig(ctx context.Context, oclient configclient.Interface, kclient kubernetes.Interface) (*RegistryConfig, error) {
+	image, err := oclient.ConfigV1().Images().Get(ctx, "cluster", kmmetav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading image config: %w", err)
+	}
+	config := &RegistryConfig{
+		TrustedCAs: map[string]string{},
+		Insecure:   image.Spec.RegistrySources.InsecureRegistries,
+	}
+	if name := image.Spec.AdditionalTrustedCA.Name; len(name) > 0 {
+		cm, err := kclient.CoreV1().ConfigMaps("openshift-config").Get(ctx, name, kmmetav1.GetOptions{})
+		if err != nil {
+			return config, fmt.Errorf("error reading additional trusted CA %s: %w", name, err)
+		}
+		config.TrustedCAs = cm.Data
+	}
+	return config, nil
+}
+
+// RegistryChecker checks the image manifests exist in the registries, with the
+// registry v2 API, authenticated by the pull secret credentials.
+type RegistryChecker struct {
+	HTTPClient *http.Client
+	Auths      map[string]Auth
+	// Insecure are the registries configured as insecure, which may issue the tokens
+	// over http. The credentials are sent only over https to the other registries.
+	Insecure []string
+}
+
+// NewRegistryChecker creates the checker with the pull secret credentials, trusting the
+// system CAs and the additional trusted CAs of the registry config, when not nil.
+func NewRegistryChecker(auths map[string]Auth, config *RegistryConfig) (*RegistryChecker, error) {
+	checker := &RegistryChecker{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Auths:      auths,
+	}
+	if config == nil {
+		return checker, nil
+	}
+	checker.Insecure = config.Insecure
+	if len(config.TrustedCAs) == 0 {
+		return checker, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	registries := make([]string, 0, len(config.TrustedCAs))
+	for registry := range config.TrustedCAs {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+	for _, registry := range registries {
+		if !pool.AppendCertsFromPEM([]byte(config.TrustedCAs[registry])) {
+			return nil, fmt.Errorf("invalid additional trusted CA of registry %s", registry)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	checker.HTTPClient.Transport = transport
+	return checker, nil
+}
+
+// isInsecure returns true when the registry host is configured as insecure.
+func (c *RegistryChecker) isInsecure(host string) bool {
+	for _, registry := range c.Insecure {
+		if registry == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(registry, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Exists returns true when the manifest of the image exists in the registry, false
+// when the registry reports the manifest not found, or an error when the image
+// cannot be checked (example: registry not reachable or unauthorized).
+func (c *RegistryChecker) Exists(ctx context.Context, image string) (bool, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return false, err
+	}
+	host := ref.Registry()
+	auth, hasAuth := c.Auths[host]
+	if host == dockerHubRegistry {
+		host = dockerHubRegistryHost
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, ref.Name(), ref.Ref())
+
+	resp, err := c.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.authorize(ctx, resp.Header.Get("WWW-Authenticate"), ref, auth, hasAuth)
+		if err != nil {
+			return false, fmt.Errorf("error authenticating in %s: %w", host, err)
+		}
+		if resp, err = c.headManifest(ctx, manifestURL, authorization); err != nil {
+			return false, err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected status checking %s: %s", ref, resp.Status)
+}
+
+func (c *RegistryChecker) headManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// authorize returns the Authorization header answering the registry challenge:
+// the basic credentials, or the bearer token issued by the token service.
+func (c *RegistryChecker) authorize(ctx context.Context, challenge string, ref *Reference, auth Auth, hasAuth bool) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password))
+	switch scheme {
+	case "basic":
+		if !hasAuth {
+			return "", fmt.Errorf("credentials not found in the pull secret")
+		}
+		return basic, nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || len(params["realm"]) == 0 {
+		return "", fmt.Errorf("invalid token realm in challenge %q", challenge)
+	}
+	if tokenURL.Scheme != "https" && !c.isInsecure(ref.Registry()) {
+		return "", fmt.Errorf("token realm %s is not https, registry %s is not configured as insecure", tokenURL.Redacted(), ref.Registry())
+	}
+	query := tokenURL.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.Name()))
+	tokenURL.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasAuth {
+		req.Header.Set("Authorization", basic)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %s", resp.Status)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge parses the WWW-Authenticate header, returning the lower case
+// scheme and the parameters, example: Bearer realm="https://auth",service="registry".
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for len(rest) > 0 {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[strings.ToLower(strings.TrimSpace(key))] = value[1:]
+				break
+			}
+			params[strings.ToLower(strings.TrimSpace(key))] = value[1 : end+1]
+			rest = value[end+2:]
+			continue
+		}
+		value, rest, _ = strings.Cut(value, ",")
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return strings.ToLower(scheme), params
+}
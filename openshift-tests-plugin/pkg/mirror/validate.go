@@ -0,0 +1,125 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+)
+
+const (
+	// ImageStatusAvailable is the image found in the mirror.
+	ImageStatusAvailable = "available"
+	// ImageStatusMissing is the image not found in the mirror, or without mirror.
+	ImageStatusMissing = "missing"
+	// ImageStatusUnverified is the image which could not be checked in the registry.
+	ImageStatusUnverified = "unverified"
+)
+
+// CheckFunc returns true when the image exists in the registry.
+type CheckFunc func(ctx context.Context, image string) (bool, error)
+
+// ImageResult is the validation of an image required by the tests.
+type ImageResult struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	// Mirror is the image found in the mirror.
+	Mirror string `json:"mirror,omitempty"`
+	// Candidates are the mirrored images checked in the registries.
+	Candidates []string `json:"candidates,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// Report is the validation of the mirror.
+type Report struct {
+	MirrorRepository string        `json:"mirrorRepository,omitempty"`
+	Rules            []Rule        `json:"rules"`
+	Images           []ImageResult `json:"images"`
+	Available        int           `json:"available"`
+	Missing          int           `json:"missing"`
+	Unverified       int           `json:"unverified"`
+}
+
+// MissingImages returns the source of the images missing in the mirror.
+func (r *Report) MissingImages() []string {
+	missing := []string{}
+	for _, img := range r.Images {
+		if img.Status == ImageStatusMissing {
+			missing = append(missing, img.Source)
+		}
+	}
+	return missing
+}
+
+// Save writes the report as JSON.
+func (r *Report) Save(file string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// Candidates returns the mirrored images of the required image: the target in the
+// mirror repository, followed by the images resolved by the cluster mirror rules.
+func Candidates(img Image, rules []Rule) []string {
+	candidates := []string{}
+	seen := map[string]struct{}{}
+	add := func(image string) {
+		if _, ok := seen[image]; !ok {
+			seen[image] = struct{}{}
+			candidates = append(candidates, image)
+		}
+	}
+	if len(img.Target) > 0 {
+		add(img.Target)
+	}
+	ref, err := ParseReference(img.Source)
+	if err != nil {
+		return candidates
+	}
+	for i := range rules {
+		for _, image := range rules[i].Resolve(ref) {
+			add(image)
+		}
+	}
+	return candidates
+}
+
+// Validate checks the required images are available in the mirror. An image is
+// missing when it has no mirror, or when no candidate exists in the registries.
+func Validate(ctx context.Context, images []Image, rules []Rule, check CheckFunc) *Report {
+	report := &Report{Rules: rules, Images: make([]ImageResult, 0, len(images))}
+	for _, img := range images {
+		res := ImageResult{Source: img.Source, Status: ImageStatusMissing, Candidates: Candidates(img, rules)}
+		for _, candidate := range res.Candidates {
+			found, err := check(ctx, candidate)
+			if err != nil {
+				res.Errors = append(res.Errors, err.Error())
+				continue
+			}
+			if found {
+				res.Status = ImageStatusAvailable
+				res.Mirror = candidate
+				res.Errors = nil
+				break
+			}
+		}
+		if res.Status == ImageStatusMissing && len(res.Errors) > 0 {
+			res.Status = ImageStatusUnverified
+		}
+		switch res.Status {
+		case ImageStatusAvailable:
+			report.Available++
+		case ImageStatusMissing:
+			report.Missing++
+		case ImageStatusUnverified:
+			report.Unverified++
+		}
+		report.Images = append(report.Images, res)
+	}
+	sort.SliceStable(report.Images, func(i, j int) bool {
+		return report.Images[i].Source < report.Images[j].Source
+	})
+	return report
+}
@@ -0,0 +1,455 @@
+/*
+Package monitor summarizes the results of the openshift-tests monitors.
+
+The monitors enabled in openshift-tests (--monitor) write the intervals observed in
+the cluster while the tests run (e2e-intervals*.json, and e2e-events*.json in older
+releases) and the results of the monitor tests (junit_*monitor*.xml) into the JUnit
+directory. The intervals are read in the current format (locator and message as
+objects) and in the legacy format (locator and message as strings, example:
+"disruption/kube-api connection/new"), and summarized by:
+  - disruption: the windows the backends were not available, by backend;
+  - alerts: the alerts firing, by alert and namespace;
+  - etcd: the events reported by the etcd monitors, example: etcd-log-analyzer;
+  - monitor tests: the failed monitor tests.
+*/
+package monitor
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File patterns of the monitor outputs in the JUnit directory.
+const (
+	IntervalsPattern    = "e2e-intervals*.json"
+	EventsPattern       = "e2e-events*.json"
+	MonitorJUnitPattern = "junit_*monitor*.xml"
+)
+
+const (
+	// maxEtcdEvents is the count of the last etcd events kept in the summary.
+	maxEtcdEvents = 50
+	// maxMessageLength is the length limit of the messages kept in the summary.
+	maxMessageLength = 512
+)
+
+// Interval is an interval observed by the monitors.
+type Interval struct {
+	Level  string
+	Source string
+	// Locator is the resource of the interval, and Keys the locator parsed.
+	Locator string
+	Keys    map[string]string
+	Reason  string
+	Message string
+	// Annotations are the message annotations, example: alertstate/firing.
+	Annotations map[string]string
+	From        time.Time
+	To          time.Time
+}
+
+// Duration returns the duration of the interval, zero when not finished.
+func (i *Interval) Duration() time.Duration {
+	if i.To.IsZero() || i.To.Before(i.From) {
+		return 0
+	}
+	return i.To.Sub(i.From)
+}
+
+// rawInterval is the interval in the current and the legacy formats.
+type rawInterval struct {
+	Level      string          `json:"level"`
+	Source     string          `json:"source"`
+	TempSource string          `json:"tempSource"`
+	Locator    json.RawMessage `json:"locator"`
+	Message    json.RawMessage `json:"message"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+}
+
+// ReadIntervals reads the intervals of the monitor file.
+func ReadIntervals(file string) ([]*Interval, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading intervals file: %w", err)
+	}
+	var doc struct {
+		Items []rawInterval `json:"items"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing intervals file %s: %w", filepath.Base(file), err)
+	}
+	intervals := make([]*Interval, 0, len(doc.Items))
+	for _, raw := range doc.Items {
+		i := &Interval{Level: raw.Level, Source: raw.Source, From: raw.From, To: raw.To, Keys: map[string]string{}, Annotations: map[string]string{}}
+		if len(i.Source) == 0 {
+			i.Source = raw.TempSource
+		}
+		parseLocator(i, raw.Locator)
+		parseMessage(i, raw.Message)
+		intervals = append(intervals, i)
+	}
+	return intervals, nil
+}
+
+// parseLocator parses the locator object ({"type": "...", "keys": {...}}), or the
+// legacy locator string ("key/value key/value").
+func parseLocator(i *Interval, data json.RawMessage) {
+	var locator struct {
+		Type string            `json:"type"`
+		Keys map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &locator); err == nil {
+		keys := make([]string, 0, len(locator.Keys))
+		for k, v := range locator.Keys {
+			i.Keys[k] = v
+			keys = append(keys, k+"/"+v)
+		}
+		sort.Strings(keys)
+		i.Locator = strings.Join(keys, " ")
+		return
+	}
+	if err := json.Unmarshal(data, &i.Locator); err != nil {
+		return
+	}
+	for _, field := range strings.Fields(i.Locator) {
+		if k, v, ok := strings.Cut(field, "/"); ok {
+			i.Keys[k] = v
+		}
+	}
+}
+
+// parseMessage parses the message object ({"reason": "...", "humanMessage": "...",
+// "annotations": {...}}), or the legacy message string, prefixed by the annotations
+// ("reason/DisruptionBegan alertstate/firing message").
+func parseMessage(i *Interval, data json.RawMessage) {
+	var message struct {
+		Reason       string            `json:"reason"`
+		HumanMessage string            `json:"humanMessage"`
+		Annotations  map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(data, &message); err == nil {
+		i.Reason, i.Message = message.Reason, message.HumanMessage
+		for k, v := range message.Annotations {
+			i.Annotations[k] = v
+		}
+		if len(i.Reason) == 0 {
+			i.Reason = i.Annotations["reason"]
+		}
+		return
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return
+	}
+	fields := strings.Fields(text)
+	for len(fields) > 0 {
+		k, v, ok := strings.Cut(fields[0], "/")
+		if !ok || len(k) == 0 || strings.ContainsAny(k, ":.") {
+			break
+		}
+		i.Annotations[k] = v
+		fields = fields[1:]
+	}
+	i.Reason = i.Annotations["reason"]
+	i.Message = strings.Join(fields, " ")
+}
+
+// DisruptionWindow is a window the backend was not available.
+type DisruptionWindow struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Seconds  float64   `json:"seconds"`
+	Message  string    `json:"message,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+}
+
+// Disruption is the disruption of a backend.
+type Disruption struct {
+	Backend        string              `json:"backend"`
+	Connection     string              `json:"connection,omitempty"`
+	Windows        int                 `json:"windows"`
+	TotalSeconds   float64             `json:"totalSeconds"`
+	LongestSeconds float64             `json:"longestSeconds"`
+	Intervals      []*DisruptionWindow `json:"intervals"`
+}
+
+// Alert is the firing of an alert.
+type Alert struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+	Count     int       `json:"count"`
+	Seconds   float64   `json:"seconds"`
+	FirstAt   time.Time `json:"firstAt"`
+	LastAt    time.Time `json:"lastAt"`
+}
+
+// EtcdEvent is an event reported by the etcd monitors.
+type EtcdEvent struct {
+	From    time.Time `json:"from"`
+	Level   string    `json:"level,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Locator string    `json:"locator,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Etcd are the events reported by the etcd monitors.
+type Etcd struct {
+	Events   int            `json:"events"`
+	ByReason map[string]int `json:"byReason"`
+	ByLevel  map[string]int `json:"byLevel"`
+	// Last are the last events, limited to maxEtcdEvents.
+	Last []*EtcdEvent `json:"last,omitempty"`
+}
+
+// MonitorTestFailure is a failed monitor test.
+type MonitorTestFailure struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+}
+
+// MonitorTests are the results of the monitor tests.
+type MonitorTests struct {
+	Total    int                   `json:"total"`
+	Failed   int                   `json:"failed"`
+	Skipped  int                   `json:"skipped"`
+	Failures []*MonitorTestFailure `json:"failures,omitempty"`
+}
+
+// Summary is the summary of the monitor outputs.
+type Summary struct {
+	Plugin string `json:"plugin,omitempty"`
+	// Monitors are the monitors enabled in openshift-tests.
+	Monitors []string `json:"monitors,omitempty"`
+	// Files are the monitor outputs read, relative to the JUnit directory.
+	Files        []string      `json:"files"`
+	Intervals    int           `json:"intervals"`
+	Disruptions  []*Disruption `json:"disruptions"`
+	Alerts       []*Alert      `json:"alerts"`
+	Etcd         *Etcd         `json:"etcd"`
+	MonitorTests *MonitorTests `json:"monitorTests"`
+	// Errors are the monitor outputs not parsed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Empty returns true when no monitor outputs are read.
+func (s *Summary) Empty() bool {
+	return len(s.Files) == 0
+}
+
+// String returns the counters of the summary.
+func (s *Summary) String() string {
+	windows := 0
+	for _, d := range s.Disruptions {
+		windows += d.Windows
+	}
+	return fmt.Sprintf("files=%d intervals=%d disruptions=%d/%d alerts=%d etcd=%d monitorTests=%d/%d",
+		len(s.Files), s.Intervals, len(s.Disruptions), windows, len(s.Alerts), s.Etcd.Events, s.MonitorTests.Failed, s.MonitorTests.Total)
+}
+
+// Summarize reads and summarizes the monitor outputs of the JUnit directory. The files
+// not parsed are reported in the summary errors.
+func Summarize(dir string) (*Summary, error) {
+	s := &Summary{
+		Files:        []string{},
+		Disruptions:  []*Disruption{},
+		Alerts:       []*Alert{},
+		Etcd:         &Etcd{ByReason: map[string]int{}, ByLevel: map[string]int{}},
+		MonitorTests: &MonitorTests{},
+	}
+	intervalFiles := []string{}
+	for _, pattern := range []string{IntervalsPattern, EventsPattern} {
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("error finding monitor files: %w", err)
+		}
+		intervalFiles = append(intervalFiles, files...)
+	}
+	// the intervals are reported by the intervals and the events files in some releases.
+	seen := map[string]struct{}{}
+	intervals := []*Interval{}
+	for _, file := range intervalFiles {
+		items, err := ReadIntervals(file)
+		if err != nil {
+			s.Errors = append(s.Errors, err.Error())
+			continue
+		}
+		s.Files = append(s.Files, filepath.Base(file))
+		for _, i := range items {
+			key := fmt.Sprintf("%s|%s|%s|%s|%s|%d|%d", i.Level, i.Source, i.Locator, i.Reason, i.Message, i.From.UnixNano(), i.To.UnixNano())
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			intervals = append(intervals, i)
+		}
+	}
+	sort.SliceStable(intervals, func(a, b int) bool { return intervals[a].From.Before(intervals[b].From) })
+	s.Intervals = len(intervals)
+	s.addIntervals(intervals)
+
+	junits, err := filepath.Glob(filepath.Join(dir, MonitorJUnitPattern))
+	if err != nil {
+		return nil, fmt.Errorf("error finding monitor files: %w", err)
+	}
+	for _, file := range junits {
+		if err := s.addMonitorTests(file); err != nil {
+			s.Errors = append(s.Errors, err.Error())
+			continue
+		}
+		s.Files = append(s.Files, filepath.Base(file))
+	}
+	return s, nil
+}
+
+// addIntervals summarizes the disruptions, the alerts and the etcd events of the intervals.
+func (s *Summary) addIntervals(intervals []*Interval) {
+	disruptions := map[string]*Disruption{}
+	alerts := map[string]*Alert{}
+	for _, i := range intervals {
+		switch {
+		case isDisruption(i):
+			backend := firstOf(i.Keys, "backend-disruption-name", "disruption")
+			key := backend + "|" + i.Keys["connection"]
+			d, ok := disruptions[key]
+			if !ok {
+				d = &Disruption{Backend: backend, Connection: i.Keys["connection"], Intervals: []*DisruptionWindow{}}
+				disruptions[key] = d
+				s.Disruptions = append(s.Disruptions, d)
+			}
+			seconds := i.Duration().Seconds()
+			d.Windows++
+			d.TotalSeconds += seconds
+			if seconds > d.LongestSeconds {
+				d.LongestSeconds = seconds
+			}
+			d.Intervals = append(d.Intervals, &DisruptionWindow{From: i.From, To: i.To, Seconds: seconds, Message: truncate(i.Message), Severity: i.Level})
+
+		case isAlertFiring(i):
+			name, namespace := i.Keys["alert"], firstOf(i.Keys, "namespace", "ns")
+			key := name + "|" + namespace
+			a, ok := alerts[key]
+			if !ok {
+				a = &Alert{Name: name, Namespace: namespace, FirstAt: i.From}
+				alerts[key] = a
+				s.Alerts = append(s.Alerts, a)
+			}
+			if severity := firstOf(i.Annotations, "severity"); len(severity) > 0 {
+				a.Severity = severity
+			}
+			a.Count++
+			a.Seconds += i.Duration().Seconds()
+			end := i.To
+			if end.IsZero() {
+				end = i.From
+			}
+			if end.After(a.LastAt) {
+				a.LastAt = end
+			}
+
+		case isEtcd(i):
+			s.Etcd.Events++
+			s.Etcd.ByReason[i.Reason]++
+			s.Etcd.ByLevel[i.Level]++
+			s.Etcd.Last = append(s.Etcd.Last, &EtcdEvent{From: i.From, Level: i.Level, Reason: i.Reason, Locator: i.Locator, Message: truncate(i.Message)})
+			if len(s.Etcd.Last) > maxEtcdEvents {
+				s.Etcd.Last = s.Etcd.Last[1:]
+			}
+		}
+	}
+	sort.SliceStable(s.Disruptions, func(a, b int) bool { return s.Disruptions[a].TotalSeconds > s.Disruptions[b].TotalSeconds })
+	sort.SliceStable(s.Alerts, func(a, b int) bool { return s.Alerts[a].Seconds > s.Alerts[b].Seconds })
+}
+
+// isDisruption returns true for the intervals the backend was not available.
+func isDisruption(i *Interval) bool {
+	if i.Source != "Disruption" && len(i.Keys["backend-disruption-name"]) == 0 && len(i.Keys["disruption"]) == 0 {
+		return false
+	}
+	return i.Reason == "DisruptionBegan" || (len(i.Reason) == 0 && i.Level == "Error")
+}
+
+// isAlertFiring returns true for the intervals of the alerts firing.
+func isAlertFiring(i *Interval) bool {
+	if len(i.Keys["alert"]) == 0 {
+		return false
+	}
+	if state, ok := i.Annotations["alertstate"]; ok {
+		return state == "firing"
+	}
+	return i.Level != "Info"
+}
+
+// isEtcd returns true for the intervals reported by the etcd monitors.
+func isEtcd(i *Interval) bool {
+	return strings.HasPrefix(strings.ToLower(i.Source), "etcd") || strings.HasPrefix(strings.ToLower(i.Reason), "etcd")
+}
+
+// addMonitorTests counts the results of the monitor tests of the JUnit file.
+func (s *Summary) addMonitorTests(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading monitor JUnit: %w", err)
+	}
+	type testCase struct {
+		Name    string    `xml:"name,attr"`
+		Skipped *struct{} `xml:"skipped"`
+		Failure *struct {
+			Message string `xml:"message,attr"`
+			Text    string `xml:",chardata"`
+		} `xml:"failure"`
+	}
+	var root struct {
+		TestCases []testCase `xml:"testcase"`
+		Suites    []struct {
+			TestCases []testCase `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("error parsing monitor JUnit %s: %w", filepath.Base(file), err)
+	}
+	cases := root.TestCases
+	for _, suite := range root.Suites {
+		cases = append(cases, suite.TestCases...)
+	}
+	for _, tc := range cases {
+		s.MonitorTests.Total++
+		switch {
+		case tc.Failure != nil:
+			s.MonitorTests.Failed++
+			message := tc.Failure.Message
+			if len(strings.TrimSpace(message)) == 0 {
+				message = tc.Failure.Text
+			}
+			s.MonitorTests.Failures = append(s.MonitorTests.Failures, &MonitorTestFailure{Name: tc.Name, Message: truncate(strings.TrimSpace(message))})
+		case tc.Skipped != nil:
+			s.MonitorTests.Skipped++
+		}
+	}
+	return nil
+}
+
+// firstOf returns the first value set of the keys.
+func firstOf(values map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := values[k]; len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
+
+// truncate limits the message to maxMessageLength.
+func truncate(message string) string {
+	if len(message) <= maxMessageLength {
+		return message
+	}
+	return strings.ToValidUTF8(message[:maxMessageLength], "") + "..."
+}
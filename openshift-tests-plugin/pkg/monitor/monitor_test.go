@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// intervals is the intervals file in the current format.
+const intervals = `{"items": [
+  {"level": "Error", "source": "Disruption",
+   "locator": {"type": "Disruption", "keys": {"backend-disruption-name": "kube-api-new-connections", "connection": "new", "disruption": "openshift-tests"}},
+   "message": {"reason": "DisruptionBegan", "humanMessage": "backend unreachable: dial tcp 10.0.0.1:6443: i/o timeout"},
+   "from": "2024-07-05T20:30:00Z", "to": "2024-07-05T20:30:04Z"},
+  {"level": "Info", "source": "Disruption",
+   "locator": {"type": "Disruption", "keys": {"backend-disruption-name": "kube-api-new-connections", "connection": "new"}},
+   "message": {"reason": "DisruptionEnded", "humanMessage": "backend available"},
+   "from": "2024-07-05T20:30:04Z", "to": "2024-07-05T20:40:00Z"},
+  {"level": "Error", "source": "Disruption",
+   "locator": {"type": "Disruption", "keys": {"backend-disruption-name": "kube-api-new-connections", "connection": "new"}},
+   "message": {"reason": "DisruptionBegan", "humanMessage": "backend unreachable"},
+   "from": "2024-07-05T20:41:00Z", "to": "2024-07-05T20:41:10Z"},
+  {"level": "Warning", "source": "Alert",
+   "locator": {"type": "Alert", "keys": {"alert": "etcdMembersDown", "namespace": "openshift-etcd"}},
+   "message": {"reason": "", "humanMessage": "etcd members are down", "annotations": {"alertstate": "firing", "severity": "critical"}},
+   "from": "2024-07-05T20:31:00Z", "to": "2024-07-05T20:33:00Z"},
+  {"level": "Info", "source": "Alert",
+   "locator": {"type": "Alert", "keys": {"alert": "Watchdog", "namespace": "openshift-monitoring"}},
+   "message": {"annotations": {"alertstate": "pending", "severity": "none"}},
+   "from": "2024-07-05T20:31:00Z", "to": "2024-07-05T20:33:00Z"},
+  {"level": "Warning", "source": "EtcdLog",
+   "locator": {"type": "Node", "keys": {"node": "master-0"}},
+   "message": {"reason": "EtcdLeaderChanged", "humanMessage": "elected leader 8e9e05c52164694d at term 4"},
+   "from": "2024-07-05T20:32:00Z", "to": "2024-07-05T20:32:01Z"},
+  {"level": "Info", "source": "KubeEvent",
+   "locator": {"type": "Pod", "keys": {"namespace": "e2e-job-4721", "pod": "pod-x2k9z"}},
+   "message": {"reason": "Scheduled", "humanMessage": "pod scheduled"},
+   "from": "2024-07-05T20:32:00Z", "to": "2024-07-05T20:32:00Z"}
+]}`
+
+// events is the events file in the legacy format, with one interval of the intervals file.
+const events = `{"items": [
+  {"level": "Error", "locator": "disruption/kube-api connection/reused",
+   "message": "reason/DisruptionBegan kube-api-reused-connections stopped responding to GET requests over reused connections: https://10.0.0.1:6443/api",
+   "from": "2024-07-05T20:35:00Z", "to": "2024-07-05T20:35:02Z"},
+  {"level": "Error", "locator": "alert/KubeAPIErrorBudgetBurn ns/openshift-kube-apiserver",
+   "message": "alertstate/firing severity/critical KubeAPIErrorBudgetBurn fired",
+   "from": "2024-07-05T20:36:00Z", "to": "2024-07-05T20:37:00Z"},
+  {"level": "Warning", "tempSource": "EtcdLog", "locator": "node/master-1",
+   "message": "reason/EtcdSlowRequest slow request took 1.2s",
+   "from": "2024-07-05T20:38:00Z", "to": "2024-07-05T20:38:00Z"},
+  {"level": "Warning", "source": "EtcdLog",
+   "locator": {"type": "Node", "keys": {"node": "master-0"}},
+   "message": {"reason": "EtcdLeaderChanged", "humanMessage": "elected leader 8e9e05c52164694d at term 4"},
+   "from": "2024-07-05T20:32:00Z", "to": "2024-07-05T20:32:01Z"}
+]}`
+
+const monitorJUnit = `<testsuite name="openshift-tests-monitor" tests="3">
+  <testcase name="[sig-arch] Monitor cluster should not have disruption"><failure message="kube-api was unreachable for 14s"></failure></testcase>
+  <testcase name="[sig-etcd] etcd leader changes are not excessive"></testcase>
+  <testcase name="[sig-node] Monitor skipped"><skipped message="not applicable"></skipped></testcase>
+</testsuite>`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadIntervals(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"e2e-events_20240705.json": events})
+	items, err := ReadIntervals(filepath.Join(dir, "e2e-events_20240705.json"))
+	if err != nil {
+		t.Fatalf("ReadIntervals() unexpected error: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("ReadIntervals() = %d intervals, want 4", len(items))
+	}
+	legacy := items[0]
+	if legacy.Keys["disruption"] != "kube-api" || legacy.Keys["connection"] != "reused" || legacy.Reason != "DisruptionBegan" ||
+		!strings.HasPrefix(legacy.Message, "kube-api-reused-connections stopped") {
+		t.Errorf("ReadIntervals() legacy interval = %+v, want the locator and the message parsed", legacy)
+	}
+	if alert := items[1]; alert.Annotations["alertstate"] != "firing" || alert.Keys["ns"] != "openshift-kube-apiserver" {
+		t.Errorf("ReadIntervals() alert = %+v, want the annotations parsed", alert)
+	}
+	if etcd := items[2]; etcd.Source != "EtcdLog" {
+		t.Errorf("ReadIntervals() source = %q, want the temp source of the legacy format", etcd.Source)
+	}
+	if current := items[3]; current.Keys["node"] != "master-0" || current.Locator != "node/master-0" || current.Reason != "EtcdLeaderChanged" {
+		t.Errorf("ReadIntervals() interval = %+v, want the locator and the message objects parsed", current)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"e2e-intervals_everything_20240705-202749.json": intervals,
+		"e2e-events_20240705-202749.json":               events,
+		"e2e-intervals_invalid.json":                    "{",
+		"junit_e2e-monitor-tests__20240705-202749.xml":  monitorJUnit,
+		"junit_e2e__20240705-202749.xml":                `<testsuite><testcase name="not a monitor"/></testsuite>`,
+	})
+	s, err := Summarize(dir)
+	if err != nil {
+		t.Fatalf("Summarize() unexpected error: %v", err)
+	}
+	if len(s.Files) != 3 || len(s.Errors) != 1 || !strings.Contains(s.Errors[0], "e2e-intervals_invalid.json") {
+		t.Errorf("Summarize() files = %v, errors = %v, want 3 files read and the invalid file", s.Files, s.Errors)
+	}
+	// the etcd event reported by both files is counted once.
+	if s.Intervals != 10 {
+		t.Errorf("Summarize() intervals = %d, want 10", s.Intervals)
+	}
+
+	if len(s.Disruptions) != 2 {
+		t.Fatalf("Summarize() disruptions = %+v, want 2 backends", s.Disruptions)
+	}
+	api := s.Disruptions[0]
+	if api.Backend != "kube-api-new-connections" || api.Windows != 2 || api.TotalSeconds != 14 || api.LongestSeconds != 10 || len(api.Intervals) != 2 {
+		t.Errorf("Summarize() disruption = %+v, want 2 windows of 14s", api)
+	}
+	if reused := s.Disruptions[1]; reused.Backend != "kube-api" || reused.Connection != "reused" || reused.TotalSeconds != 2 {
+		t.Errorf("Summarize() disruption = %+v, want the legacy backend", reused)
+	}
+
+	if len(s.Alerts) != 2 {
+		t.Fatalf("Summarize() alerts = %+v, want the 2 alerts firing", s.Alerts)
+	}
+	if a := s.Alerts[0]; a.Name != "etcdMembersDown" || a.Namespace != "openshift-etcd" || a.Severity != "critical" || a.Seconds != 120 || a.Count != 1 {
+		t.Errorf("Summarize() alert = %+v, want etcdMembersDown firing for 120s", a)
+	}
+	if a := s.Alerts[1]; a.Name != "KubeAPIErrorBudgetBurn" || a.Namespace != "openshift-kube-apiserver" || a.Seconds != 60 {
+		t.Errorf("Summarize() alert = %+v, want the legacy alert", a)
+	}
+
+	if s.Etcd.Events != 2 || s.Etcd.ByReason["EtcdLeaderChanged"] != 1 || s.Etcd.ByReason["EtcdSlowRequest"] != 1 || len(s.Etcd.Last) != 2 {
+		t.Errorf("Summarize() etcd = %+v, want the leader change and the slow request", s.Etcd)
+	}
+
+	mt := s.MonitorTests
+	if mt.Total != 3 || mt.Failed != 1 || mt.Skipped != 1 || len(mt.Failures) != 1 || mt.Failures[0].Message != "kube-api was unreachable for 14s" {
+		t.Errorf("Summarize() monitor tests = %+v, want 1 failure of 3 tests", mt)
+	}
+	if got := s.String(); got != "files=3 intervals=10 disruptions=2/3 alerts=2 etcd=2 monitorTests=1/3" {
+		t.Errorf("String() = %s", got)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	s, err := Summarize(t.TempDir())
+	if err != nil {
+		t.Fatalf("Summarize() unexpected error: %v", err)
+	}
+	if !s.Empty() || s.Intervals != 0 || s.Etcd == nil || s.MonitorTests == nil {
+		t.Errorf("Summarize() = %+v, want the empty summary", s)
+	}
+}
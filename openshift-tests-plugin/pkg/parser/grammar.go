@@ -0,0 +1,59 @@
+package parser
+
+import "regexp"
+
+// Grammar* are the versions of the openshift-tests output grammar.
+const (
+	// GrammarV1 is the legacy output: the counters of the started line in parentheses,
+	// and the result lines without timestamp, example:
+	//   started: (0/1/8) "test"
+	//   passed: (2.1s) "test"
+	//   passed (2.1s) "test"
+	GrammarV1 = "v1"
+	// GrammarV2 is the output of openshift-tests 4.x: the result lines with the end
+	// timestamp of the test, example:
+	//   started: 0/1/8 "test"
+	//   passed: (2.1s) 2024-07-05T20:27:49 "test"
+	GrammarV2 = "v2"
+)
+
+// timestampLayout is the layout of the end timestamp in the result lines (GrammarV2).
+const timestampLayout = "2006-01-02T15:04:05"
+
+// grammar is a version of the status lines (started and results) of the output.
+type grammar struct {
+	version string
+	// started matches the started line, with the groups: failures, index and total
+	// counters (optional), and the test name.
+	started *regexp.Regexp
+	// result matches the result line, with the groups: result, duration, timestamp
+	// (when hasTimestamp) and the test name.
+	result       *regexp.Regexp
+	hasTimestamp bool
+}
+
+// grammars are the known grammars, by priority: the most recent version first.
+var grammars = []*grammar{
+	{
+		version:      GrammarV2,
+		started:      regexp.MustCompile(`^started:\s+(\d+)/(\d+)/(\d+)\s+(\S.*)$`),
+		result:       regexp.MustCompile(`^(passed|failed|skipped):?\s+\(([^)]*)\)\s+(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})\s+(\S.*)$`),
+		hasTimestamp: true,
+	},
+	{
+		version: GrammarV1,
+		started: regexp.MustCompile(`^started:\s+(?:\((\d+)/(\d+)/(\d+)\)\s+)?(\S.*)$`),
+		result:  regexp.MustCompile(`^(passed|failed|skipped):?\s+\(([^)]*)\)\s+(\S.*)$`),
+	},
+}
+
+var (
+	// reStatus matches the lines reported as status lines, invalid when not matched by a grammar.
+	reStatus = regexp.MustCompile(`^(started|passed|failed|skipped)(:|\s+\()`)
+	// reMonitor matches the lines of the monitors running with the tests: klog lines
+	// (example: I0705 20:27:49.123456 1 monitor.go:123] ...) and intervals (example:
+	// Jul 05 20:27:49.123 I ns/openshift-etcd pod/etcd-0 reason/Ready).
+	reMonitor = regexp.MustCompile(`^([IWEF]\d{4} \d{2}:\d{2}:\d{2}\.\d+\s|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}\.\d{3} [IWE] )`)
+	// reSummary matches the summary printed at the end of the run.
+	reSummary = regexp.MustCompile(`^((Flaky|Failing) tests:|(error: )?\d+ (fail|pass), .*\(.*\)|Retrying \d+ failed tests)$`)
+)
@@ -0,0 +1,346 @@
+/*
+Package parser parses the openshift-tests output read by the plugin from the FIFO.
+
+Each line is parsed to an event: the status lines of the tests (started and results,
+in the known versions of the grammar, see GrammarV1 and GrammarV2), the lines of the
+monitors interleaved with the tests, the summary of the run, and the output of the
+tests (example: the multi-line failure output printed before the failed line). The
+status lines not matched by a grammar, or with an invalid duration or timestamp, are
+recorded in the parse errors counters, never failing the parser.
+
+The output of a test is printed by openshift-tests before the result line: the output
+lines since the previous status line are attached to the result event, limited to the
+last MaxOutputLines.
+
+The start and the end timestamps of each test are calculated from the result line:
+the end timestamp printed by openshift-tests (GrammarV2), or the time the line is read,
+and the duration of the test.
+*/
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType is the type of the line parsed.
+type EventType string
+
+const (
+	// EventStarted is the test started.
+	EventStarted EventType = "started"
+	// EventResult is the result of the test: passed, failed or skipped.
+	EventResult EventType = "result"
+	// EventMonitor is a line of the monitors.
+	EventMonitor EventType = "monitor"
+	// EventSummary is a line of the summary of the run.
+	EventSummary EventType = "summary"
+	// EventOutput is the output of the tests, example: the failure output.
+	EventOutput EventType = "output"
+	// EventInvalid is a status line not matched by the grammars.
+	EventInvalid EventType = "invalid"
+)
+
+// Error* are the kinds of the parse errors.
+const (
+	ErrorStatusLine = "status-line"
+	ErrorDuration   = "duration"
+	ErrorTimestamp  = "timestamp"
+)
+
+// maxLastErrors is the number of the last parse errors kept in the stats.
+const maxLastErrors = 10
+
+// MaxOutputLines is the number of the last output lines attached to the result of a test.
+const MaxOutputLines = 1000
+
+// Counters are the counters of the started line: the failures, the index of the
+// test started and the total of tests.
+type Counters struct {
+	Failures int
+	Index    int
+	Total    int
+}
+
+// Event is a line of the output parsed.
+type Event struct {
+	Type EventType
+	// Line is the line number, starting at 1.
+	Line int
+	Text string
+	// Grammar is the version of the grammar matched by the status line.
+	Grammar string
+
+	// Name is the test name, unquoted.
+	Name string
+	// Result is the test result: passed, failed or skipped.
+	Result   string
+	Counters *Counters
+	Duration time.Duration
+	// StartedAt is the time the test started: calculated from the result, or the time
+	// the started line is read.
+	StartedAt time.Time
+	// EndAt is the time the test finished.
+	EndAt time.Time
+	// Output is the output printed before the result line, and OutputDropped the count
+	// of the first lines dropped by MaxOutputLines.
+	Output        []string
+	OutputDropped int
+
+	// Err is the parse error of the line, when the line is invalid or partially parsed.
+	Err error
+}
+
+// Stats are the counters of the lines parsed.
+type Stats struct {
+	Lines   int `json:"lines"`
+	Started int `json:"started"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	Monitor int `json:"monitor"`
+	Summary int `json:"summary"`
+	Output  int `json:"output"`
+	// Errors is the count of the parse errors, by kind in ErrorsByKind.
+	Errors       int            `json:"errors"`
+	ErrorsByKind map[string]int `json:"errorsByKind,omitempty"`
+	LastErrors   []string       `json:"lastErrors,omitempty"`
+	// Grammars is the count of the status lines by grammar version.
+	Grammars map[string]int `json:"grammars,omitempty"`
+}
+
+// String returns the counters of the stats.
+func (s Stats) String() string {
+	return fmt.Sprintf("lines=%d started=%d passed=%d failed=%d skipped=%d monitor=%d output=%d errors=%d",
+		s.Lines, s.Started, s.Passed, s.Failed, s.Skipped, s.Monitor, s.Output, s.Errors)
+}
+
+// Parser parses the lines of the openshift-tests output, safe for concurrent use.
+type Parser struct {
+	// Now returns the time a line is read. Default: time.Now.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	stats Stats
+	// started is the time the started line of the test is read.
+	started map[string]time.Time
+	// output are the output lines since the last status line.
+	output        []string
+	outputDropped int
+}
+
+// New creates the parser.
+func New() *Parser {
+	return &Parser{
+		Now:     time.Now,
+		started: map[string]time.Time{},
+		stats: Stats{
+			ErrorsByKind: map[string]int{},
+			Grammars:     map[string]int{},
+		},
+	}
+}
+
+// Parse parses the line, updating the stats.
+func (p *Parser) Parse(line string) *Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats.Lines++
+	line = strings.TrimRight(line, "\r\n")
+	ev := &Event{Line: p.stats.Lines, Text: line}
+	now := p.Now().UTC()
+	switch {
+	case strings.HasPrefix(line, "started"):
+		if p.parseStarted(ev, line, now) {
+			return ev
+		}
+	case strings.HasPrefix(line, "passed"), strings.HasPrefix(line, "failed"), strings.HasPrefix(line, "skipped"):
+		if p.parseResult(ev, line, now) {
+			return ev
+		}
+	}
+	switch {
+	case reStatus.MatchString(line):
+		ev.Type = EventInvalid
+		ev.Err = fmt.Errorf("status line not matched by the grammars %s and %s", GrammarV2, GrammarV1)
+		p.recordError(ErrorStatusLine, ev)
+	case reMonitor.MatchString(line):
+		ev.Type = EventMonitor
+		p.stats.Monitor++
+	case reSummary.MatchString(line):
+		ev.Type = EventSummary
+		p.stats.Summary++
+		p.resetOutput()
+	default:
+		ev.Type = EventOutput
+		p.stats.Output++
+		if len(p.output) >= MaxOutputLines {
+			p.output = p.output[1:]
+			p.outputDropped++
+		}
+		p.output = append(p.output, line)
+	}
+	return ev
+}
+
+// parseStarted parses the started line, returning false when not matched by a grammar.
+func (p *Parser) parseStarted(ev *Event, line string, now time.Time) bool {
+	for _, g := range grammars {
+		m := g.started.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ev.Type = EventStarted
+		ev.Grammar = g.version
+		ev.Name = unquote(m[4])
+		ev.StartedAt = now
+		if len(m[1]) > 0 {
+			// the counters are matched by \d+, the errors are overflows only.
+			failures, _ := strconv.Atoi(m[1])
+			index, _ := strconv.Atoi(m[2])
+			total, _ := strconv.Atoi(m[3])
+			ev.Counters = &Counters{Failures: failures, Index: index, Total: total}
+		}
+		p.started[ev.Name] = now
+		p.resetOutput()
+		p.stats.Started++
+		p.stats.Grammars[g.version]++
+		return true
+	}
+	return false
+}
+
+// parseResult parses the result line, returning false when not matched by a grammar.
+func (p *Parser) parseResult(ev *Event, line string, now time.Time) bool {
+	for _, g := range grammars {
+		m := g.result.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ev.Type = EventResult
+		ev.Grammar = g.version
+		ev.Result = m[1]
+		name := m[3]
+		ev.EndAt = now
+		if g.hasTimestamp {
+			name = m[4]
+			if ts, err := time.Parse(timestampLayout, m[3]); err == nil {
+				ev.EndAt = ts
+			} else {
+				ev.Err = fmt.Errorf("invalid timestamp %q: %w", m[3], err)
+				p.recordError(ErrorTimestamp, ev)
+			}
+		}
+		ev.Name = unquote(name)
+		if d, err := time.ParseDuration(m[2]); err == nil && d >= 0 {
+			ev.Duration = d
+		} else {
+			ev.Err = fmt.Errorf("invalid duration %q", m[2])
+			p.recordError(ErrorDuration, ev)
+		}
+
+		// the start is calculated by the duration, or the time the started line is read
+		// when the duration is unknown.
+		ev.StartedAt = ev.EndAt.Add(-ev.Duration)
+		if started, ok := p.started[ev.Name]; ok {
+			if ev.Duration == 0 {
+				ev.StartedAt = started
+			}
+			delete(p.started, ev.Name)
+		}
+
+		ev.Output, ev.OutputDropped = trimBlankLines(p.output), p.outputDropped
+		p.resetOutput()
+
+		switch ev.Result {
+		case "passed":
+			p.stats.Passed++
+		case "failed":
+			p.stats.Failed++
+		case "skipped":
+			p.stats.Skipped++
+		}
+		p.stats.Grammars[g.version]++
+		return true
+	}
+	return false
+}
+
+// resetOutput discards the output lines. The caller must hold the lock.
+func (p *Parser) resetOutput() {
+	p.output = nil
+	p.outputDropped = 0
+}
+
+// recordError counts the parse error of the line. The caller must hold the lock.
+func (p *Parser) recordError(kind string, ev *Event) {
+	p.stats.Errors++
+	p.stats.ErrorsByKind[kind]++
+	p.stats.LastErrors = append(p.stats.LastErrors, fmt.Sprintf("line %d: %s: %v", ev.Line, kind, ev.Err))
+	if len(p.stats.LastErrors) > maxLastErrors {
+		p.stats.LastErrors = p.stats.LastErrors[len(p.stats.LastErrors)-maxLastErrors:]
+	}
+}
+
+// Stats returns a copy of the counters of the lines parsed.
+func (p *Parser) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.ErrorsByKind = map[string]int{}
+	for k, v := range p.stats.ErrorsByKind {
+		s.ErrorsByKind[k] = v
+	}
+	s.Grammars = map[string]int{}
+	for k, v := range p.stats.Grammars {
+		s.Grammars[k] = v
+	}
+	s.LastErrors = append([]string{}, p.stats.LastErrors...)
+	return s
+}
+
+// Grammar returns the version of the grammar matched by most of the status lines, or
+// empty when no status lines are parsed.
+func (p *Parser) Grammar() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	version, count := "", 0
+	for _, g := range grammars {
+		if c := p.stats.Grammars[g.version]; c > count {
+			version, count = g.version, c
+		}
+	}
+	return version
+}
+
+// unquote returns the test name printed quoted by openshift-tests, or the name when
+// not quoted.
+func unquote(name string) string {
+	name = strings.TrimSpace(name)
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		return unquoted
+	}
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return name[1 : len(name)-1]
+	}
+	return name
+}
+
+// trimBlankLines returns the lines without the blank lines at the start and the end.
+func trimBlankLines(lines []string) []string {
+	start, end := 0, len(lines)
+	for start < end && len(strings.TrimSpace(lines[start])) == 0 {
+		start++
+	}
+	for end > start && len(strings.TrimSpace(lines[end-1])) == 0 {
+		end--
+	}
+	if start == end {
+		return nil
+	}
+	return append([]string{}, lines[start:end]...)
+}
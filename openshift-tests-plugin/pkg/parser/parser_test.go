@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedNow returns the parser with the time the lines are read fixed.
+func fixedNow(now time.Time) *Parser {
+	p := New()
+	p.Now = func() time.Time { return now }
+	return p
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2024, 7, 5, 21, 0, 0, 0, time.UTC)
+	name := `[sig-api-machinery] local kubeconfig "lb-ext.kubeconfig" should work [Suite:openshift/conformance/parallel/minimal]`
+	quoted := strconv.Quote(name)
+	tests := []struct {
+		line      string
+		wantType  EventType
+		grammar   string
+		result    string
+		duration  time.Duration
+		startedAt time.Time
+		endAt     time.Time
+		counters  *Counters
+		wantErr   string
+	}{
+		{line: "started: 0/4/8 " + quoted, wantType: EventStarted, grammar: GrammarV2, startedAt: now, counters: &Counters{0, 4, 8}},
+		{line: "started: (1/5/8) " + quoted, wantType: EventStarted, grammar: GrammarV1, startedAt: now, counters: &Counters{1, 5, 8}},
+		{line: "started: " + quoted, wantType: EventStarted, grammar: GrammarV1, startedAt: now},
+		{
+			line: "passed: (3.2s) 2024-07-05T20:27:52 " + quoted, wantType: EventResult, grammar: GrammarV2, result: "passed", duration: 3200 * time.Millisecond,
+			startedAt: time.Date(2024, 7, 5, 20, 27, 48, 800000000, time.UTC), endAt: time.Date(2024, 7, 5, 20, 27, 52, 0, time.UTC),
+		},
+		{
+			line: "failed: (5m2s) 2024-07-05T20:32:51 " + quoted, wantType: EventResult, grammar: GrammarV2, result: "failed", duration: 5*time.Minute + 2*time.Second,
+			startedAt: time.Date(2024, 7, 5, 20, 27, 49, 0, time.UTC), endAt: time.Date(2024, 7, 5, 20, 32, 51, 0, time.UTC),
+		},
+		{line: "passed (1.2s) " + quoted, wantType: EventResult, grammar: GrammarV1, result: "passed", duration: 1200 * time.Millisecond, startedAt: now.Add(-1200 * time.Millisecond), endAt: now},
+		{line: "skipped: (0.0s) " + quoted, wantType: EventResult, grammar: GrammarV1, result: "skipped", startedAt: now, endAt: now},
+		{line: "failed: (1x) 2024-07-05T20:32:51 " + quoted, wantType: EventResult, grammar: GrammarV2, result: "failed", wantErr: ErrorDuration,
+			startedAt: time.Date(2024, 7, 5, 20, 32, 51, 0, time.UTC), endAt: time.Date(2024, 7, 5, 20, 32, 51, 0, time.UTC)},
+		{line: "passed: (1s) 2024-13-45T20:32:51 " + quoted, wantType: EventResult, grammar: GrammarV2, result: "passed", duration: time.Second, wantErr: ErrorTimestamp,
+			startedAt: now.Add(-time.Second), endAt: now},
+		{line: "passed: " + quoted, wantType: EventInvalid, wantErr: ErrorStatusLine},
+		{line: "started: ", wantType: EventInvalid, wantErr: ErrorStatusLine},
+		{line: "I0705 20:27:49.123456       1 monitor.go:123] Starting monitor", wantType: EventMonitor},
+		{line: "Jul 05 20:27:49.123 I ns/openshift-etcd pod/etcd-0 node/master-0 reason/Ready", wantType: EventMonitor},
+		{line: `Jul  5 20:27:58.101: INFO: Waiting up to 5m0s for pod "pod-disruption-target"`, wantType: EventOutput},
+		{line: "fail [k8s.io/kubernetes/test/e2e/apps/job.go:211]: Timed out after 300.000s.", wantType: EventOutput},
+		{line: "failed to pull the image (timeout)", wantType: EventOutput},
+		{line: "Starting SimultaneousPodIPController", wantType: EventOutput},
+		{line: "error: 1 fail, 6 pass, 1 skip (5m2s)", wantType: EventSummary},
+		{line: "Failing tests:", wantType: EventSummary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			p := fixedNow(now)
+			ev := p.Parse(tt.line)
+			if ev.Type != tt.wantType || ev.Grammar != tt.grammar || ev.Result != tt.result || ev.Duration != tt.duration {
+				t.Fatalf("Parse() = type %s grammar %q result %q duration %v, want %s %q %q %v", ev.Type, ev.Grammar, ev.Result, ev.Duration, tt.wantType, tt.grammar, tt.result, tt.duration)
+			}
+			if tt.wantType == EventStarted || tt.wantType == EventResult {
+				if ev.Name != name {
+					t.Errorf("Parse() name = %q, want %q", ev.Name, name)
+				}
+				if !ev.StartedAt.Equal(tt.startedAt) || !ev.EndAt.Equal(tt.endAt) {
+					t.Errorf("Parse() started at %v, end at %v, want %v and %v", ev.StartedAt, ev.EndAt, tt.startedAt, tt.endAt)
+				}
+			}
+			if fmt.Sprint(ev.Counters) != fmt.Sprint(tt.counters) {
+				t.Errorf("Parse() counters = %v, want %v", ev.Counters, tt.counters)
+			}
+			stats := p.Stats()
+			if len(tt.wantErr) > 0 && (ev.Err == nil || stats.ErrorsByKind[tt.wantErr] != 1) {
+				t.Errorf("Parse() error = %v, errors %v, want the error %s", ev.Err, stats.ErrorsByKind, tt.wantErr)
+			}
+			if len(tt.wantErr) == 0 && (ev.Err != nil || stats.Errors != 0) {
+				t.Errorf("Parse() unexpected error: %v", ev.Err)
+			}
+		})
+	}
+}
+
+// TestParseStream tests the stats and the timestamps of an output with the tests
+// interleaved with the monitors and the failure output.
+func TestParseStream(t *testing.T) {
+	output := `openshift-tests version: 4.20.0
+started: 0/1/3 "test a"
+I0705 20:27:49.123456       1 monitor.go:123] Starting monitor
+started: 0/2/3 "test b"
+
+passed: (2.1s) 2024-07-05T20:27:49 "test a"
+started: 0/3/3 "test c"
+Jul  5 20:27:58.101: INFO: Waiting up to 5m0s for pod
+fail [k8s.io/kubernetes/test/e2e/apps/job.go:211]: Timed out after 300.000s.
+Expected pod to be "Failed"
+
+failed: (5m2s) 2024-07-05T20:32:51 "test b"
+skipped (0.3s) "test c"
+passed: garbage "test c"
+error: 1 fail, 1 pass, 1 skip (5m2s)`
+
+	p := New()
+	events := map[string]*Event{}
+	for _, line := range strings.Split(output, "\n") {
+		if ev := p.Parse(line); ev.Type == EventResult {
+			events[ev.Name] = ev
+		}
+	}
+	stats := p.Stats()
+	if stats.Lines != 15 || stats.Started != 3 || stats.Passed != 1 || stats.Failed != 1 || stats.Skipped != 1 ||
+		stats.Monitor != 1 || stats.Summary != 1 || stats.Output != 6 || stats.Errors != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if stats.Grammars[GrammarV2] != 5 || stats.Grammars[GrammarV1] != 1 || p.Grammar() != GrammarV2 {
+		t.Errorf("Stats() grammars = %v, Grammar() = %s, want %s", stats.Grammars, p.Grammar(), GrammarV2)
+	}
+	if len(stats.LastErrors) != 1 || !strings.Contains(stats.LastErrors[0], "line 14: status-line") {
+		t.Errorf("Stats() last errors = %v, want the invalid status line", stats.LastErrors)
+	}
+	if b := events["test b"]; b == nil || b.StartedAt.Format(timestampLayout) != "2024-07-05T20:27:49" {
+		t.Errorf("test b = %+v, want started at 2024-07-05T20:27:49", b)
+	}
+	// the output is attached to the result printed after it, the blank lines trimmed.
+	wantOutput := []string{
+		"Jul  5 20:27:58.101: INFO: Waiting up to 5m0s for pod",
+		"fail [k8s.io/kubernetes/test/e2e/apps/job.go:211]: Timed out after 300.000s.",
+		`Expected pod to be "Failed"`,
+	}
+	if b := events["test b"]; b == nil || strings.Join(b.Output, "\n") != strings.Join(wantOutput, "\n") {
+		t.Errorf("test b output = %q, want %q", events["test b"].Output, wantOutput)
+	}
+	if a, c := events["test a"], events["test c"]; len(a.Output) != 0 || len(c.Output) != 0 {
+		t.Errorf("test a output = %q, test c output = %q, want no output", a.Output, c.Output)
+	}
+}
+
+// TestParseOutputLimit tests the output of a test is limited to the last lines.
+func TestParseOutputLimit(t *testing.T) {
+	p := New()
+	p.Parse(`started: 0/1/1 "test"`)
+	for i := 0; i < MaxOutputLines+10; i++ {
+		p.Parse(fmt.Sprintf("line %d", i))
+	}
+	ev := p.Parse(`failed: (1s) 2024-07-05T20:27:49 "test"`)
+	if len(ev.Output) != MaxOutputLines || ev.OutputDropped != 10 || ev.Output[0] != "line 10" {
+		t.Errorf("Parse() output = %d lines from %q, dropped %d, want %d lines from line 10", len(ev.Output), ev.Output[0], ev.OutputDropped, MaxOutputLines)
+	}
+	if ev := p.Parse(`passed: (1s) 2024-07-05T20:27:50 "test"`); len(ev.Output) != 0 || ev.OutputDropped != 0 {
+		t.Errorf("Parse() output = %q, want the output reset by the previous result", ev.Output)
+	}
+}
+
+// FuzzParse tests the parser does not fail with any input, and the events are consistent.
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		`started: 0/1/8 "[sig-apps] test [Suite:k8s]"`,
+		`started: (0/1/8) "[sig-apps] test"`,
+		`passed: (2.1s) 2024-07-05T20:27:49 "[sig-apps] test"`,
+		`failed: (5m2s) 2024-07-05T20:32:51 "[sig-apps] \"quoted\" test"`,
+		`skipped (0.3s) "test"`,
+		`passed: (1x) 2024-99-99T99:99:99 "test"`,
+		`passed: ()  "`,
+		"I0705 20:27:49.123456       1 monitor.go:123] Starting monitor",
+		"error: 1 fail, 6 pass, 1 skip (5m2s)",
+		"fail [job.go:211]: Timed out",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		p := New()
+		ev := p.Parse(line)
+		stats := p.Stats()
+		if stats.Lines != 1 || ev.Line != 1 {
+			t.Fatalf("Parse(%q) lines = %d, want 1", line, stats.Lines)
+		}
+		switch ev.Type {
+		case EventStarted:
+			if stats.Started != 1 || len(ev.Grammar) == 0 {
+				t.Errorf("Parse(%q) started, stats %+v", line, stats)
+			}
+		case EventResult:
+			if stats.Passed+stats.Failed+stats.Skipped != 1 || len(ev.Grammar) == 0 || ev.Duration < 0 || ev.StartedAt.After(ev.EndAt) {
+				t.Errorf("Parse(%q) = %+v, stats %+v", line, ev, stats)
+			}
+		case EventInvalid:
+			if ev.Err == nil || stats.ErrorsByKind[ErrorStatusLine] != 1 {
+				t.Errorf("Parse(%q) invalid without error, stats %+v", line, stats)
+			}
+		case EventMonitor, EventSummary, EventOutput:
+			if ev.Err != nil || stats.Errors != 0 {
+				t.Errorf("Parse(%q) %s with error: %v", line, ev.Type, ev.Err)
+			}
+		default:
+			t.Errorf("Parse(%q) unknown type %q", line, ev.Type)
+		}
+		if (ev.Err != nil) != (stats.Errors > 0) {
+			t.Errorf("Parse(%q) error = %v, errors counted %d", line, ev.Err, stats.Errors)
+		}
+	})
+}
+
+// FuzzParseResult tests the result line printed by openshift-tests is parsed to the
+// test name, the duration and the timestamps.
+func FuzzParseResult(f *testing.F) {
+	f.Add("[sig-apps] test [Suite:k8s]", int64(2100), int64(1720211269), 0)
+	f.Add(`[sig-api-machinery] local kubeconfig "lb-ext.kubeconfig" [Suite:openshift]`, int64(302000), int64(1720211571), 1)
+	f.Add("test\twith\ttabs\nand new line", int64(0), int64(0), 2)
+	f.Fuzz(func(t *testing.T, name string, millis, unix int64, result int) {
+		if len(strings.TrimSpace(name)) == 0 || millis < 0 || millis > int64(24*time.Hour/time.Millisecond) || unix < 0 || unix > 4102444800 {
+			t.Skip()
+		}
+		results := []string{"passed", "failed", "skipped"}
+		res := results[((result%3)+3)%3]
+		duration := time.Duration(millis) * time.Millisecond
+		end := time.Unix(unix, 0).UTC()
+		line := fmt.Sprintf("%s: (%s) %s %s", res, duration, end.Format(timestampLayout), strconv.Quote(name))
+
+		ev := New().Parse(line)
+		if ev.Type != EventResult || ev.Err != nil {
+			t.Fatalf("Parse(%q) = %s, error %v, want the result", line, ev.Type, ev.Err)
+		}
+		if ev.Result != res || ev.Name != strings.TrimSpace(name) && ev.Name != name || ev.Duration != duration ||
+			!ev.EndAt.Equal(end) || !ev.StartedAt.Equal(end.Add(-duration)) {
+			t.Errorf("Parse(%q) = %+v, want %s %q (%v) ending at %v", line, ev, res, name, duration, end)
+		}
+	})
+}
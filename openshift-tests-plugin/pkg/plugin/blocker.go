@@ -3,6 +3,8 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	sbclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
@@ -11,6 +13,7 @@ import (
 	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klabels "k8s.io/apimachinery/pkg/labels"
 	kubernetes "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
 )
 
 // BlockerPluginsInput is the input for the BlockerPlugins.
@@ -100,3 +103,75 @@ func GetPodStatusString(pod *kcorev1.Pod) string {
 	}
 	return string(pod.Status.Phase)
 }
+
+// blockerStallDetector tracks the blocker plugin progress to detect executions
+// that are running but not advancing the completed counter.
+type blockerStallDetector struct {
+	timeout       time.Duration
+	lastCompleted int64
+	lastProgress  time.Time
+}
+
+// newBlockerStallDetector creates a stall detector starting the window at the given time.
+func newBlockerStallDetector(timeout time.Duration, now time.Time) *blockerStallDetector {
+	return &blockerStallDetector{
+		timeout:      timeout,
+		lastProgress: now,
+	}
+}
+
+// Observe records the current blocker progress, returning true when the blocker
+// did not advance the completed counter for the stall window while not blocked by
+// another plugin. The window is reset when the blocker progresses or is blocked.
+func (d *blockerStallDetector) Observe(completed int64, blocked bool, now time.Time) bool {
+	if blocked || completed > d.lastCompleted {
+		d.lastCompleted = completed
+		d.lastProgress = now
+		return false
+	}
+	if d.timeout <= 0 {
+		return false
+	}
+	return now.Sub(d.lastProgress) >= d.timeout
+}
+
+// StalledFor returns the time the blocker is without progress.
+func (d *blockerStallDetector) StalledFor(now time.Time) time.Duration {
+	return now.Sub(d.lastProgress).Truncate(time.Second)
+}
+
+// isBlockerBlocked checks if the blocker plugin is waiting for its own blocker,
+// based in the progress message published to the aggregator.
+func isBlockerBlocked(ps *sbaggregation.PluginStatus) bool {
+	if ps == nil || ps.Progress == nil {
+		return false
+	}
+	for _, prefix := range []string{"status=waiting-for", "status=blocked-by", "status=blocker-stalled"} {
+		if strings.HasPrefix(ps.Progress.Message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPluginPodLogs get the last log lines for each container of the plugin pod.
+func GetPluginPodLogs(kclient kubernetes.Interface, pod *kcorev1.Pod, tailLines int64) string {
+	if pod == nil {
+		return "unable to collect logs: plugin pod not found"
+	}
+	logs := []string{}
+	for _, container := range pod.Spec.Containers {
+		req := kclient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &kcorev1.PodLogOptions{
+			Container: container.Name,
+			TailLines: ptr.To(tailLines),
+		})
+		data, err := req.DoRaw(context.TODO())
+		if err != nil {
+			log.Warnf("unable to collect logs from pod %s container %s: %v", pod.Name, container.Name, err)
+			logs = append(logs, fmt.Sprintf(">> container %s: unable to collect logs: %v", container.Name, err))
+			continue
+		}
+		logs = append(logs, fmt.Sprintf(">> container %s (last %d lines):\n%s", container.Name, tailLines, string(data)))
+	}
+	return strings.Join(logs, "\n")
+}
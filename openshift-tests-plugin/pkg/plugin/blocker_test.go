@@ -0,0 +1,294 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sbplugin "github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	sbaggregation "github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// TestBlockerStallDetector tests the stall window of the blocker progress.
+func TestBlockerStallDetector(t *testing.T) {
+	start := time.Date(2024, 7, 5, 20, 0, 0, 0, time.UTC)
+	type observation struct {
+		after     time.Duration
+		completed int64
+		blocked   bool
+		want      bool
+	}
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		observations []observation
+	}{
+		{
+			name:    "progressing blocker is never stalled",
+			timeout: 10 * time.Minute,
+			observations: []observation{
+				{after: 5 * time.Minute, completed: 1},
+				{after: 14 * time.Minute, completed: 2},
+				{after: 23 * time.Minute, completed: 3},
+			},
+		},
+		{
+			name:    "blocker without progress is stalled after the window",
+			timeout: 10 * time.Minute,
+			observations: []observation{
+				{after: 5 * time.Minute, completed: 1},
+				{after: 14 * time.Minute, completed: 1},
+				{after: 15 * time.Minute, completed: 1, want: true},
+				{after: 16 * time.Minute, completed: 2},
+			},
+		},
+		{
+			name:    "blocked blocker resets the window",
+			timeout: 10 * time.Minute,
+			observations: []observation{
+				{after: 20 * time.Minute, blocked: true},
+				{after: 29 * time.Minute},
+				{after: 30 * time.Minute, want: true},
+			},
+		},
+		{
+			name:    "detection disabled",
+			timeout: 0,
+			observations: []observation{
+				{after: 24 * time.Hour},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newBlockerStallDetector(tt.timeout, start)
+			for idx, o := range tt.observations {
+				if got := d.Observe(o.completed, o.blocked, start.Add(o.after)); got != o.want {
+					t.Errorf("observation #%d: Observe() = %v, want %v", idx, got, o.want)
+				}
+			}
+		})
+	}
+}
+
+// TestIsBlockerBlocked tests the blocked state from the progress message.
+func TestIsBlockerBlocked(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{message: "status=waiting-for=openshift-kube-conformance=(0/0/0)=[0/2000]", want: true},
+		{message: "status=blocked-by=openshift-cluster-upgrade=(0/0/0)=[0/2000]", want: true},
+		{message: "status=blocker-stalled=openshift-kube-conformance=(0/-10/0)=[0/2000]", want: true},
+		{message: "status=running=T/C/P/F/S=10/1/1/0/0", want: false},
+	}
+	for _, tt := range tests {
+		ps := &sbaggregation.PluginStatus{Progress: &sbplugin.ProgressUpdate{Message: tt.message}}
+		if got := isBlockerBlocked(ps); got != tt.want {
+			t.Errorf("isBlockerBlocked(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+	if isBlockerBlocked(&sbaggregation.PluginStatus{}) {
+		t.Errorf("isBlockerBlocked() without progress = true, want false")
+	}
+}
+
+// TestGetPluginPodLogs tests the log collection from each container of the blocker pod.
+func TestGetPluginPodLogs(t *testing.T) {
+	pod := &kcorev1.Pod{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "sonobuoy-10-openshift-kube-conformance-job", Namespace: EnvNamespace},
+		Spec: kcorev1.PodSpec{
+			Containers: []kcorev1.Container{{Name: "plugin"}, {Name: "tests"}},
+		},
+	}
+	got := GetPluginPodLogs(kfake.NewSimpleClientset(pod), pod, 10)
+	for _, want := range []string{">> container plugin (last 10 lines)", ">> container tests (last 10 lines)", "fake logs"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("GetPluginPodLogs() = %q, want to contain %q", got, want)
+		}
+	}
+	if got := GetPluginPodLogs(kfake.NewSimpleClientset(), nil, 10); !strings.Contains(got, "plugin pod not found") {
+		t.Errorf("GetPluginPodLogs(nil) = %q, want pod not found message", got)
+	}
+}
+
+// TestInitBlockerConfig tests the blocker handling settings from environment.
+func TestInitBlockerConfig(t *testing.T) {
+	tests := []struct {
+		name              string
+		pluginName        string
+		env               map[string]string
+		wantStallTimeout  time.Duration
+		wantStallPolicy   string
+		wantFailurePolicy string
+	}{
+		{
+			name:              "defaults",
+			pluginName:        PluginName20,
+			wantStallTimeout:  BlockerStallTimeout * time.Second,
+			wantStallPolicy:   BlockerStallPolicyWait,
+			wantFailurePolicy: BlockerFailurePolicyFailFast,
+		},
+		{
+			name:              "artifacts collector continue by default",
+			pluginName:        PluginName99,
+			wantStallTimeout:  BlockerStallTimeout * time.Second,
+			wantStallPolicy:   BlockerStallPolicyWait,
+			wantFailurePolicy: BlockerFailurePolicyContinue,
+		},
+		{
+			name:       "custom values",
+			pluginName: PluginName20,
+			env: map[string]string{
+				"BLOCKER_STALL_TIMEOUT":  "90m",
+				"BLOCKER_STALL_POLICY":   BlockerStallPolicyContinue,
+				"BLOCKER_FAILURE_POLICY": BlockerFailurePolicySkipWithJUnit,
+			},
+			wantStallTimeout:  90 * time.Minute,
+			wantStallPolicy:   BlockerStallPolicyContinue,
+			wantFailurePolicy: BlockerFailurePolicySkipWithJUnit,
+		},
+		{
+			name:       "invalid values keep defaults",
+			pluginName: PluginName20,
+			env: map[string]string{
+				"BLOCKER_STALL_TIMEOUT":  "ten minutes",
+				"BLOCKER_STALL_POLICY":   "retry",
+				"BLOCKER_FAILURE_POLICY": "ignore",
+			},
+			wantStallTimeout:  BlockerStallTimeout * time.Second,
+			wantStallPolicy:   BlockerStallPolicyWait,
+			wantFailurePolicy: BlockerFailurePolicyFailFast,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			p, err := NewPlugin(tt.pluginName)
+			if err != nil {
+				t.Fatalf("NewPlugin(%s) unexpected error: %v", tt.pluginName, err)
+			}
+			p.initBlockerConfig()
+			if p.BlockerStallTimeout != tt.wantStallTimeout {
+				t.Errorf("BlockerStallTimeout = %v, want %v", p.BlockerStallTimeout, tt.wantStallTimeout)
+			}
+			if p.BlockerStallPolicy != tt.wantStallPolicy {
+				t.Errorf("BlockerStallPolicy = %q, want %q", p.BlockerStallPolicy, tt.wantStallPolicy)
+			}
+			if p.BlockerFailurePolicy != tt.wantFailurePolicy {
+				t.Errorf("BlockerFailurePolicy = %q, want %q", p.BlockerFailurePolicy, tt.wantFailurePolicy)
+			}
+		})
+	}
+}
+
+// TestHandleBlockerFailed tests the failure propagation policies.
+func TestHandleBlockerFailed(t *testing.T) {
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	if err := p.handleBlockerFailed(PluginName10); err == nil {
+		t.Errorf("handleBlockerFailed() with policy %q expected error, got nil", p.BlockerFailurePolicy)
+	}
+
+	p.BlockerFailurePolicy = BlockerFailurePolicySkipWithJUnit
+	if err := p.handleBlockerFailed(PluginName10); err != nil {
+		t.Errorf("handleBlockerFailed() with policy %q unexpected error: %v", p.BlockerFailurePolicy, err)
+	}
+	if !strings.Contains(p.skipReason, PluginName10) {
+		t.Errorf("skipReason = %q, want to contain blocker plugin %s", p.skipReason, PluginName10)
+	}
+}
+
+// newProcessJUnitPlugin creates the plugin with the workspace, the suite list and the
+// JUnit written by the runner, to process the JUnit results.
+func newProcessJUnitPlugin(t *testing.T) *Plugin {
+	t.Helper()
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	ws := NewWorkspace(t.TempDir(), "")
+	if err := ws.Create(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetWorkspace(ws)
+	p.SetClients(kfake.NewSimpleClientset(), nil, nil)
+	test := "[sig-node] test a [Suite:openshift/conformance/parallel]"
+	if err := os.WriteFile(ws.SuiteList(), []byte(`"`+test+`"`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJUnitTestSuite(filepath.Join(ws.JUnitDir(), "junit_e2e__20240705-202749.xml"), "openshift-tests",
+		[]*JUnitTestReport{{Name: test, Result: "passed"}}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// packagedJUnit returns the JUnit of the results package reported to the worker.
+func packagedJUnit(t *testing.T, p *Plugin) string {
+	t.Helper()
+	dir, err := os.ReadFile(p.workspace().ResultsDoneFile())
+	if err != nil {
+		t.Fatalf("results done file not written: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(string(dir), "*.xml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("results package has %d JUnit files (%v), want 1", len(files), err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestProcessJUnitBlockerFailed tests the failure of the blocker plugin is reported in the
+// packaged results when the dependent plugin runs by the policy continue.
+func TestProcessJUnitBlockerFailed(t *testing.T) {
+	p := newProcessJUnitPlugin(t)
+	p.BlockerFailurePolicy = BlockerFailurePolicyContinue
+	if err := p.handleBlockerFailed(PluginName10); err != nil {
+		t.Fatalf("handleBlockerFailed() unexpected error: %v", err)
+	}
+	if err := p.ProcessJUnit(); err != nil {
+		t.Fatalf("ProcessJUnit() unexpected error: %v", err)
+	}
+	junit := packagedJUnit(t, p)
+	for _, want := range []string{"[sig-node] test a", "[opct] blocker plugin " + PluginName10 + " completed successfully", `failures="1"`} {
+		if !strings.Contains(junit, want) {
+			t.Errorf("packaged JUnit = %s, want to contain %q", junit, want)
+		}
+	}
+}
+
+// TestProcessJUnitBlockerStalled tests the logs of the stalled blocker pod are reported in
+// the packaged results when the dependent plugin runs.
+func TestProcessJUnitBlockerStalled(t *testing.T) {
+	p := newProcessJUnitPlugin(t)
+	p.BlockerStallPolicy = BlockerStallPolicyContinue
+	pod := &kcorev1.Pod{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "sonobuoy-10-openshift-kube-conformance-job", Namespace: EnvNamespace},
+		Spec:       kcorev1.PodSpec{Containers: []kcorev1.Container{{Name: "plugin"}, {Name: "tests"}}},
+	}
+	p.SetClients(kfake.NewSimpleClientset(pod), nil, nil)
+	p.reportBlockerStalled(PluginName10, pod, time.Hour, 10)
+	if err := p.ProcessJUnit(); err != nil {
+		t.Fatalf("ProcessJUnit() unexpected error: %v", err)
+	}
+	junit := packagedJUnit(t, p)
+	for _, want := range []string{"[sig-node] test a", "[opct] blocker plugin " + PluginName10 + " is progressing",
+		"&gt;&gt; container tests (last 100 lines)", "fake logs", `failures="1"`} {
+		if !strings.Contains(junit, want) {
+			t.Errorf("packaged JUnit = %s, want to contain %q", junit, want)
+		}
+	}
+}
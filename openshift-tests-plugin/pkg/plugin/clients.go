@@ -1,12 +1,17 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
 	"os"
 
-	"github.com/spf13/viper"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
+
+	occlient "github.com/openshift/client-go/config/clientset/versioned"
+	opclient "github.com/openshift/client-go/operator/clientset/versioned"
 	sbclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
 	sbdynamic "github.com/vmware-tanzu/sonobuoy/pkg/dynamic"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	krest "k8s.io/client-go/rest"
 	kclient "k8s.io/client-go/tools/clientcmd"
@@ -14,18 +19,14 @@ import (
 
 // CreateKubeRestConfig creates a kubernetes rest config from the kubeconfig file.
 func CreateKubeRestConfig() (*krest.Config, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-
-	if len(kubeconfig) == 0 {
-		kubeconfig = viper.GetString("kubeconfig")
-		if kubeconfig == "" {
-			return nil, fmt.Errorf("--kubeconfig or KUBECONFIG environment variable must be set")
-		}
+	kubeconfig := config.Current().Kubeconfig
+	if kubeconfig == "" {
+		return nil, fmt.Errorf("--kubeconfig or KUBECONFIG environment variable must be set")
+	}
 
-		// Check kubeconfig exists
-		if _, err := os.Stat(kubeconfig); err != nil {
-			return nil, fmt.Errorf("kubeconfig %q does not exists: %v", kubeconfig, err)
-		}
+	// Check kubeconfig exists
+	if _, err := os.Stat(kubeconfig); err != nil {
+		return nil, fmt.Errorf("kubeconfig %q does not exists: %v", kubeconfig, err)
 	}
 
 	clientConfig, err := kclient.BuildConfigFromFlags("", kubeconfig)
@@ -56,3 +57,47 @@ func CreateClients() (kubernetes.Interface, sbclient.Interface, error) {
 
 	return clientset, sonobuoyClient, nil
 }
+
+// CreateConfigClient creates the OpenShift config API client instance.
+func CreateConfigClient() (occlient.Interface, error) {
+	restConfig, err := CreateKubeRestConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error creating kube client config: %v", err)
+	}
+	client, err := occlient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating config client: %v", err)
+	}
+	return client, nil
+}
+
+// CreateOperatorClient creates the OpenShift operator API client instance.
+func CreateOperatorClient() (opclient.Interface, error) {
+	restConfig, err := CreateKubeRestConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error creating kube client config: %v", err)
+	}
+	client, err := opclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating operator client: %v", err)
+	}
+	return client, nil
+}
+
+// GetClusterVersion returns the desired version of the ClusterVersion.
+func GetClusterVersion() (string, error) {
+	oc, err := CreateConfigClient()
+	if err != nil {
+		return "", err
+	}
+	return clusterVersion(oc)
+}
+
+// clusterVersion returns the desired version of the ClusterVersion.
+func clusterVersion(oc occlient.Interface) (string, error) {
+	cv, err := oc.ConfigV1().ClusterVersions().Get(context.TODO(), "version", kmmetav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return cv.Status.Desired.Version, nil
+}
@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/mirror"
+	log "github.com/sirupsen/logrus"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OpenShiftTestsImagesMapping is the mapping of the images required by openshift-tests
+	// to the mirror repository (openshift-tests images --to-repository), written by the
+	// tests container in disconnected environments.
+	OpenShiftTestsImagesMapping = "/tmp/shared/images.mapping"
+
+	// DisconnectedImagesReportFile is the mirror validation report, saved in ResultsDir.
+	DisconnectedImagesReportFile = "disconnected_images.json"
+
+	// DisconnectedEgressJUnit reports the tests removed from the suite as they require
+	// internet egress. The name must be sorted after the openshift-tests JUnit
+	// (junit_e2e__<timestamp>.xml) and the runner status JUnit, processed first.
+	DisconnectedEgressJUnit = "junit_e2e_skip_disconnected.xml"
+
+	// Valid values of the env var DISCONNECTED_EGRESS_POLICY, the result reported
+	// to the tests requiring internet egress.
+	DisconnectedEgressPolicySkip = "skip"
+	DisconnectedEgressPolicyFail = "fail"
+)
+
+// DefaultEgressTestPatterns matches the tests known to require internet egress,
+// labeled by openshift-tests to be skipped in disconnected environments.
+var DefaultEgressTestPatterns = []string{
+	`\[Skipped:Disconnected\]`,
+}
+
+// initDisconnected sets the disconnected mode, enabled when the mirror repository
+// (MIRROR_IMAGE_REPOSITORY) is set. DISCONNECTED_EGRESS_POLICY is the result of the
+// tests requiring internet egress: skip or fail. DISCONNECTED_EGRESS_TESTS_FILE is
+// a file with additional patterns (regex, one by line) of tests requiring egress.
+func (p *Plugin) initDisconnected() {
+	if p.OTRunner == nil || len(p.OTRunner.FromRepository) == 0 {
+		return
+	}
+	p.Disconnected = true
+	cfg := p.settings().Disconnected
+	if envPolicy := cfg.EgressPolicy; len(envPolicy) > 0 {
+		switch envPolicy {
+		case DisconnectedEgressPolicySkip, DisconnectedEgressPolicyFail:
+			p.EgressPolicy = envPolicy
+		default:
+			log.Errorf("invalid DISCONNECTED_EGRESS_POLICY %q, using default %q", envPolicy, p.EgressPolicy)
+		}
+	}
+	patterns := DefaultEgressTestPatterns
+	if file := cfg.EgressTestsFile; len(file) > 0 {
+		extra, err := readPatterns(file)
+		if err != nil {
+			log.Errorf("unable to read DISCONNECTED_EGRESS_TESTS_FILE %s: %v", file, err)
+		}
+		patterns = append(patterns, extra...)
+	}
+	p.EgressTestPatterns = nil
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Errorf("invalid egress test pattern %q: %v", pattern, err)
+			continue
+		}
+		p.EgressTestPatterns = append(p.EgressTestPatterns, re)
+	}
+	log.Infof("Disconnected mode enabled: mirror repository %s, egress tests policy %q", p.OTRunner.FromRepository, p.EgressPolicy)
+}
+
+// readPatterns reads the non-empty lines of the file, ignoring comments.
+func readPatterns(file string) ([]string, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	patterns := []string{}
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// ValidateMirror checks the images required by openshift-tests are available in the
+// mirror, saving the report of missing images in ResultsDir.
+func (p *Plugin) ValidateMirror() (*mirror.Report, error) {
+	if p.clientKube == nil || p.clientConfig == nil {
+		return nil, fmt.Errorf("kube clients not initialized")
+	}
+	ctx := context.TODO()
+	images, err := mirror.ParseMapping(p.workspace().Shared(filepath.Base(OpenShiftTestsImagesMapping)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the images required by openshift-tests: %w", err)
+	}
+	opclient, err := CreateOperatorClient()
+	if err != nil {
+		log.Warnf("unable to create operator client, ignoring %s: %v", mirror.RuleKindICSP, err)
+	}
+	rules, err := mirror.ListRules(ctx, p.clientConfig, opclient)
+	if err != nil {
+		return nil, err
+	}
+	auths := map[string]mirror.Auth{}
+	secret, err := p.clientKube.CoreV1().Secrets("openshift-config").Get(ctx, "pull-secret", kmmetav1.GetOptions{})
+	if err != nil {
+		log.Warnf("unable to read the cluster pull secret, checking the images anonymously: %v", err)
+	} else if auths, err = mirror.ParsePullSecret(secret.Data[kcorev1.DockerConfigJsonKey]); err != nil {
+		log.Warnf("unable to parse the cluster pull secret, checking the images anonymously: %v", err)
+		auths = map[string]mirror.Auth{}
+	}
+
+	registryConfig, err := mirror.ReadRegistryConfig(ctx, p.clientConfig, p.clientKube)
+	if err != nil {
+		log.Warnf("unable to read the registries config, trusting the system CAs: %v", err)
+	}
+	checker, err := mirror.NewRegistryChecker(auths, registryConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the registry checker: %w", err)
+	}
+
+	log.Infof("Validating %d images required by openshift-tests in the mirror (%d mirror rules)", len(images), len(rules))
+	report := mirror.Validate(ctx, images, rules, checker.Exists)
+	report.MirrorRepository = p.OTRunner.FromRepository
+	for _, img := range report.Images {
+		switch img.Status {
+		case mirror.ImageStatusMissing:
+			log.Warnf("Image missing in the mirror: %s (checked: %s)", img.Source, strings.Join(img.Candidates, ", "))
+		case mirror.ImageStatusUnverified:
+			log.Warnf("Image not verified in the mirror: %s: %s", img.Source, strings.Join(img.Errors, "; "))
+		}
+	}
+	log.Infof("Mirror validation: %d available, %d missing, %d unverified", report.Available, report.Missing, report.Unverified)
+	if err := report.Save(p.workspace().Results(DisconnectedImagesReportFile)); err != nil {
+		return report, fmt.Errorf("error saving mirror report: %w", err)
+	}
+	return report, nil
+}
+
+// FilterEgressTests removes the tests requiring internet egress from the suite,
+// reporting them in the JUnit DisconnectedEgressJUnit with the egress policy result.
+func (p *Plugin) FilterEgressTests() error {
+	if !p.Disconnected || len(p.EgressTestPatterns) == 0 {
+		return nil
+	}
+	switch p.id {
+	case PluginId10, PluginId20:
+	default:
+		return nil
+	}
+
+	removed := p.removeSuiteTests(p.EgressTestPatterns)
+	if len(removed) == 0 {
+		return nil
+	}
+
+	result := "skipped"
+	if p.EgressPolicy == DisconnectedEgressPolicyFail {
+		result = "failed"
+	}
+	reports := make([]*JUnitTestReport, 0, len(removed))
+	for _, name := range removed {
+		reports = append(reports, &JUnitTestReport{
+			Name:    name,
+			Result:  result,
+			Message: fmt.Sprintf("[opct] test requires internet egress, not supported in disconnected environment (mirror repository %s)", p.OTRunner.FromRepository),
+		})
+	}
+	if err := WriteJUnitTestSuite(filepath.Join(p.OTRunner.JUnitDir, DisconnectedEgressJUnit), "opct-disconnected", reports); err != nil {
+		return err
+	}
+
+	suiteFile := filepath.Join(filepath.Dir(p.SuiteFile), "suite-disconnected.list")
+	if err := WriteTestSuite(p.SuiteTests, suiteFile); err != nil {
+		return fmt.Errorf("error saving suite list to file: %w", err)
+	}
+	log.Infof("Disconnected: removed %d tests requiring internet egress (%s), running %d tests from %s", len(removed), result, len(p.SuiteTests), suiteFile)
+	p.OTRunner.File = suiteFile
+	return nil
+}
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilterEgressTests(t *testing.T) {
+	for _, policy := range []string{DisconnectedEgressPolicySkip, DisconnectedEgressPolicyFail} {
+		t.Run(policy, func(t *testing.T) {
+			dir := t.TempDir()
+			patterns := filepath.Join(dir, "egress.list")
+			if err := os.WriteFile(patterns, []byte("# custom\n\\[sig-builds\\].*git\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("DISCONNECTED_EGRESS_POLICY", policy)
+			t.Setenv("DISCONNECTED_EGRESS_TESTS_FILE", patterns)
+
+			p, err := NewPlugin(PluginName20)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			p.SuiteFile = filepath.Join(dir, "suite.list")
+			p.OTRunner.JUnitDir = filepath.Join(dir, "junit")
+			p.OTRunner.FromRepository = "mirror.example.com/ocp/e2e"
+			p.initDisconnected()
+			if !p.Disconnected || len(p.EgressTestPatterns) != 2 {
+				t.Fatalf("initDisconnected() = %v patterns %v, want disconnected with 2 patterns", p.Disconnected, p.EgressTestPatterns)
+			}
+			p.SuiteTests = map[string]struct{}{
+				`"[sig-network] egress [Skipped:Disconnected] [Suite:openshift/conformance/parallel]"`: {},
+				`"[sig-builds] build from git repository"`:                                             {},
+				`"[sig-apps] deployment"`:                                                              {},
+			}
+			if err := p.FilterEgressTests(); err != nil {
+				t.Fatalf("FilterEgressTests() unexpected error: %v", err)
+			}
+
+			if _, ok := p.SuiteTests[`"[sig-apps] deployment"`]; !ok || len(p.SuiteTests) != 1 {
+				t.Errorf("SuiteTests = %v, want only the deployment test", p.SuiteTests)
+			}
+			data, err := os.ReadFile(p.OTRunner.File)
+			if err != nil || strings.TrimSpace(string(data)) != `"[sig-apps] deployment"` {
+				t.Errorf("suite file %s = %q (%v), want the filtered suite", p.OTRunner.File, string(data), err)
+			}
+			junit, err := os.ReadFile(filepath.Join(p.OTRunner.JUnitDir, DisconnectedEgressJUnit))
+			if err != nil {
+				t.Fatalf("egress JUnit not created: %v", err)
+			}
+			result := "<skipped"
+			if policy == DisconnectedEgressPolicyFail {
+				result = "<failure"
+			}
+			for _, want := range []string{`tests="2"`, `name="[sig-builds] build from git repository"`, result, "requires internet egress"} {
+				if !strings.Contains(string(junit), want) {
+					t.Errorf("egress JUnit missing %q:\n%s", want, string(junit))
+				}
+			}
+		})
+	}
+}
+
+func TestFilterEgressTestsConnected(t *testing.T) {
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	p.initDisconnected()
+	p.SuiteTests = map[string]struct{}{`"[sig-network] egress [Skipped:Disconnected]"`: {}}
+	if err := p.FilterEgressTests(); err != nil || len(p.SuiteTests) != 1 || p.Disconnected {
+		t.Errorf("FilterEgressTests() = %v, suite %v, want tests kept when connected", err, p.SuiteTests)
+	}
+}
+
+func TestMergeJUnitTestCases(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "junit_e2e__20240705-202749.xml")
+	src := filepath.Join(dir, DisconnectedEgressJUnit)
+	result := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="openshift-tests" tests="2" skipped="0" failures="1" time="10">
+  <property name="TestVersion" value="v4.18.0"></property>
+  <testcase name="[sig-a] passed" time="1"></testcase>
+  <testcase name="[sig-b] failed" time="1"><failure>error</failure></testcase>
+</testsuite>`
+	if err := os.WriteFile(dst, []byte(result), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJUnitTestSuite(src, "opct-disconnected", []*JUnitTestReport{
+		{Name: "[sig-c] egress", Result: "skipped", Message: "requires egress"},
+		{Name: "[sig-d] egress", Result: "failed", Message: "requires egress"},
+	}); err != nil {
+		t.Fatalf("WriteJUnitTestSuite() unexpected error: %v", err)
+	}
+	if err := MergeJUnitTestCases(dst, src); err != nil {
+		t.Fatalf("MergeJUnitTestCases() unexpected error: %v", err)
+	}
+
+	p := &Plugin{}
+	failures := filepath.Join(dir, "failures.list")
+	suite := filepath.Join(dir, "suite.list")
+	if err := os.WriteFile(suite, []byte("\"[sig-b] failed\"\n\"[sig-d] egress\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ParseAndExtractFailuresFromJunit(suite, dst, failures, filepath.Join(dir, "failures-suite.list")); err != nil {
+		t.Fatalf("merged JUnit is invalid: %v", err)
+	}
+	data, _ := os.ReadFile(dst)
+	for _, want := range []string{`tests="4" skipped="1" failures="2"`, `name="[sig-c] egress"`, `<property name="TestVersion"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("merged JUnit missing %q:\n%s", want, string(data))
+		}
+	}
+	failed, _ := os.ReadFile(failures)
+	if !strings.Contains(string(failed), "[sig-d] egress") {
+		t.Errorf("failures = %q, want the failed egress test", string(failed))
+	}
+}
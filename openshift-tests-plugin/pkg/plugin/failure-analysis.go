@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/analysis"
+)
+
+const (
+	// FailureAnalysisName is the name of the failure signatures report (.json), saved in
+	// ResultsDir and suffixed by the shard.
+	FailureAnalysisName = "failure-analysis"
+
+	// failureAnalysisSummaryLimit is the count of signatures shown in the summary.
+	failureAnalysisSummaryLimit = 10
+)
+
+// AnalyzeFailures clusters the failures of the JUnit by signature, saving the report to
+// ResultsDir. The failures without message in the JUnit are analyzed by the failure
+// output read from the stream. The most frequent signatures are shown by Summary.
+func (p *Plugin) AnalyzeFailures(junitFile string) (*analysis.Report, error) {
+	failures, err := analysis.ReadJUnitFailures(junitFile)
+	if err != nil {
+		return nil, err
+	}
+	for i := range failures {
+		if len(strings.TrimSpace(failures[i].Message)) > 0 {
+			continue
+		}
+		if excerpt := p.Progress.FailureExcerpt(failures[i].Test); excerpt != nil {
+			failures[i].Message = excerpt.Excerpt
+		}
+	}
+	report := analysis.Analyze(failures)
+	reportFile := p.workspace().Results(FailureAnalysisName + p.ShardSuffix() + ".json")
+	if err := saveJSON(reportFile, report); err != nil {
+		return nil, fmt.Errorf("error saving failure analysis: %w", err)
+	}
+
+	log.Infof("Failure analysis: %d failures in %d signatures, saved to %s", report.Failures, len(report.Signatures), reportFile)
+	return report, nil
+}
@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/analysis"
+)
+
+// TestAnalyzeFailures tests the failures of the JUnit are clustered, the failures without
+// message analyzed by the failure output of the stream.
+func TestAnalyzeFailures(t *testing.T) {
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	ws := NewWorkspace(t.TempDir(), "")
+	if err := ws.Create(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetWorkspace(ws)
+
+	for _, line := range []string{
+		`started: 0/3/3 "[sig-cli] test c"`,
+		`fail [cli.go:10]: pod "cli-x2k9z" timed out after 5m0s`,
+		`failed: (5m2s) 2024-07-05T20:32:51 "[sig-cli] test c"`,
+	} {
+		if _, err := p.Progress.ParserOpenShiftTestsOutputLine(line); err != nil {
+			t.Fatalf("ParserOpenShiftTestsOutputLine() unexpected error: %v", err)
+		}
+	}
+	junit := filepath.Join(ws.JUnitDir(), "junit_e2e__20240705-202749.xml")
+	if err := WriteJUnitTestSuite(junit, "openshift-tests", []*JUnitTestReport{
+		{Name: "[sig-apps] test a", Result: "failed", Message: `fail [apps.go:1]: pod "a-b7c9z" timed out after 300.000s`},
+		{Name: "[sig-network] test b", Result: "passed"},
+		{Name: "[sig-cli] test c", Result: "failed"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := p.AnalyzeFailures(junit)
+	if err != nil {
+		t.Fatalf("AnalyzeFailures() unexpected error: %v", err)
+	}
+	if report.Failures != 2 || len(report.Signatures) != 1 || report.Signatures[0].Signature != `pod "<pod>" timed out after <duration>` {
+		t.Fatalf("AnalyzeFailures() = %+v, want the failures of test a and c in one signature", report)
+	}
+	saved := analysis.Report{}
+	data, err := os.ReadFile(ws.Results(FailureAnalysisName + ".json"))
+	if err != nil || json.Unmarshal(data, &saved) != nil || len(saved.Signatures) != 1 {
+		t.Errorf("failure analysis = %s (%v), want the report saved", string(data), err)
+	}
+	dir, err := p.PackageResults(junit)
+	if err != nil {
+		t.Fatalf("PackageResults() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FailureAnalysisName+".json")); err != nil {
+		t.Errorf("failure analysis not found in the results package: %v", err)
+	}
+
+	// the signatures are shown by the summary.
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	p.FailureAnalysis = report
+	p.Summary()
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if want := "2 " + report.Signatures[0].ID + " [sig-apps,sig-cli] " + report.Signatures[0].Signature; !strings.Contains(string(out), want) {
+		t.Errorf("Summary() = %q, want the signature %q", string(out), want)
+	}
+}
@@ -0,0 +1,192 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/parser"
+)
+
+const (
+	// FailureExcerptsName is the name of the failure report (.json) and of the directory
+	// with the output of each failed test, saved in ResultsDir and suffixed by the shard.
+	FailureExcerptsName = "failure-excerpts"
+	// FailuresConfigMapKey is the key of the failure excerpts in the failures ConfigMap.
+	FailuresConfigMapKey = "failures.json"
+	// FailuresLabel labels the failures ConfigMap with the plugin ID, selecting the
+	// ConfigMaps of the failures to replay.
+	FailuresLabel = "opct.redhat.com/plugin-failures"
+
+	// MaxFailureExcerptBytes is the size limit of the output kept by failed test.
+	MaxFailureExcerptBytes = 16 * 1024
+	// MaxFailureExcerpts is the limit of failed tests with the output kept in memory.
+	MaxFailureExcerpts = 1000
+
+	// maxConfigMapExcerptBytes and maxConfigMapFailuresBytes are the size limits of the
+	// failure excerpts in the ConfigMap, by test and in total (the ConfigMap is limited to 1MiB).
+	maxConfigMapExcerptBytes  = 1024
+	maxConfigMapFailuresBytes = 256 * 1024
+	// truncateMarkerBytes is the size reserved to the truncation marker.
+	truncateMarkerBytes = 64
+)
+
+// FailureExcerpt is the output printed by openshift-tests before the failed line of a test.
+type FailureExcerpt struct {
+	Name string `json:"name"`
+	// File is the file with the excerpt, relative to ResultsDir.
+	File    string `json:"file,omitempty"`
+	Excerpt string `json:"excerpt"`
+	// Lines and Bytes are the size of the output printed, before truncated.
+	Lines     int    `json:"lines"`
+	Bytes     int    `json:"bytes"`
+	Truncated bool   `json:"truncated,omitempty"`
+	StartedAt string `json:"startedAt,omitempty"`
+	EndAt     string `json:"endAt,omitempty"`
+}
+
+// FailureReport is the output of the failed tests reported in the JUnit.
+type FailureReport struct {
+	Plugin   string            `json:"plugin"`
+	Failures int               `json:"failures"`
+	Excerpts []*FailureExcerpt `json:"excerpts"`
+	// WithoutOutput are the failed tests without output read from the stream.
+	WithoutOutput []string `json:"withoutOutput,omitempty"`
+}
+
+// newFailureExcerpt creates the excerpt of the output of the failed test, limited to
+// MaxFailureExcerptBytes.
+func newFailureExcerpt(ev *parser.Event) *FailureExcerpt {
+	text := strings.Join(ev.Output, "\n")
+	fe := &FailureExcerpt{
+		Name:      ev.Name,
+		Lines:     len(ev.Output) + ev.OutputDropped,
+		Bytes:     len(text),
+		StartedAt: ev.StartedAt.Format(time.RFC3339),
+		EndAt:     ev.EndAt.Format(time.RFC3339),
+	}
+	if ev.OutputDropped > 0 {
+		text = fmt.Sprintf("... [%d lines truncated] ...\n%s", ev.OutputDropped, text)
+		fe.Truncated = true
+	}
+	var truncated bool
+	fe.Excerpt, truncated = truncateExcerpt(text, MaxFailureExcerptBytes)
+	fe.Truncated = fe.Truncated || truncated
+	return fe
+}
+
+// truncateExcerpt limits the text to max bytes, keeping the head, where the failure
+// is usually described, and the tail, where the failed assertion is printed.
+func truncateExcerpt(text string, max int) (string, bool) {
+	if len(text) <= max {
+		return text, false
+	}
+	keep := max - truncateMarkerBytes
+	if keep < 0 {
+		keep = 0
+	}
+	head := keep / 4
+	tail := keep - head
+	marker := fmt.Sprintf("\n... [%d bytes truncated] ...\n", len(text)-keep)
+	// the cut may split a multi-byte character, dropped as invalid.
+	return strings.ToValidUTF8(text[:head], "") + marker + strings.ToValidUTF8(text[len(text)-tail:], ""), true
+}
+
+// recordFailureOutput keeps the output of the failed test, up to MaxFailureExcerpts tests.
+func (ps *PluginProgress) recordFailureOutput(ev *parser.Event) {
+	ps.failuresMu.Lock()
+	defer ps.failuresMu.Unlock()
+	if ps.failures == nil {
+		ps.failures = make(map[string]*FailureExcerpt)
+	}
+	if _, ok := ps.failures[ev.Name]; !ok && len(ps.failures) >= MaxFailureExcerpts {
+		if ps.failuresDropped == 0 {
+			log.Warnf("failure output: limit of %d tests reached, discarding the output of the next failures", MaxFailureExcerpts)
+		}
+		ps.failuresDropped++
+		return
+	}
+	ps.failures[ev.Name] = newFailureExcerpt(ev)
+}
+
+// FailureExcerpt returns the output of the failed test, nil when not read from the stream.
+func (ps *PluginProgress) FailureExcerpt(name string) *FailureExcerpt {
+	ps.failuresMu.Lock()
+	defer ps.failuresMu.Unlock()
+	return ps.failures[name]
+}
+
+// SaveFailureExcerpts saves the output of the failed tests of the failures list (the
+// quoted test names extracted from the JUnit) to ResultsDir: a file by test and the
+// failure report.
+func (p *Plugin) SaveFailureExcerpts(failuresList string) (*FailureReport, error) {
+	data, err := os.ReadFile(failuresList)
+	if err != nil {
+		return nil, fmt.Errorf("error reading failures list: %w", err)
+	}
+	ws := p.workspace()
+	name := FailureExcerptsName + p.ShardSuffix()
+	report := &FailureReport{Plugin: p.FullName(), Excerpts: []*FailureExcerpt{}}
+	for _, line := range strings.Split(string(data), "\n") {
+		// the names are quoted by ParseAndExtractFailuresFromJunit, not escaped.
+		test := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(line), `"`), `"`)
+		if len(test) == 0 {
+			continue
+		}
+		report.Failures++
+		excerpt := p.Progress.FailureExcerpt(test)
+		if excerpt == nil {
+			report.WithoutOutput = append(report.WithoutOutput, test)
+			continue
+		}
+		if len(report.Excerpts) == 0 {
+			if err := os.MkdirAll(ws.Results(name), 0755); err != nil {
+				return nil, fmt.Errorf("error creating failure excerpts directory: %w", err)
+			}
+		}
+		fe := *excerpt
+		fe.File = filepath.Join(name, fmt.Sprintf("%04d.txt", len(report.Excerpts)+1))
+		if err := os.WriteFile(ws.Results(fe.File), []byte(fmt.Sprintf("%s\n\n%s\n", fe.Name, fe.Excerpt)), 0644); err != nil {
+			return nil, fmt.Errorf("error saving failure excerpt: %w", err)
+		}
+		report.Excerpts = append(report.Excerpts, &fe)
+	}
+	if err := saveJSON(ws.Results(name+".json"), report); err != nil {
+		return nil, fmt.Errorf("error saving failure report: %w", err)
+	}
+	log.Infof("Saved the output of %d/%d failed tests to %s", len(report.Excerpts), report.Failures, ws.Results(name+".json"))
+	return report, nil
+}
+
+// configMapFailures returns the failure excerpts saved in the failures ConfigMap, each
+// excerpt limited to maxConfigMapExcerptBytes, up to maxConfigMapFailuresBytes.
+func configMapFailures(report *FailureReport) (string, error) {
+	excerpts := []*FailureExcerpt{}
+	size := 0
+	for _, excerpt := range report.Excerpts {
+		fe := *excerpt
+		if text, truncated := truncateExcerpt(fe.Excerpt, maxConfigMapExcerptBytes); truncated {
+			fe.Excerpt, fe.Truncated = text, true
+		}
+		data, err := json.Marshal(&fe)
+		if err != nil {
+			return "", err
+		}
+		if size+len(data) > maxConfigMapFailuresBytes {
+			log.Warnf("failure output: ConfigMap limit reached, saving %d/%d excerpts", len(excerpts), len(report.Excerpts))
+			break
+		}
+		size += len(data)
+		excerpts = append(excerpts, &fe)
+	}
+	data, err := json.Marshal(excerpts)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
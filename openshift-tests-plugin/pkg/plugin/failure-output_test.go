@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTruncateExcerpt(t *testing.T) {
+	if got, truncated := truncateExcerpt("short", 1024); got != "short" || truncated {
+		t.Errorf("truncateExcerpt() = %q (%v), want the text not truncated", got, truncated)
+	}
+	text := "head " + strings.Repeat("é", 2000) + " tail"
+	got, truncated := truncateExcerpt(text, 1024)
+	if !truncated || len(got) > 1024 || !utf8.ValidString(got) {
+		t.Fatalf("truncateExcerpt() = %d bytes (%v), want up to 1024 valid bytes truncated", len(got), truncated)
+	}
+	if !strings.HasPrefix(got, "head ") || !strings.HasSuffix(got, " tail") || !strings.Contains(got, "bytes truncated") {
+		t.Errorf("truncateExcerpt() = %q, want the head, the marker and the tail", got)
+	}
+}
+
+// TestSaveFailureExcerpts tests the output of the failed tests read from the stream is
+// saved to the results and to the failures ConfigMap.
+func TestSaveFailureExcerpts(t *testing.T) {
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	ws := NewWorkspace(t.TempDir(), "")
+	if err := ws.Create(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetWorkspace(ws)
+	p.SetClients(kfake.NewSimpleClientset(), nil, nil)
+
+	output := []string{
+		`started: 0/1/3 "test a"`,
+		`started: 0/2/3 "test b"`,
+		`fail [job.go:211]: Timed out after 300.000s.`,
+		`Expected pod to be "Failed"`,
+		``,
+		`failed: (5m2s) 2024-07-05T20:32:51 "test a"`,
+		strings.Repeat("x", MaxFailureExcerptBytes+1),
+		`failed: (1s) 2024-07-05T20:32:52 "test b"`,
+		`started: 0/3/3 "test c"`,
+		`failed: (1s) 2024-07-05T20:32:53 "test c"`,
+	}
+	for _, line := range output {
+		if _, err := p.Progress.ParserOpenShiftTestsOutputLine(line); err != nil {
+			t.Fatalf("ParserOpenShiftTestsOutputLine() unexpected error: %v", err)
+		}
+	}
+	failuresList := ws.Shared("failures.list")
+	if err := os.WriteFile(failuresList, []byte("\"test a\"\n\"test b\"\n\"test c\""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := p.SaveFailureExcerpts(failuresList)
+	if err != nil {
+		t.Fatalf("SaveFailureExcerpts() unexpected error: %v", err)
+	}
+	if report.Failures != 3 || len(report.Excerpts) != 2 || fmt.Sprint(report.WithoutOutput) != "[test c]" {
+		t.Fatalf("SaveFailureExcerpts() = %+v, want 2 excerpts of 3 failures", report)
+	}
+	a, b := report.Excerpts[0], report.Excerpts[1]
+	if a.Name != "test a" || a.Excerpt != "fail [job.go:211]: Timed out after 300.000s.\nExpected pod to be \"Failed\"" || a.Truncated {
+		t.Errorf("excerpt = %+v, want the failure output of test a", a)
+	}
+	if b.Bytes != MaxFailureExcerptBytes+1 || !b.Truncated || len(b.Excerpt) > MaxFailureExcerptBytes {
+		t.Errorf("excerpt = %d bytes of %d, truncated %v, want limited to %d", len(b.Excerpt), b.Bytes, b.Truncated, MaxFailureExcerptBytes)
+	}
+	data, err := os.ReadFile(ws.Results(a.File))
+	if err != nil || !strings.HasPrefix(string(data), "test a\n\nfail [job.go:211]") {
+		t.Errorf("excerpt file %s = %q (%v), want the test name and the output", a.File, string(data), err)
+	}
+	saved := FailureReport{}
+	data, err = os.ReadFile(ws.Results(FailureExcerptsName + ".json"))
+	if err != nil || json.Unmarshal(data, &saved) != nil || len(saved.Excerpts) != 2 {
+		t.Errorf("failure report = %s (%v), want the excerpts", string(data), err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.ResultsDir, FailureExcerptsName, "0002.txt")); err != nil {
+		t.Errorf("excerpt file of test b: %v", err)
+	}
+	// the excerpts are uploaded in the results package.
+	dir, err := p.PackageResults(ws.Results("junit_e2e__20240705-202749.xml"))
+	if err != nil {
+		t.Fatalf("PackageResults() unexpected error: %v", err)
+	}
+	for _, name := range []string{FailureExcerptsName + ".json", a.File, b.File} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s not found in the results package: %v", name, err)
+		}
+	}
+
+	suite := ws.Work("failures-suite.txt")
+	if err := os.WriteFile(suite, []byte(`"test a"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SaveToConfigMap(suite, report); err != nil {
+		t.Fatalf("SaveToConfigMap() unexpected error: %v", err)
+	}
+	cm, err := p.clientKube.CoreV1().ConfigMaps(EnvNamespace).Get(context.TODO(), "plugin-failures-20", kmmetav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Labels[FailuresLabel] != PluginId20 {
+		t.Errorf("failures ConfigMap labels = %v, want the plugin ID", cm.Labels)
+	}
+	excerpts := []*FailureExcerpt{}
+	if err := json.Unmarshal([]byte(cm.Data[FailuresConfigMapKey]), &excerpts); err != nil || len(excerpts) != 2 {
+		t.Fatalf("ConfigMap %s = %s (%v), want 2 excerpts", FailuresConfigMapKey, cm.Data[FailuresConfigMapKey], err)
+	}
+	if excerpts[0].Excerpt != a.Excerpt || len(excerpts[1].Excerpt) > maxConfigMapExcerptBytes {
+		t.Errorf("ConfigMap excerpts = %q and %d bytes, want limited to %d bytes", excerpts[0].Excerpt, len(excerpts[1].Excerpt), maxConfigMapExcerptBytes)
+	}
+}
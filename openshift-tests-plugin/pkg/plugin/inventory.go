@@ -0,0 +1,277 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	occlient "github.com/openshift/client-go/config/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// InventoryStageStart is the snapshot taken when the plugin is initialized.
+	InventoryStageStart = "start"
+	// InventoryStageEnd is the snapshot taken when the plugin execution finished.
+	InventoryStageEnd = "end"
+
+	// InventoryDiffFile is the comparison of the start and end snapshots, saved in ResultsDir.
+	InventoryDiffFile = "inventory_diff.json"
+
+	// nodeRoleLabelPrefix is the label prefix of the node roles.
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+)
+
+// InventoryCondition is the status condition of a cluster resource.
+type InventoryCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// InventoryClusterVersion is the state of the ClusterVersion.
+type InventoryClusterVersion struct {
+	Version    string               `json:"version"`
+	Channel    string               `json:"channel,omitempty"`
+	Conditions []InventoryCondition `json:"conditions,omitempty"`
+}
+
+// InventoryOperator is the state of a ClusterOperator.
+type InventoryOperator struct {
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	Conditions []InventoryCondition `json:"conditions,omitempty"`
+}
+
+// Condition returns the condition by type, nil when not reported.
+func (o *InventoryOperator) Condition(condType string) *InventoryCondition {
+	for i := range o.Conditions {
+		if o.Conditions[i].Type == condType {
+			return &o.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// Degraded returns true when the operator reports the condition Degraded=True.
+func (o *InventoryOperator) Degraded() bool {
+	cond := o.Condition(string(configv1.OperatorDegraded))
+	return cond != nil && cond.Status == string(configv1.ConditionTrue)
+}
+
+// InventoryNode is the state of a cluster node.
+type InventoryNode struct {
+	Name       string               `json:"name"`
+	Roles      []string             `json:"roles,omitempty"`
+	Capacity   map[string]string    `json:"capacity,omitempty"`
+	Conditions []InventoryCondition `json:"conditions,omitempty"`
+}
+
+// Inventory is the snapshot of the cluster state.
+type Inventory struct {
+	Stage            string                   `json:"stage"`
+	Plugin           string                   `json:"plugin,omitempty"`
+	CreatedAt        string                   `json:"createdAt"`
+	ClusterVersion   *InventoryClusterVersion `json:"clusterVersion,omitempty"`
+	ClusterOperators []InventoryOperator      `json:"clusterOperators,omitempty"`
+	Nodes            []InventoryNode          `json:"nodes,omitempty"`
+	Platform         string                   `json:"platform,omitempty"`
+	NetworkType      string                   `json:"networkType,omitempty"`
+	// Errors are the failures collecting the resources, the snapshot is partial when set.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// InventoryOperatorChange is a ClusterOperator which changed the Degraded condition.
+type InventoryOperatorChange struct {
+	Name    string `json:"name"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// InventoryDiff is the comparison of the inventory at the start and end of the plugin.
+type InventoryDiff struct {
+	Plugin string `json:"plugin,omitempty"`
+	// DegradedOperators are the operators which became degraded during the plugin execution.
+	DegradedOperators []InventoryOperatorChange `json:"degradedOperators"`
+	// RecoveredOperators are the operators degraded at the start, not degraded at the end.
+	RecoveredOperators []string `json:"recoveredOperators,omitempty"`
+}
+
+// CollectInventory collects the snapshot of the cluster state. Failures collecting
+// a resource are recorded in the inventory errors, not interrupting the collection.
+func CollectInventory(ctx context.Context, kclient kubernetes.Interface, oclient occlient.Interface) *Inventory {
+	inv := &Inventory{CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	addErr := func(resource string, err error) {
+		inv.Errors = append(inv.Errors, fmt.Sprintf("%s: %v", resource, err))
+	}
+
+	if cv, err := oclient.ConfigV1().ClusterVersions().Get(ctx, "version", kmmetav1.GetOptions{}); err != nil {
+		addErr("clusterversion", err)
+	} else {
+		inv.ClusterVersion = &InventoryClusterVersion{
+			Version: cv.Status.Desired.Version,
+			Channel: cv.Spec.Channel,
+		}
+		for _, c := range cv.Status.Conditions {
+			inv.ClusterVersion.Conditions = append(inv.ClusterVersion.Conditions, InventoryCondition{
+				Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+			})
+		}
+	}
+
+	if cos, err := oclient.ConfigV1().ClusterOperators().List(ctx, kmmetav1.ListOptions{}); err != nil {
+		addErr("clusteroperators", err)
+	} else {
+		for _, co := range cos.Items {
+			op := InventoryOperator{Name: co.Name}
+			for _, v := range co.Status.Versions {
+				if v.Name == "operator" {
+					op.Version = v.Version
+				}
+			}
+			for _, c := range co.Status.Conditions {
+				op.Conditions = append(op.Conditions, InventoryCondition{
+					Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+				})
+			}
+			inv.ClusterOperators = append(inv.ClusterOperators, op)
+		}
+		sort.Slice(inv.ClusterOperators, func(i, j int) bool {
+			return inv.ClusterOperators[i].Name < inv.ClusterOperators[j].Name
+		})
+	}
+
+	if nodes, err := kclient.CoreV1().Nodes().List(ctx, kmmetav1.ListOptions{}); err != nil {
+		addErr("nodes", err)
+	} else {
+		for _, node := range nodes.Items {
+			inv.Nodes = append(inv.Nodes, inventoryNode(&node))
+		}
+		sort.Slice(inv.Nodes, func(i, j int) bool { return inv.Nodes[i].Name < inv.Nodes[j].Name })
+	}
+
+	if infra, err := oclient.ConfigV1().Infrastructures().Get(ctx, "cluster", kmmetav1.GetOptions{}); err != nil {
+		addErr("infrastructure", err)
+	} else if infra.Status.PlatformStatus != nil {
+		inv.Platform = string(infra.Status.PlatformStatus.Type)
+	}
+
+	if network, err := oclient.ConfigV1().Networks().Get(ctx, "cluster", kmmetav1.GetOptions{}); err != nil {
+		addErr("network", err)
+	} else if len(network.Status.NetworkType) > 0 {
+		inv.NetworkType = network.Status.NetworkType
+	} else {
+		inv.NetworkType = network.Spec.NetworkType
+	}
+
+	return inv
+}
+
+// inventoryNode returns the roles, capacity and conditions of the node.
+func inventoryNode(node *kcorev1.Node) InventoryNode {
+	n := InventoryNode{Name: node.Name, Capacity: map[string]string{}}
+	for label := range node.Labels {
+		if role, ok := strings.CutPrefix(label, nodeRoleLabelPrefix); ok && len(role) > 0 {
+			n.Roles = append(n.Roles, role)
+		}
+	}
+	sort.Strings(n.Roles)
+	for name, quantity := range node.Status.Capacity {
+		n.Capacity[string(name)] = quantity.String()
+	}
+	for _, c := range node.Status.Conditions {
+		n.Conditions = append(n.Conditions, InventoryCondition{
+			Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+		})
+	}
+	return n
+}
+
+// DiffInventory compares the snapshots, flagging the operators which became degraded.
+func DiffInventory(start, end *Inventory) *InventoryDiff {
+	diff := &InventoryDiff{Plugin: end.Plugin, DegradedOperators: []InventoryOperatorChange{}}
+	startOps := make(map[string]*InventoryOperator, len(start.ClusterOperators))
+	for i := range start.ClusterOperators {
+		startOps[start.ClusterOperators[i].Name] = &start.ClusterOperators[i]
+	}
+	for i := range end.ClusterOperators {
+		op := &end.ClusterOperators[i]
+		before, found := startOps[op.Name]
+		wasDegraded := found && before.Degraded()
+		switch {
+		case op.Degraded() && !wasDegraded:
+			cond := op.Condition(string(configv1.OperatorDegraded))
+			diff.DegradedOperators = append(diff.DegradedOperators, InventoryOperatorChange{
+				Name: op.Name, Reason: cond.Reason, Message: cond.Message,
+			})
+		case !op.Degraded() && wasDegraded:
+			diff.RecoveredOperators = append(diff.RecoveredOperators, op.Name)
+		}
+	}
+	return diff
+}
+
+// inventoryFile returns the snapshot file path of the stage.
+func (p *Plugin) inventoryFile(stage string) string {
+	return p.workspace().Results(fmt.Sprintf("inventory_%s.json", stage))
+}
+
+// SnapshotInventory saves the cluster inventory of the stage into ResultsDir. The
+// end snapshot is compared with the start, saving the diff and reporting the
+// operators which became degraded during the plugin execution.
+func (p *Plugin) SnapshotInventory(stage string) error {
+	if p.clientKube == nil || p.clientConfig == nil {
+		return fmt.Errorf("kube clients not initialized")
+	}
+	inv := CollectInventory(context.TODO(), p.clientKube, p.clientConfig)
+	inv.Stage = stage
+	inv.Plugin = p.FullName()
+	for _, msg := range inv.Errors {
+		log.Warnf("Inventory %s: unable to collect %s", stage, msg)
+	}
+	if err := saveJSON(p.inventoryFile(stage), inv); err != nil {
+		return fmt.Errorf("error saving inventory: %w", err)
+	}
+	log.Infof("Inventory %s saved: %d cluster operators, %d nodes", stage, len(inv.ClusterOperators), len(inv.Nodes))
+	if stage != InventoryStageEnd {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.inventoryFile(InventoryStageStart))
+	if err != nil {
+		return fmt.Errorf("unable to read start inventory: %w", err)
+	}
+	start := &Inventory{}
+	if err := json.Unmarshal(data, start); err != nil {
+		return fmt.Errorf("invalid start inventory: %w", err)
+	}
+	diff := DiffInventory(start, inv)
+	for _, op := range diff.DegradedOperators {
+		log.Warnf("ClusterOperator %s became degraded during the plugin execution: %s %s", op.Name, op.Reason, op.Message)
+	}
+	for _, name := range diff.RecoveredOperators {
+		log.Infof("ClusterOperator %s recovered from degraded during the plugin execution", name)
+	}
+	if err := saveJSON(p.workspace().Results(InventoryDiffFile), diff); err != nil {
+		return fmt.Errorf("error saving inventory diff: %w", err)
+	}
+	return nil
+}
+
+// saveJSON writes the object as indented JSON.
+func saveJSON(file string, obj interface{}) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
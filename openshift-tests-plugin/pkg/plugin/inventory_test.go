@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	ocfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	kcorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func clusterOperator(name string, degraded configv1.ConditionStatus, reason string) *configv1.ClusterOperator {
+	return &configv1.ClusterOperator{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: name},
+		Status: configv1.ClusterOperatorStatus{
+			Versions: []configv1.OperandVersion{{Name: "operator", Version: "4.18.0"}},
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+				{Type: configv1.OperatorDegraded, Status: degraded, Reason: reason},
+			},
+		},
+	}
+}
+
+func TestCollectInventory(t *testing.T) {
+	oclient := ocfake.NewSimpleClientset(
+		&configv1.ClusterVersion{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "version"},
+			Spec:       configv1.ClusterVersionSpec{Channel: "stable-4.18"},
+			Status:     configv1.ClusterVersionStatus{Desired: configv1.Release{Version: "4.18.0"}},
+		},
+		clusterOperator("kube-apiserver", configv1.ConditionFalse, ""),
+		clusterOperator("etcd", configv1.ConditionFalse, ""),
+		&configv1.Infrastructure{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType}},
+		},
+	)
+	kclient := kfake.NewSimpleClientset(&kcorev1.Node{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "master-0", Labels: map[string]string{
+			"node-role.kubernetes.io/master":        "",
+			"node-role.kubernetes.io/control-plane": "",
+		}},
+		Status: kcorev1.NodeStatus{
+			Capacity:   kcorev1.ResourceList{kcorev1.ResourceCPU: resource.MustParse("4")},
+			Conditions: []kcorev1.NodeCondition{{Type: kcorev1.NodeReady, Status: kcorev1.ConditionTrue}},
+		},
+	})
+
+	inv := CollectInventory(context.Background(), kclient, oclient)
+	if inv.ClusterVersion == nil || inv.ClusterVersion.Version != "4.18.0" || inv.ClusterVersion.Channel != "stable-4.18" {
+		t.Errorf("ClusterVersion = %+v, want 4.18.0 stable-4.18", inv.ClusterVersion)
+	}
+	if len(inv.ClusterOperators) != 2 || inv.ClusterOperators[0].Name != "etcd" || inv.ClusterOperators[0].Version != "4.18.0" {
+		t.Errorf("ClusterOperators = %+v, want etcd and kube-apiserver sorted", inv.ClusterOperators)
+	}
+	if len(inv.Nodes) != 1 || !reflect.DeepEqual(inv.Nodes[0].Roles, []string{"control-plane", "master"}) || inv.Nodes[0].Capacity["cpu"] != "4" {
+		t.Errorf("Nodes = %+v, want master-0 with roles and capacity", inv.Nodes)
+	}
+	if inv.Platform != "AWS" {
+		t.Errorf("Platform = %q, want AWS", inv.Platform)
+	}
+	// the network config is missing, the inventory is partial.
+	if len(inv.Errors) != 1 || inv.NetworkType != "" {
+		t.Errorf("Errors = %v, want only the network error", inv.Errors)
+	}
+}
+
+func TestDiffInventory(t *testing.T) {
+	operators := func(cos ...runtime.Object) []InventoryOperator {
+		inv := CollectInventory(context.Background(), kfake.NewSimpleClientset(), ocfake.NewSimpleClientset(cos...))
+		return inv.ClusterOperators
+	}
+	start := &Inventory{ClusterOperators: operators(
+		clusterOperator("etcd", configv1.ConditionFalse, ""),
+		clusterOperator("dns", configv1.ConditionTrue, "DNSDegraded"),
+		clusterOperator("network", configv1.ConditionFalse, ""),
+	)}
+	end := &Inventory{Plugin: "20-openshift-conformance-validated", ClusterOperators: operators(
+		clusterOperator("etcd", configv1.ConditionTrue, "EtcdMembersDegraded"),
+		clusterOperator("dns", configv1.ConditionFalse, ""),
+		clusterOperator("network", configv1.ConditionFalse, ""),
+		clusterOperator("storage", configv1.ConditionTrue, "StorageDegraded"),
+	)}
+
+	diff := DiffInventory(start, end)
+	want := []InventoryOperatorChange{
+		{Name: "etcd", Reason: "EtcdMembersDegraded"},
+		{Name: "storage", Reason: "StorageDegraded"},
+	}
+	if !reflect.DeepEqual(diff.DegradedOperators, want) {
+		t.Errorf("DegradedOperators = %+v, want %+v", diff.DegradedOperators, want)
+	}
+	if !reflect.DeepEqual(diff.RecoveredOperators, []string{"dns"}) {
+		t.Errorf("RecoveredOperators = %v, want [dns]", diff.RecoveredOperators)
+	}
+	if diff.Plugin != end.Plugin {
+		t.Errorf("Plugin = %q, want %q", diff.Plugin, end.Plugin)
+	}
+}
@@ -1,10 +1,15 @@
 package plugin
 
 import (
+	"encoding/xml"
 	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -15,16 +20,22 @@ type JUnitTestReport struct {
 	Result   string
 	Name     string
 	Message  string
+	// Output is the detailed output of the failure, or the output of the passed test.
+	Output string
+	// Duration is the time taken by the test, reported in the JUnit test suite.
+	Duration time.Duration
 }
 
 // JUnitTestReportTemplate is the template for the JUnit test report.
 var JUnitTestReportTemplate = `
-<testsuite name="opct" tests="1" failures="0" time="0.0">
+<testsuite name="opct" tests="1" skipped="{{ if eq .Result "skipped" }}1{{ else }}0{{ end }}" failures="{{ if eq .Result "failed" }}1{{ else }}0{{ end }}" time="0.0">
 	<testcase name="{{ .Name }}" time="0.0">
 {{- if eq .Result "skipped" }}
 	<skipped message="{{ .Message }}"/>
 {{- else if eq .Result "failed" }}
-	<failure message="{{ .Message }}"/>
+	<failure message="{{ .Message }}">{{ .Output }}</failure>
+{{- else if .Output }}
+	<system-out>{{ .Output }}</system-out>
 {{- end }}
 	</testcase>
 </testsuite>`
@@ -36,6 +47,8 @@ func NewJUnitTestReport(in *JUnitTestReport) *JUnitTestReport {
 		Result:   in.Result,
 		Name:     in.Name,
 		Message:  in.Message,
+		Output:   in.Output,
+		Duration: in.Duration,
 	}
 }
 
@@ -70,3 +83,126 @@ func (j *JUnitTestReport) Write() error {
 	log.Infof("JUnit file created at %s", j.Filepath)
 	return nil
 }
+
+// junitMessage is the skipped or failure element of the JUnit test case.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// junitTestCase is the JUnit test case.
+type junitTestCase struct {
+	XMLName xml.Name      `xml:"testcase"`
+	Name    string        `xml:"name,attr"`
+	Time    string        `xml:"time,attr"`
+	Skipped *junitMessage `xml:"skipped,omitempty"`
+	Failure *junitMessage `xml:"failure,omitempty"`
+	// SystemOut is the output of the passed test.
+	SystemOut string `xml:"system-out,omitempty"`
+}
+
+// junitTestSuite is the JUnit test suite.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// WriteJUnitTestSuite writes the test reports (results skipped, failed or passed)
+// as the test cases of a JUnit test suite.
+func WriteJUnitTestSuite(file, name string, tests []*JUnitTestReport) error {
+	suite := junitTestSuite{Name: name, Tests: len(tests)}
+	total := time.Duration(0)
+	for _, test := range tests {
+		tc := junitTestCase{Name: test.Name, Time: junitTime(test.Duration)}
+		total += test.Duration
+		switch test.Result {
+		case "skipped":
+			tc.Skipped = &junitMessage{Message: test.Message}
+			suite.Skipped++
+		case "failed":
+			output := test.Output
+			if len(output) == 0 {
+				// the failure output is required to report the failure.
+				output = test.Message
+			}
+			tc.Failure = &junitMessage{Message: test.Message, Output: output}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = junitTime(total)
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error rendering JUnit: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating parent directory: %w", err)
+	}
+	if err := os.WriteFile(file, append([]byte(xml.Header), data...), 0644); err != nil {
+		return fmt.Errorf("error writing JUnit: %w", err)
+	}
+	log.Infof("JUnit file created at %s", file)
+	return nil
+}
+
+// reTestSuiteCounter matches the counters of the JUnit test suite element.
+var reTestSuiteCounter = regexp.MustCompile(`\b(tests|skipped|failures)="(\d+)"`)
+
+// MergeJUnitTestCases appends the test cases of the JUnit src into the test suite
+// of dst, updating the suite counters. The content of dst is kept as-is.
+func MergeJUnitTestCases(dst, src string) error {
+	srcData, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	var suite junitTestSuite
+	if err := xml.Unmarshal(srcData, &suite); err != nil {
+		return fmt.Errorf("error parsing JUnit %s: %w", src, err)
+	}
+	if len(suite.TestCases) == 0 {
+		return nil
+	}
+	dstData, err := os.ReadFile(dst)
+	if err != nil {
+		return err
+	}
+	content := string(dstData)
+	start := strings.Index(content, "<testsuite")
+	end := strings.LastIndex(content, "</testsuite>")
+	if start < 0 || end < start {
+		return fmt.Errorf("test suite not found in JUnit %s", dst)
+	}
+	startTagEnd := start + strings.Index(content[start:], ">")
+
+	cases, err := xml.MarshalIndent(suite.TestCases, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("error rendering JUnit: %w", err)
+	}
+	// The counters are computed from the test cases, not trusting the suite counters of src.
+	added := map[string]int{"tests": len(suite.TestCases)}
+	for _, tc := range suite.TestCases {
+		switch {
+		case tc.Skipped != nil:
+			added["skipped"]++
+		case tc.Failure != nil:
+			added["failures"]++
+		}
+	}
+	startTag := reTestSuiteCounter.ReplaceAllStringFunc(content[start:startTagEnd], func(attr string) string {
+		m := reTestSuiteCounter.FindStringSubmatch(attr)
+		n, _ := strconv.Atoi(m[2])
+		return fmt.Sprintf(`%s="%d"`, m[1], n+added[m[1]])
+	})
+	merged := content[:start] + startTag + content[startTagEnd:end] + "  " + string(cases) + "\n" + content[end:]
+	return os.WriteFile(dst, []byte(merged), 0644)
+}
+
+// junitTime returns the duration in seconds, as the time of the JUnit.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.1f", d.Seconds())
+}
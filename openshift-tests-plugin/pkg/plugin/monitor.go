@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/monitor"
+)
+
+// MonitorSummaryName is the name of the summary of the monitor outputs (.json), saved
+// in ResultsDir and suffixed by the shard.
+const MonitorSummaryName = "monitor-summary"
+
+// SummarizeMonitors summarizes the outputs of the openshift-tests monitors written to
+// the JUnit directory: the disruption windows, the alerts firing, the etcd events and
+// the monitor tests, saving the summary to ResultsDir. The summary is not saved when
+// no monitor outputs are found.
+func (p *Plugin) SummarizeMonitors() (*monitor.Summary, error) {
+	ws := p.workspace()
+	summary, err := monitor.Summarize(ws.JUnitDir())
+	if err != nil {
+		return nil, err
+	}
+	summary.Plugin = p.FullName()
+	if p.OTRunner != nil && len(p.OTRunner.Monitortests) > 0 {
+		summary.Monitors = strings.Split(p.OTRunner.Monitortests, ",")
+	}
+	if len(summary.Errors) > 0 {
+		log.Warnf(">> Monitors: unable to read the monitor outputs: %v", summary.Errors)
+	}
+	if summary.Empty() {
+		log.Infof(">> Monitors: no monitor outputs found in %s (monitors=%v)", ws.JUnitDir(), summary.Monitors)
+		return summary, nil
+	}
+	summaryFile := ws.Results(MonitorSummaryName + p.ShardSuffix() + ".json")
+	if err := saveJSON(summaryFile, summary); err != nil {
+		return nil, fmt.Errorf("error saving monitor summary: %w", err)
+	}
+	log.Infof(">> Monitors: %s, saved to %s", summary, summaryFile)
+	for _, d := range summary.Disruptions {
+		fmt.Printf("disruption %s (%s): %d windows, %.1fs\n", d.Backend, d.Connection, d.Windows, d.TotalSeconds)
+	}
+	for _, a := range summary.Alerts {
+		fmt.Printf("alert %s (%s) %s: firing %.1fs\n", a.Name, a.Namespace, a.Severity, a.Seconds)
+	}
+	return summary, nil
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/monitor"
+)
+
+// TestSummarizeMonitors tests the summary of the monitor outputs is saved to the results
+// of the plugin, only when the monitors wrote outputs.
+func TestSummarizeMonitors(t *testing.T) {
+	p, err := NewPlugin(PluginName10)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	ws := NewWorkspace(t.TempDir(), "")
+	if err := ws.Create(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetWorkspace(ws)
+	summaryFile := ws.Results(MonitorSummaryName + ".json")
+
+	if _, err := p.SummarizeMonitors(); err != nil {
+		t.Fatalf("SummarizeMonitors() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(summaryFile); !os.IsNotExist(err) {
+		t.Errorf("monitor summary saved without monitor outputs: %v", err)
+	}
+
+	intervals := `{"items": [{"level": "Error", "source": "Disruption",
+  "locator": {"type": "Disruption", "keys": {"backend-disruption-name": "ingress-to-oauth-server-new-connections", "connection": "new"}},
+  "message": {"reason": "DisruptionBegan", "humanMessage": "backend unreachable"},
+  "from": "2024-07-05T20:30:00Z", "to": "2024-07-05T20:30:03Z"}]}`
+	if err := os.WriteFile(filepath.Join(ws.JUnitDir(), "e2e-intervals_everything_20240705-202749.json"), []byte(intervals), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SummarizeMonitors(); err != nil {
+		t.Fatalf("SummarizeMonitors() unexpected error: %v", err)
+	}
+	summary := monitor.Summary{}
+	data, err := os.ReadFile(summaryFile)
+	if err != nil || json.Unmarshal(data, &summary) != nil {
+		t.Fatalf("monitor summary = %s (%v), want the summary saved", string(data), err)
+	}
+	if summary.Plugin != p.FullName() || len(summary.Monitors) != 1 || summary.Monitors[0] != DefaultOpenShiftTestsRunMonitors ||
+		len(summary.Disruptions) != 1 || summary.Disruptions[0].TotalSeconds != 3 {
+		t.Errorf("monitor summary = %+v, want the disruption of the plugin %s", summary, p.FullName())
+	}
+	dir, err := p.PackageResults(ws.Results("junit_e2e__20240705-202749.xml"))
+	if err != nil {
+		t.Fatalf("PackageResults() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, MonitorSummaryName+".json")); err != nil {
+		t.Errorf("monitor summary not found in the results package: %v", err)
+	}
+}
@@ -4,9 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -102,48 +100,3 @@ func WriteTestSuite(tests map[string]struct{}, outFile string) error {
 	}
 	return nil
 }
-
-// resultLineParser is a struct to parse the results from the tests.
-type resultLineParser struct {
-	ParserName string
-	TestName   string
-	TimeTook   string
-	Endat      string
-}
-
-// ExtractTestTimeFromLine parse line and extract information from it.
-func (res *resultLineParser) ExtractTestTimeFromLine(line string) error {
-
-	reInfo := regexp.MustCompile(fmt.Sprintf(`^%s(\:|)\s\((?P<Time>.*)\)(\s\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}|)\s(?P<TestName>.*)`, res.ParserName))
-	matchInfo := reInfo.FindStringSubmatch(line)
-	if len(matchInfo) < 3 {
-		return fmt.Errorf("%s parser: unexpected results: matchCount(%d): %v", res.ParserName, len(matchInfo), matchInfo)
-	}
-	reTS := regexp.MustCompile(fmt.Sprintf(`^%s(\:|)\s\(.*\)(?P<Timestamp>\s\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})\s.*`, res.ParserName))
-	matchTS := reTS.FindStringSubmatch(line)
-	if len(matchTS) < 1 {
-		log.Debugf("%s parser: unable to extract timestamp from line: %v", res.ParserName, line)
-	} else {
-		res.Endat = matchTS[1]
-	}
-	res.TestName = matchInfo[3]
-	res.TimeTook = matchInfo[1]
-
-	return nil
-}
-
-// CalculateFields parse line and extract information from it.
-func (res *resultLineParser) CalculateFields(tests map[string]*TestProgress) error {
-	if _, ok := tests[res.TestName]; !ok {
-		log.Errorf("%s parser: test not yet created, creating: %v", res.ParserName, res.TestName)
-		tests[res.TestName] = &TestProgress{}
-	}
-
-	tests[res.TestName].Result = res.ParserName
-	tests[res.TestName].EndAt = res.TimeTook
-	tests[res.TestName].TimeTook = res.TimeTook
-	d, _ := time.ParseDuration(tests[res.TestName].TimeTook)
-	tests[res.TestName].TimeTookSeconds = d.Seconds()
-
-	return nil
-}
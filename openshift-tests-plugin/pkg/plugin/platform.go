@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	configv1 "github.com/openshift/api/config/v1"
+	occlient "github.com/openshift/client-go/config/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// EnvOpenShiftTestsProvider overrides the openshift-tests --provider discovered
+	// from the cluster. The value is passed as-is, example: '{"type":"aws","region":"us-east-1"}'.
+	EnvOpenShiftTestsProvider = "OPENSHIFT_TESTS_PROVIDER"
+
+	// ProviderTypeNone is the provider of platforms not supported by the e2e providers of
+	// openshift-tests, which discovers the cluster when --provider is not set.
+	ProviderTypeNone = "none"
+)
+
+// providerTypes maps the platform type to the e2e provider type registered by
+// openshift-tests. The other platforms are ProviderTypeNone.
+var providerTypes = map[configv1.PlatformType]string{
+	configv1.AWSPlatformType:       "aws",
+	configv1.AzurePlatformType:     "azure",
+	configv1.GCPPlatformType:       "gce",
+	configv1.VSpherePlatformType:   "vsphere",
+	configv1.OpenStackPlatformType: "openstack",
+}
+
+// TestProvider is the cloud provider configuration of openshift-tests (--provider).
+type TestProvider struct {
+	Type          string   `json:"type"`
+	ProjectID     string   `json:"projectid,omitempty"`
+	Region        string   `json:"region,omitempty"`
+	Zone          string   `json:"zone,omitempty"`
+	Zones         []string `json:"zones,omitempty"`
+	MultiZone     bool     `json:"multizone"`
+	MultiMaster   bool     `json:"multimaster"`
+	NetworkPlugin string   `json:"networkPlugin,omitempty"`
+	// Disconnected skips the tests requiring internet egress in openshift-tests.
+	Disconnected bool `json:"disconnected,omitempty"`
+}
+
+// String returns the provider as JSON, the format of openshift-tests --provider.
+func (tp *TestProvider) String() string {
+	// json.Marshal does not fail for the provider fields.
+	data, _ := json.Marshal(tp)
+	return string(data)
+}
+
+// DiscoverTestProvider builds the openshift-tests provider from the Infrastructure
+// and Network config resources, and from the topology labels of the nodes.
+func DiscoverTestProvider(ctx context.Context, kclient kubernetes.Interface, oclient occlient.Interface) (*TestProvider, error) {
+	infra, err := oclient.ConfigV1().Infrastructures().Get(ctx, "cluster", kmmetav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading infrastructure: %w", err)
+	}
+	tp := &TestProvider{
+		Type:        ProviderTypeNone,
+		MultiMaster: infra.Status.ControlPlaneTopology != configv1.SingleReplicaTopologyMode,
+	}
+	if ps := infra.Status.PlatformStatus; ps != nil {
+		if providerType, ok := providerTypes[ps.Type]; ok {
+			tp.Type = providerType
+		}
+		switch {
+		case ps.AWS != nil:
+			tp.Region = ps.AWS.Region
+		case ps.GCP != nil:
+			tp.Region = ps.GCP.Region
+			tp.ProjectID = ps.GCP.ProjectID
+		}
+	}
+
+	if network, err := oclient.ConfigV1().Networks().Get(ctx, "cluster", kmmetav1.GetOptions{}); err != nil {
+		log.Warnf("unable to discover the network plugin: %v", err)
+	} else if len(network.Status.NetworkType) > 0 {
+		tp.NetworkPlugin = network.Status.NetworkType
+	} else {
+		tp.NetworkPlugin = network.Spec.NetworkType
+	}
+
+	nodes, err := kclient.CoreV1().Nodes().List(ctx, kmmetav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+	zones := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		if zone := node.Labels[kcorev1.LabelTopologyZone]; len(zone) > 0 {
+			zones[zone] = struct{}{}
+		}
+		if region := node.Labels[kcorev1.LabelTopologyRegion]; len(tp.Region) == 0 && len(region) > 0 {
+			tp.Region = region
+		}
+	}
+	for zone := range zones {
+		tp.Zones = append(tp.Zones, zone)
+	}
+	sort.Strings(tp.Zones)
+	if len(tp.Zones) > 0 {
+		tp.Zone = tp.Zones[0]
+	}
+	tp.MultiZone = len(tp.Zones) > 1
+
+	return tp, nil
+}
+
+// initProvider sets the openshift-tests --provider, from the env var
+// OPENSHIFT_TESTS_PROVIDER when set, or discovered from the cluster. The provider is
+// not set for the platforms without e2e provider (ProviderTypeNone).
+func (p *Plugin) initProvider() {
+	if p.OTRunner == nil {
+		return
+	}
+	if provider := p.settings().Runner.Provider; len(provider) > 0 {
+		log.Infof("Using openshift-tests provider from %s: %s", EnvOpenShiftTestsProvider, provider)
+		p.OTRunner.Provider = provider
+		return
+	}
+	if p.clientKube == nil || p.clientConfig == nil {
+		log.Warn("Unable to discover the openshift-tests provider: kube clients not initialized")
+		return
+	}
+	tp, err := DiscoverTestProvider(context.TODO(), p.clientKube, p.clientConfig)
+	if err != nil {
+		log.Warnf("Unable to discover the openshift-tests provider: %v", err)
+		return
+	}
+	if tp.Type == ProviderTypeNone {
+		log.Infof("Platform without openshift-tests provider, the provider is discovered by openshift-tests")
+		return
+	}
+	tp.Disconnected = p.Disconnected
+	log.Infof("Discovered openshift-tests provider: %s", tp)
+	p.OTRunner.Provider = tp.String()
+}
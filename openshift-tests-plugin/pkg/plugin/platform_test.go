@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	ocfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testNode(name, region, zone string) *kcorev1.Node {
+	labels := map[string]string{}
+	if len(region) > 0 {
+		labels[kcorev1.LabelTopologyRegion] = region
+	}
+	if len(zone) > 0 {
+		labels[kcorev1.LabelTopologyZone] = zone
+	}
+	return &kcorev1.Node{ObjectMeta: kmmetav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestDiscoverTestProvider(t *testing.T) {
+	network := &configv1.Network{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.NetworkStatus{NetworkType: "OVNKubernetes"},
+	}
+	multiZone := []runtime.Object{
+		testNode("node-a", "region-1", "zone-a"),
+		testNode("node-b", "region-1", "zone-b"),
+		testNode("node-c", "region-1", "zone-a"),
+	}
+	tests := []struct {
+		name     string
+		status   configv1.InfrastructureStatus
+		nodes    []runtime.Object
+		expected string
+	}{
+		{
+			name: "AWS",
+			status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.AWSPlatformType, AWS: &configv1.AWSPlatformStatus{Region: "us-east-1"},
+			}},
+			nodes:    []runtime.Object{testNode("node-a", "us-east-1", "us-east-1a"), testNode("node-b", "us-east-1", "us-east-1b")},
+			expected: `{"type":"aws","region":"us-east-1","zone":"us-east-1a","zones":["us-east-1a","us-east-1b"],"multizone":true,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name: "Azure",
+			status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.AzurePlatformType, Azure: &configv1.AzurePlatformStatus{ResourceGroupName: "rg"},
+			}},
+			nodes:    []runtime.Object{testNode("node-a", "eastus", "eastus-1"), testNode("node-b", "eastus", "eastus-2")},
+			expected: `{"type":"azure","region":"eastus","zone":"eastus-1","zones":["eastus-1","eastus-2"],"multizone":true,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name: "GCP",
+			status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.GCPPlatformType, GCP: &configv1.GCPPlatformStatus{ProjectID: "project", Region: "us-central1"},
+			}},
+			nodes:    []runtime.Object{testNode("node-a", "us-central1", "us-central1-a")},
+			expected: `{"type":"gce","projectid":"project","region":"us-central1","zone":"us-central1-a","zones":["us-central1-a"],"multizone":false,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name: "vSphere",
+			status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.VSpherePlatformType, VSphere: &configv1.VSpherePlatformStatus{},
+			}},
+			nodes:    multiZone,
+			expected: `{"type":"vsphere","region":"region-1","zone":"zone-a","zones":["zone-a","zone-b"],"multizone":true,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name: "OpenStack",
+			status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.OpenStackPlatformType, OpenStack: &configv1.OpenStackPlatformStatus{},
+			}},
+			nodes:    []runtime.Object{testNode("node-a", "regionOne", "nova")},
+			expected: `{"type":"openstack","region":"regionOne","zone":"nova","zones":["nova"],"multizone":false,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name: "Nutanix",
+			status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.NutanixPlatformType, Nutanix: &configv1.NutanixPlatformStatus{},
+			}},
+			nodes:    multiZone,
+			expected: `{"type":"none","region":"region-1","zone":"zone-a","zones":["zone-a","zone-b"],"multizone":true,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name: "BareMetal single node",
+			status: configv1.InfrastructureStatus{
+				ControlPlaneTopology: configv1.SingleReplicaTopologyMode,
+				PlatformStatus:       &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType, BareMetal: &configv1.BareMetalPlatformStatus{}},
+			},
+			nodes:    []runtime.Object{testNode("node-a", "", "")},
+			expected: `{"type":"none","multizone":false,"multimaster":false,"networkPlugin":"OVNKubernetes"}`,
+		},
+		{
+			name:     "None",
+			status:   configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.NonePlatformType}},
+			nodes:    []runtime.Object{testNode("node-a", "", "")},
+			expected: `{"type":"none","multizone":false,"multimaster":true,"networkPlugin":"OVNKubernetes"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infra := &configv1.Infrastructure{ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"}, Status: tt.status}
+			tp, err := DiscoverTestProvider(context.Background(), kfake.NewSimpleClientset(tt.nodes...), ocfake.NewSimpleClientset(infra, network))
+			if err != nil {
+				t.Fatalf("DiscoverTestProvider() unexpected error: %v", err)
+			}
+			if got := tp.String(); got != tt.expected {
+				t.Errorf("DiscoverTestProvider() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := DiscoverTestProvider(context.Background(), kfake.NewSimpleClientset(), ocfake.NewSimpleClientset()); err == nil {
+		t.Errorf("DiscoverTestProvider() must fail without infrastructure")
+	}
+}
+
+func TestInitProvider(t *testing.T) {
+	infra := &configv1.Infrastructure{
+		ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.NonePlatformType}},
+	}
+	p := &Plugin{
+		OTRunner:     NewOpenShiftRunCommand("run", PluginSuite20),
+		clientKube:   kfake.NewSimpleClientset(),
+		clientConfig: ocfake.NewSimpleClientset(infra),
+	}
+	p.initProvider()
+	if p.OTRunner.Provider != "" {
+		t.Errorf("Provider = %s, want not set for platform None", p.OTRunner.Provider)
+	}
+
+	infra.Status.PlatformStatus = &configv1.PlatformStatus{Type: configv1.AWSPlatformType, AWS: &configv1.AWSPlatformStatus{Region: "us-east-1"}}
+	p.clientConfig = ocfake.NewSimpleClientset(infra)
+	p.initProvider()
+	if want := `{"type":"aws","region":"us-east-1","multizone":false,"multimaster":true}`; p.OTRunner.Provider != want {
+		t.Errorf("Provider = %s, want %s", p.OTRunner.Provider, want)
+	}
+
+	t.Setenv(EnvOpenShiftTestsProvider, `{"type":"aws","region":"us-west-2"}`)
+	p.initProvider()
+	if want := `{"type":"aws","region":"us-west-2"}`; p.OTRunner.Provider != want {
+		t.Errorf("Provider = %s, want env override %s", p.OTRunner.Provider, want)
+	}
+}
@@ -10,11 +10,15 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
-	"strconv"
+	"regexp"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/analysis"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/matrix"
 	log "github.com/sirupsen/logrus"
 
 	occlient "github.com/openshift/client-go/config/clientset/versioned"
@@ -43,6 +47,18 @@ const (
 	OpenShiftTestsSuiteList = "/tmp/shared/suite.list"
 	OTestsSuiteListComplete = "/tmp/shared/suite.list.done"
 
+	// OpenShiftTestsDoneRecord is the structured done record (RunnerStatus) written
+	// by the run script when openshift-tests finishes.
+	OpenShiftTestsDoneRecord = "/tmp/shared/done.json"
+	// OpenShiftTestsStderrLog holds the openshift-tests stderr in the run script.
+	OpenShiftTestsStderrLog = "/tmp/shared/stderr.log"
+	// OpenShiftTestsShardFile holds the shard suffix of the plugin outputs (empty when
+	// not sharded), read by the tests container to name the uploaded artifacts.
+	OpenShiftTestsShardFile = "/tmp/shared/shard"
+	// SharedPluginBinary is the plugin binary shared with the tests container,
+	// used to upload the artifacts to the collector (exec upload-artifact).
+	SharedPluginBinary = "/tmp/shared/openshift-tests-plugin"
+
 	DefaultOpenShiftTestsRunMonitors    = "etcd-log-analyzer"
 	DefaultOpenShiftTestsRunMaxParallel = "0"
 
@@ -51,6 +67,38 @@ const (
 	// WaitThresholdLimit defines the limit to wait for done file. Default 4h.
 	WaitThresholdLimit = 14400
 
+	// BlockerStallTimeout defines the window, in seconds, the blocker plugin can run
+	// without progressing the completed counter before it is considered stalled. Default 1h.
+	BlockerStallTimeout = 3600
+	// BlockerPollInterval defines the interval, in seconds, the dependency waiter
+	// checks the status of the blocker plugin.
+	BlockerPollInterval = 10
+	// BlockerStallLogTailLines defines the number of log lines collected from each
+	// container of a stalled blocker plugin.
+	BlockerStallLogTailLines = 100
+
+	// BlockerStallPolicy* defines the action taken by the dependency waiter when the
+	// blocker plugin is stalled.
+	BlockerStallPolicyWait     = "wait"
+	BlockerStallPolicyFail     = "fail"
+	BlockerStallPolicyContinue = "continue"
+
+	// BlockerFailurePolicy* defines how the failure of the blocker plugin is
+	// propagated to the dependent plugin.
+	// fail-fast stops the dependent plugin with error.
+	// continue runs the dependent plugin recording the upstream failure in JUnit.
+	// skip-with-junit skips the dependent plugin execution recording the reason in JUnit.
+	BlockerFailurePolicyFailFast      = "fail-fast"
+	BlockerFailurePolicyContinue      = "continue"
+	BlockerFailurePolicySkipWithJUnit = "skip-with-junit"
+
+	// BlockerFailedJUnit reports the failure of the blocker plugin when the dependent
+	// plugin runs by the policy continue, merged into the results JUnit.
+	BlockerFailedJUnit = "junit_e2e_blocker_failed.xml"
+	// BlockerStalledJUnit reports the stalled blocker plugin with the logs of the blocker
+	// pod, merged into the results JUnit.
+	BlockerStalledJUnit = "junit_e2e_blocker_stalled.xml"
+
 	KubeApiServerInternal = "https://kubernetes.default.svc:443"
 	KubeApiServerSACertCA = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
 	KubeApiServerSAToken  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
@@ -100,37 +148,107 @@ type Plugin struct {
 
 	Namespace string
 
+	// Config is the plugin configuration loaded from the config file, the environment
+	// and the flags.
+	Config *config.Config
+	// Workspace holds the paths shared with the tests container and the sonobuoy worker.
+	Workspace *Workspace
+
 	// Runtime
 	clientKube     kubernetes.Interface
 	clientSonobuoy sbclient.Interface
+	clientConfig   occlient.Interface
 	DoneChan       chan bool
 	DoneControl    bool
 
 	// OTRunner is the test runner command to schedule openshift-tests run.
 	OTRunner *OpenShiftTestsRunCommand
+	// Runner is the test runner executed by the plugin. Default: OTRunner
+	Runner Runner
 
 	// ExecMode is the execution mode for the workflow. Default: default
 	// Valid values: default, upgrade
 	ExecMode string
+
+	// RunnerMode is how openshift-tests is executed. Default: shared-volume
+	// Valid values: shared-volume, subprocess
+	RunnerMode string
+
+	// BlockerStallTimeout is the window the blocker plugin can run without progress
+	// before it is considered stalled by the dependency waiter.
+	BlockerStallTimeout time.Duration
+	// BlockerPollInterval is the interval the dependency waiter checks the blocker plugin.
+	BlockerPollInterval time.Duration
+	// BlockerStallPolicy is the action taken when the blocker plugin is stalled.
+	// Valid values: wait, fail, continue. Default: wait
+	BlockerStallPolicy string
+	// BlockerFailurePolicy is the action taken when the blocker plugin has failed.
+	// Valid values: fail-fast, continue, skip-with-junit. Default: fail-fast
+	BlockerFailurePolicy string
+
+	// ShardIndex is the 0-based index of the suite shard executed by the plugin.
+	ShardIndex int
+	// ShardCount is the total of shards the suite is partitioned. Sharding is
+	// disabled when the count is lower than 2.
+	ShardCount int
+	// ShardDurationsFile is the JSON file with historical test durations used to
+	// balance the shards.
+	ShardDurationsFile string
+
+	// Disconnected is set when the cluster runs in a restricted network, using
+	// the mirror repository (MIRROR_IMAGE_REPOSITORY).
+	Disconnected bool
+	// EgressPolicy is the result reported to the tests requiring internet egress,
+	// removed from the suite in disconnected mode. Valid values: skip, fail. Default: skip
+	EgressPolicy string
+	// EgressTestPatterns matches the tests requiring internet egress.
+	EgressTestPatterns []*regexp.Regexp
+
+	// VersionMatrix holds the plugin settings by OpenShift version, and VersionConfig
+	// the settings resolved for the cluster version.
+	VersionMatrix *matrix.Matrix
+	VersionConfig *matrix.Config
+	// ExcludedTestPatterns matches the tests removed from the suite by the version matrix.
+	ExcludedTestPatterns []*regexp.Regexp
+
+	// FailureAnalysis is the failures clustered by signature, set by the JUnit processor.
+	FailureAnalysis *analysis.Report
+	// ResultsDigest is the digest (SHA-256) of the results manifest, set by PackageResults.
+	ResultsDigest string
+
+	// skipReason is the reason to skip the openshift-tests execution, set when
+	// the plugin must finish without running tests.
+	skipReason string
+	// discoveryFailed is set when the tests container could not discover the suite,
+	// reported in the JUnit by the suite discovery.
+	discoveryFailed bool
 }
 
 // NewPlugin creates a new plugin service.
 func NewPlugin(name string) (*Plugin, error) {
 	p := &Plugin{
 		name:        name,
+		Config:      config.Current(),
 		Namespace:   EnvNamespace,
 		SuiteFile:   fmt.Sprintf("%s/suite.list", SharedDir),
 		Progress:    NewPluginProgress(),
 		DoneChan:    make(chan bool),
 		DoneControl: false,
 		ExecMode:    ExecModeDefault,
+		RunnerMode:  RunnerModeSharedVolume,
+
+		BlockerStallTimeout: BlockerStallTimeout * time.Second,
+		BlockerPollInterval: BlockerPollInterval * time.Second,
+		BlockerStallPolicy:  BlockerStallPolicyWait,
+
+		BlockerFailurePolicy: BlockerFailurePolicyFailFast,
+		EgressPolicy:         DisconnectedEgressPolicySkip,
 	}
 	switch p.name {
 	case PluginName05, PluginAlias05:
 		p.id = PluginId05
 		p.SuiteName = PluginSuite05
 		p.OTRunner = NewOpenShiftRunCommand("run-upgrade", p.SuiteName)
-		p.Timeout = 3 * time.Hour
 	case PluginName10, PluginAlias10:
 		p.id = PluginId10
 		p.SuiteName = PluginSuite10
@@ -139,32 +257,84 @@ func NewPlugin(name string) (*Plugin, error) {
 		}
 		p.BlockerPlugins = []*Plugin{{name: PluginName05}}
 		p.OTRunner = NewOpenShiftRunCommand("run", p.SuiteName)
-		p.Timeout = 2 * time.Hour
 	case PluginName20, PluginAlias20:
 		p.id = PluginId20
 		p.SuiteName = PluginSuite20
 		p.BlockerPlugins = []*Plugin{{name: PluginName10}}
 		p.OTRunner = NewOpenShiftRunCommand("run", p.SuiteName)
-		p.Timeout = 4 * time.Hour
 	case PluginName80, PluginAlias80:
 		p.id = PluginId80
 		p.SuiteName = PluginSuite80
 		p.BlockerPlugins = []*Plugin{{name: PluginName20}}
 		p.OTRunner = NewOpenShiftRunCommand("run", p.SuiteName)
 		p.OTRunner.File = p.SuiteFile
-		p.OTRunner.MaxParallel = "1"
-		p.Timeout = 1 * time.Hour
 	case PluginName99, PluginAlias99:
 		p.id = PluginId99
 		p.SuiteName = PluginSuite99
 		p.BlockerPlugins = []*Plugin{{name: PluginName80}}
-		p.Timeout = 1 * time.Hour
+		p.BlockerFailurePolicy = BlockerFailurePolicyContinue
 	default:
 		return nil, fmt.Errorf("unknown plugin name %q", name)
 	}
+	if p.OTRunner != nil {
+		p.Runner = p.OTRunner
+	}
+	p.SetWorkspace(workspaceFromConfig(p.Config))
+	// The settings of the version matrix are resolved for the cluster version when
+	// the plugin is initialized.
+	var err error
+	if p.VersionMatrix, err = matrix.LoadMatrix(p.Config.VersionMatrixFile); err != nil {
+		return nil, fmt.Errorf("unable to load the version matrix: %w", err)
+	}
+	p.ResolveVersionConfig("")
 	return p, nil
 }
 
+// settings returns the plugin configuration, or the configuration loaded from the
+// environment when the plugin is not created by NewPlugin.
+func (p *Plugin) settings() *config.Config {
+	if p.Config != nil {
+		return p.Config
+	}
+	return config.Current()
+}
+
+// SetWorkspace sets the paths of the plugin and the runner in the workspace.
+func (p *Plugin) SetWorkspace(ws *Workspace) {
+	suiteFile := p.SuiteFile
+	p.Workspace = ws
+	p.SuiteFile = ws.SuiteList()
+	p.Progress.SetURL(ws.ProgressURL)
+	if p.OTRunner == nil {
+		return
+	}
+	p.OTRunner.JUnitDir = ws.JUnitDir()
+	p.OTRunner.FiFoPath = ws.FiFo()
+	p.OTRunner.DoneRecordPath = ws.DoneRecord()
+	p.OTRunner.StderrPath = ws.StderrLog()
+	p.OTRunner.RunFile = ws.RunFile()
+	if len(p.OTRunner.File) > 0 && p.OTRunner.File == suiteFile {
+		p.OTRunner.File = p.SuiteFile
+	}
+}
+
+// workspace returns the plugin workspace, or the default workspace when the plugin
+// is not created by NewPlugin.
+func (p *Plugin) workspace() *Workspace {
+	if p.Workspace != nil {
+		return p.Workspace
+	}
+	return DefaultWorkspace()
+}
+
+// SetClients sets the clients of the cluster, created from the kubeconfig by
+// Initialize when not set.
+func (p *Plugin) SetClients(kube kubernetes.Interface, sonobuoy sbclient.Interface, oc occlient.Interface) {
+	p.clientKube = kube
+	p.clientSonobuoy = sonobuoy
+	p.clientConfig = oc
+}
+
 // FullName returns the full name of the plugin.
 func (p *Plugin) FullName() string {
 	return fmt.Sprintf("%s-%s", p.id, p.name)
@@ -192,6 +362,8 @@ func (p *Plugin) PluginFullNameByName(name string) string {
 		id = PluginId20
 	case PluginName80:
 		id = PluginId80
+	case PluginName99:
+		id = PluginId99
 	}
 	return fmt.Sprintf("%s-%s", id, name)
 }
@@ -201,7 +373,7 @@ func (p *Plugin) getSuiteName(id string) string {
 	switch id {
 	case PluginId10:
 		// Try to get from DEFAULT_SUITE_NAME, otherwise set const
-		suiteName := os.Getenv("DEFAULT_SUITE_NAME")
+		suiteName := p.settings().DefaultSuiteName
 		if suiteName == "" {
 			return PluginSuite10
 		}
@@ -215,99 +387,124 @@ func (p *Plugin) Initialize() error {
 	// TODO send a message to aggregator indicating for "initialization" state.
 	// The following message is sent by script version: "status=initializing"
 	// Create work/result dir
-	if err := os.MkdirAll(ResultsDir, os.ModePerm); err != nil {
-		log.Errorf("error creating result directory %s: %v", ResultsDir, err)
+	ws := p.workspace()
+	if err := os.MkdirAll(ws.ResultsDir, os.ModePerm); err != nil {
+		log.Errorf("error creating result directory %s: %v", ws.ResultsDir, err)
 	}
 
 	// Create FIFO
-	if err := syscall.Mknod(FiFoPath, syscall.S_IFIFO|0666, 0); err != nil {
-		return fmt.Errorf("error creating FIFO on path %s: %v", FiFoPath, err)
+	if err := syscall.Mknod(ws.FiFo(), syscall.S_IFIFO|0666, 0); err != nil {
+		return fmt.Errorf("error creating FIFO on path %s: %v", ws.FiFo(), err)
 	}
 
-	// Initialize kube and sonobuoy clients
-	kcli, sbcli, err := CreateClients()
-	if err != nil {
-		log.Errorf("error initializing the clients: %v", err)
-		return nil
+	// Initialize kube and sonobuoy clients, when not set (example: sandbox mode)
+	var err error
+	if p.clientKube == nil {
+		kcli, sbcli, err := CreateClients()
+		if err != nil {
+			log.Errorf("error initializing the clients: %v", err)
+			return nil
+		}
+		p.clientKube = kcli
+		p.clientSonobuoy = sbcli
+	}
+	if p.clientConfig == nil {
+		if p.clientConfig, err = CreateConfigClient(); err != nil {
+			log.Errorf("error initializing the config client: %v", err)
+		}
+	}
+	if err := p.SnapshotInventory(InventoryStageStart); err != nil {
+		log.Errorf("unable to snapshot the cluster inventory: %v", err)
 	}
-	p.clientKube = kcli
-	p.clientSonobuoy = sbcli
+	p.initVersionConfig()
 
-	// Set top level env vars from config
-	// TODO move env vars discovery to a separate function
+	// Set top level settings from config
+	cfg := p.settings()
 
 	// MIRROR_IMAGE_REPOSITORY is used in disconnected/mirrored environments
-	envMirror := os.Getenv("MIRROR_IMAGE_REPOSITORY")
-	if len(envMirror) > 0 {
+	if len(cfg.MirrorImageRepository) > 0 {
 		if p.OTRunner != nil {
-			p.OTRunner.FromRepository = envMirror
+			p.OTRunner.FromRepository = cfg.MirrorImageRepository
 		}
 	}
 
 	// UPGRADE_RELEASES is used to upgrade the cluster
-	envRunMode := os.Getenv("RUN_MODE")
-	if len(envRunMode) > 0 {
-		switch envRunMode {
-		case ExecModeUpgrade:
-			p.ExecMode = ExecModeUpgrade
-		case "normal":
-			p.ExecMode = ExecModeDefault
-		default:
-			p.ExecMode = ExecModeDefault
-		}
+	switch cfg.RunMode {
+	case "":
+	case ExecModeUpgrade:
+		p.ExecMode = ExecModeUpgrade
+	default:
+		p.ExecMode = ExecModeDefault
 	}
-	envUpgradeRelease := os.Getenv("UPGRADE_RELEASES")
-	if p.ExecMode == ExecModeUpgrade && len(envUpgradeRelease) > 0 {
+	if p.ExecMode == ExecModeUpgrade && len(cfg.UpgradeReleases) > 0 {
 		if p.OTRunner != nil {
-			p.OTRunner.ToImage = envUpgradeRelease
+			p.OTRunner.ToImage = cfg.UpgradeReleases
 		}
 	}
 
+	p.initBlockerConfig()
+	p.initShardConfig()
+	p.initRunner()
+	p.initRunnerMode()
+	p.initDisconnected()
+	p.initProvider()
+
 	if p.id == PluginId99 {
-		// TODO extract the results
+		// The artifacts are collected by the collector workflow (exec collector).
 		return nil
 	}
 
+	if err := shareBinary(ws.PluginBinary()); err != nil {
+		log.Errorf("unable to share the plugin binary, the artifacts will not be uploaded to the collector: %v", err)
+	}
+
 	// Wait for suite list complete
 	doneCallback := func() {
-		log.Infof("Done callback for %s", OTestsSuiteListComplete)
+		log.Infof("Done callback for %s", ws.SuiteListDone())
 	}
-	log.Infof("Waiting for suite list complete %s", OTestsSuiteListComplete)
-	if err := watchForFile(OTestsSuiteListComplete, doneCallback); err != nil {
-		log.Errorf("unable to watch done suite list on %s: %v", OTestsSuiteListComplete, err)
+	log.Infof("Waiting for suite list complete %s", ws.SuiteListDone())
+	if err := watchForFile(ws.SuiteListDone(), doneCallback); err != nil {
+		log.Errorf("unable to watch done suite list on %s: %v", ws.SuiteListDone(), err)
 	}
 
-	log.Infof("Loading total test count from %s", OpenShiftTestsSuiteList)
-	p.SuiteTests, err = ParseSuiteList(OpenShiftTestsSuiteList)
+	log.Infof("Loading total test count from %s", ws.SuiteList())
+	p.SuiteTests, err = ParseSuiteList(ws.SuiteList())
 	if err != nil {
-		log.Errorf("unable to load suite list from %s: %v", OpenShiftTestsSuiteList, err)
+		log.Errorf("unable to load suite list from %s: %v", ws.SuiteList(), err)
 	}
 	log.Infof("Total test count: %d", len(p.SuiteTests))
 
-	// For kube-conformance plugin (10) on OCP 4.20+, use the extracted conformance
-	// test list to run only conformance tests via --file flag. On 4.20+ the suite
-	// kubernetes/conformance was removed from openshift-tests, requiring extraction
-	// On 4.20+, conformance tests are extracted from k8s-tests-ext and run via --file.
-	// The suite name is preserved from DEFAULT_SUITE_NAME (kubernetes/conformance/parallel)
-	// so openshift-tests filters tests consistently with the suite definition.
-	// On pre-4.20, DEFAULT_SUITE_NAME is "kubernetes/conformance" which works directly.
-	if p.id == PluginId10 {
-		suiteName := os.Getenv("DEFAULT_SUITE_NAME")
-		if suiteName != "" && suiteName != "kubernetes/conformance" {
-			k8sConformanceList := "/tmp/shared/k8s-conformance-tests.list"
-			if info, err := os.Stat(k8sConformanceList); err == nil && info.Size() > 0 {
-				log.Infof("Setting run file for plugin %s using extracted conformance list %s", p.name, k8sConformanceList)
-				p.OTRunner.File = k8sConformanceList
-				p.OTRunner.SuiteName = suiteName
-			}
-		}
+	// The suite is discovered by the tests container for the OpenShift version. On 4.20+
+	// the Kubernetes conformance tests are listed by the extension (k8s-tests-ext), and
+	// openshift-tests runs the suite with the test list (--file).
+	p.applySuiteDiscovery(ws.Shared(filepath.Base(SuiteDiscoveryResult)))
+
+	if err := p.FilterExcludedTests(); err != nil {
+		return fmt.Errorf("unable to filter the tests excluded by the version matrix: %w", err)
 	}
 
 	if err := p.InitalizeDevelMode(); err != nil {
 		log.Errorf("error setting up devel mode: %v", err)
 	}
 
-	p.Progress.Set(&PluginProgress{TotalCount: ptr.To(int64(len(p.SuiteTests)))})
+	if p.Disconnected {
+		if _, err := p.ValidateMirror(); err != nil {
+			log.Errorf("unable to validate the mirror: %v", err)
+		}
+		if err := p.FilterEgressTests(); err != nil {
+			return fmt.Errorf("unable to filter the tests requiring internet egress: %w", err)
+		}
+	}
+
+	if err := p.InitializeShard(); err != nil {
+		return fmt.Errorf("unable to initialize shard: %w", err)
+	}
+
+	if p.runsSuiteList() {
+		p.Progress.Set(&PluginProgress{TotalCount: ptr.To(int64(len(p.SuiteTests)))})
+	} else {
+		log.Infof("Total test count is reported by the runner %s", p.Runner.Name())
+	}
 
 	// TODO(mtulio) - upgrade only: check MachineConfigPool opct exists.
 	// Q(^) should we validate in CLI only or create (pre) and delete(post) in plugin?
@@ -315,6 +512,35 @@ func (p *Plugin) Initialize() error {
 	return nil
 }
 
+// initBlockerConfig sets the blocker plugin handling from environment.
+// BLOCKER_STALL_TIMEOUT is the duration (example: 90m) without progress to mark the
+// blocker as stalled, 0 disables the detection. BLOCKER_STALL_POLICY is the action
+// taken when the blocker is stalled: wait, fail or continue.
+// BLOCKER_FAILURE_POLICY is the action taken when the blocker has failed:
+// fail-fast, continue or skip-with-junit.
+func (p *Plugin) initBlockerConfig() {
+	cfg := p.settings().Blocker
+	if cfg.StallTimeout != nil {
+		p.BlockerStallTimeout = cfg.StallTimeout.Duration
+	}
+	if envPolicy := cfg.StallPolicy; len(envPolicy) > 0 {
+		switch envPolicy {
+		case BlockerStallPolicyWait, BlockerStallPolicyFail, BlockerStallPolicyContinue:
+			p.BlockerStallPolicy = envPolicy
+		default:
+			log.Errorf("invalid BLOCKER_STALL_POLICY %q, using default %q", envPolicy, p.BlockerStallPolicy)
+		}
+	}
+	if envPolicy := cfg.FailurePolicy; len(envPolicy) > 0 {
+		switch envPolicy {
+		case BlockerFailurePolicyFailFast, BlockerFailurePolicyContinue, BlockerFailurePolicySkipWithJUnit:
+			p.BlockerFailurePolicy = envPolicy
+		default:
+			log.Errorf("invalid BLOCKER_FAILURE_POLICY %q, using default %q", envPolicy, p.BlockerFailurePolicy)
+		}
+	}
+}
+
 // ExtractTestsToReplay loads the suite list from the ConfigMap and save to the suite file.
 func (p *Plugin) ExtractTestsToReplay() error {
 	// Explicity exclude some tests from the replay list.
@@ -333,45 +559,40 @@ func (p *Plugin) ExtractTestsToReplay() error {
 	foundConfig := false
 	tests := map[string]struct{}{}
 
-	// Consume config map created by each plugin with it's failures.
-	cmName := "plugin-failures-10"
-	cm10, err := p.clientKube.CoreV1().ConfigMaps(p.Namespace).Get(context.TODO(), cmName, kmmetav1.GetOptions{})
+	// Consume config map created by each plugin with it's failures, selected by the
+	// failures label. Sharded plugins create one config map by shard.
+	selector := fmt.Sprintf("%s in (%s,%s)", FailuresLabel, PluginId10, PluginId20)
+	cmList, err := p.clientKube.CoreV1().ConfigMaps(p.Namespace).List(context.TODO(), kmmetav1.ListOptions{LabelSelector: selector})
 	if err != nil {
-		log.Errorf("unable to retrieve ConfigMap %s: %v", cmName, err)
+		log.Errorf("unable to list ConfigMaps %s in namespace %s: %v", selector, p.Namespace, err)
+		cmList = &kcorev1.ConfigMapList{}
 	}
-	if err == nil {
-		suiteListData := cm10.Data["replay.list"]
-		for _, line := range strings.Split(suiteListData, "\n") {
-			if isInExcludeList(line) {
+	for _, id := range []string{PluginId10, PluginId20} {
+		found := false
+		for _, cm := range cmList.Items {
+			if cm.Labels[FailuresLabel] != id {
 				continue
 			}
-			foundConfig = true
-			tests[line] = struct{}{}
-		}
-		log.Infof("Total failed tests to replay after processing plugin %s: %d", cmName, len(tests))
-	}
-
-	cmName = "plugin-failures-20"
-	cm20, err := p.clientKube.CoreV1().ConfigMaps(p.Namespace).Get(context.TODO(), cmName, kmmetav1.GetOptions{})
-	if err != nil {
-		log.Errorf("unable to retrieve ConfigMap %s: %v", cmName, err)
-	}
-	if err == nil {
-		suiteListData := cm20.Data["replay.list"]
-		for _, line := range strings.Split(suiteListData, "\n") {
-			if isInExcludeList(line) {
-				continue
+			found = true
+			suiteListData := cm.Data["replay.list"]
+			for _, line := range strings.Split(suiteListData, "\n") {
+				if isInExcludeList(line) {
+					continue
+				}
+				foundConfig = true
+				tests[line] = struct{}{}
 			}
-			foundConfig = true
-			tests[line] = struct{}{}
+			log.Infof("Total failed tests to replay after processing plugin %s: %d", cm.Name, len(tests))
+		}
+		if !found {
+			log.Errorf("unable to retrieve the failures ConfigMap of plugin %s", id)
 		}
-		log.Infof("Total failed tests to replay after processing plugin %s: %d", cmName, len(tests))
 	}
 
 	if !foundConfig {
 		log.Warnf("No tests to replay.")
 		if err := NewJUnitTestReport(&JUnitTestReport{
-			Filepath: "/tmp/shared/junit/junit_e2e_replay_skip.xml",
+			Filepath: filepath.Join(p.workspace().JUnitDir(), "junit_e2e_replay_skip.xml"),
 			Result:   "skipped",
 			Name:     "[opct] replay list is available",
 			Message:  "No tests to replay were found, skipping the plugin",
@@ -401,24 +622,10 @@ func (p *Plugin) ExtractTestsToReplay() error {
 
 // InitializeDevelMode sets up the devel mode for the plugin.
 func (p *Plugin) InitalizeDevelMode() error {
-	devCountStr := os.Getenv("DEV_MODE_COUNT")
-	var devCount int
-	var err error
-
-	// Devel mode isn't enabled (env var not set)
-	if len(devCountStr) == 0 {
-		return nil
-	}
-	if len(devCountStr) > 0 {
-		devCount, err = strconv.Atoi(devCountStr)
-		if err != nil {
-			log.Errorf("error converting DEV_MODE_COUNT to int: %v", err)
-			devCount = 0
-		}
-	}
+	devCount := p.settings().DevModeCount
 
-	// Devel mode/flag is enabled but no count set.
-	if devCount == 0 {
+	// Devel mode isn't enabled (DEV_MODE_COUNT not set).
+	if devCount <= 0 {
 		return nil
 	}
 
@@ -454,7 +661,7 @@ func (p *Plugin) InitalizeDevelMode() error {
 
 		// persisting the new list
 		// Save the SuiteTests map to a file
-		p.SuiteFile = OpenShiftTestsSuiteList
+		p.SuiteFile = p.workspace().SuiteList()
 		suiteListData := ""
 		for testName := range p.SuiteTests {
 			suiteListData += testName + "\n"
@@ -476,6 +683,22 @@ func (p *Plugin) InitalizeDevelMode() error {
 
 // Run send the start command.
 func (p *Plugin) Run() error {
+	// The plugin 99 has no tests runner: the artifacts are collected by the collector
+	// workflow (exec collector), reported as skipped when the plugin is started by 'run'.
+	if p.id == PluginId99 {
+		junit := NewJUnitTestReport(&JUnitTestReport{
+			Filepath: filepath.Join(p.workspace().JUnitDir(), "junit_e2e_collector_skip.xml"),
+			Result:   "skipped",
+			Name:     "[opct] artifacts collected by the collector workflow",
+			Message:  fmt.Sprintf("Skipping the tests runner, the plugin %s collects the artifacts with 'exec collector'", p.name),
+		})
+		if err := junit.Write(); err != nil {
+			return fmt.Errorf("error writing custom junit: %w", err)
+		}
+		p.DoneControl = true
+		return nil
+	}
+
 	// generate the suite list for replay plugin
 	if p.id == PluginId80 {
 		if err := p.ExtractTestsToReplay(); err != nil {
@@ -483,10 +706,11 @@ func (p *Plugin) Run() error {
 		}
 	}
 
+	skipRun := false
 	// Skip the plugin execution when plugin is upgrade in 'default' mode (non-upgrade).
 	if p.id == PluginId05 && p.ExecMode == ExecModeDefault {
 		junit := NewJUnitTestReport(&JUnitTestReport{
-			Filepath: "/tmp/shared/junit/junit_e2e_upgrade_skip.xml",
+			Filepath: filepath.Join(p.workspace().JUnitDir(), "junit_e2e_upgrade_skip.xml"),
 			Result:   "skipped",
 			Name:     "[opct] run suite in default execution mode",
 			Message:  "Skipping the plugin execution the execution mode 'default'",
@@ -494,40 +718,73 @@ func (p *Plugin) Run() error {
 		if err := junit.Write(); err != nil {
 			return fmt.Errorf("error writing custom junit: %w", err)
 		}
-		// create start command in the tests container/process
+		skipRun = true
+	} else if p.discoveryFailed {
+		// Skip the plugin execution when the suite discovery failed, reported in the JUnit.
+		log.Warnf("Run: skipping the tests execution, see the JUnit %s", SuiteDiscoveryJUnit)
+		skipRun = true
+	} else if len(p.skipReason) > 0 {
+		// Skip the plugin execution when the blocker plugin failed (policy skip-with-junit).
+		junit := NewJUnitTestReport(&JUnitTestReport{
+			Filepath: filepath.Join(p.workspace().JUnitDir(), "junit_e2e_blocker_skip.xml"),
+			Result:   "skipped",
+			Name:     "[opct] blocker plugin completed successfully",
+			Message:  p.skipReason,
+		})
+		if err := junit.Write(); err != nil {
+			return fmt.Errorf("error writing custom junit: %w", err)
+		}
+		skipRun = true
+	}
+
+	if p.RunnerMode == RunnerModeSubprocess {
+		if skipRun {
+			log.Info("Run: skipping openshift-tests subprocess.")
+			p.DoneControl = true
+			return nil
+		}
+		return p.RunSubprocess()
+	}
+
+	if err := os.WriteFile(p.workspace().ShardFile(), []byte(p.ShardSuffix()), 0644); err != nil {
+		log.Errorf("unable to write the shard file: %v", err)
+	}
+	// create start command in the tests container/process
+	if skipRun {
 		if err := p.OTRunner.CreateSkip(); err != nil {
 			return fmt.Errorf("unable to create run skip script: %w", err)
 		}
-	} else {
-		// create start command in the tests container/process
-		if err := p.OTRunner.Create(); err != nil {
-			log.Errorf("unable to create run script: %v", err)
-		}
+	} else if err := writeRunFile(p.workspace().RunFile(), p.Runner.Script()); err != nil {
+		log.Errorf("unable to create run script: %v", err)
 	}
 
 	// Wait for run-done
 	// TODO: add watch file with context timeout
 	// TODO(mtulio): do we need to check for error file?
-	log.Infof("Waiting for execution done [%s]", OpenShiftTestsDoneFile)
+	doneFile := p.workspace().DoneFile()
+	log.Infof("Waiting for execution done [%s]", doneFile)
 	threshold := 0
 	backoffSeconds := []int{1, 2, 4, 8}
 	for {
 		// Exit the execution once the tests container/process has finished.
-		if _, err := os.Stat(OpenShiftTestsDoneFile); err == nil {
+		if _, err := os.Stat(doneFile); err == nil {
 			log.Info("Run: Detected done.")
+			if !skipRun {
+				p.processDoneRecord(p.OTRunner.DoneRecordPath)
+			}
 			p.DoneControl = true
 			break
 		} else if errors.Is(err, os.ErrNotExist) {
 			// Keep waiting for the done file to be created (execution completed).
 			sec := backoffSeconds[threshold%len(backoffSeconds)]
-			log.Debugf("backoff waiting %d seconds for done file %s", sec, OpenShiftTestsDoneFile)
+			log.Debugf("backoff waiting %d seconds for done file %s", sec, doneFile)
 			time.Sleep(time.Duration(sec) * time.Second)
 			if threshold >= WaitThresholdLimit {
-				return fmt.Errorf("timeout while waiting for done file %s", OpenShiftTestsDoneFile)
+				return fmt.Errorf("timeout while waiting for done file %s", doneFile)
 			}
 			// every 5 minutes emit the waiting message
 			if (threshold % WaitThresholdNotify) == 0 {
-				log.Debugf("waiting for done file %s", OpenShiftTestsDoneFile)
+				log.Debugf("waiting for done file %s", doneFile)
 			}
 			threshold++
 			continue
@@ -554,11 +811,12 @@ func (p *Plugin) Done() {
 func (p *Plugin) WatchForDone() {
 	defer p.Done()
 
-	if err := watchForFile(ResultsDoneFile, p.Done); err != nil {
+	doneFile := p.workspace().ResultsDoneFile()
+	if err := watchForFile(doneFile, p.Done); err != nil {
 		log.Errorf("Done file watch error: %s", err)
 	}
 
-	log.Infof("Done file has been created at path %s\n", ResultsDoneFile)
+	log.Infof("Done file has been created at path %s\n", doneFile)
 }
 
 // RunReportProgress starts the file/fifo scanner to update status and progress.
@@ -566,6 +824,10 @@ func (p *Plugin) WatchForDone() {
 // The pipe file is created as output of the openshift-tests run command in the
 // tests container/process.
 func (p *Plugin) RunReportProgress() {
+	if p.RunnerMode == RunnerModeSubprocess {
+		log.Info("Progress report is read from the openshift-tests subprocess output.")
+		return
+	}
 	go func() {
 		log.Info("Starting progress report reader...")
 		for {
@@ -574,7 +836,7 @@ func (p *Plugin) RunReportProgress() {
 				break
 			}
 
-			fifo, err := os.Open(FiFoPath)
+			fifo, err := os.Open(p.workspace().FiFo())
 			if err != nil {
 				log.WithError(err).Error("error reading the input stream")
 				fifo.Close()
@@ -585,24 +847,30 @@ func (p *Plugin) RunReportProgress() {
 
 			scanner := bufio.NewScanner(fifo)
 			for scanner.Scan() {
-				line := scanner.Text()
-				skip, err := p.Progress.ParserOpenShiftTestsOutputLine(line)
-				if err != nil {
-					log.WithError(err).Error("line parser error")
-					// or return/break??
-					continue
-				}
-				if skip {
-					continue
-				}
-				p.Progress.UpdateTotalCounters()
-				go func() { p.Progress.UpdateAndSend() }()
+				p.processOutputLine(scanner.Text())
 			}
 			log.Infof(">> Preliminary summary: %s", p.Progress.GetTotalCountersString())
 		}
 	}()
 }
 
+// processOutputLine parses the runner output line, updating and sending the progress.
+func (p *Plugin) processOutputLine(line string) {
+	if p.Runner == nil {
+		return
+	}
+	skip, err := p.Runner.ParseOutputLine(p.Progress, line)
+	if err != nil {
+		log.WithError(err).Error("line parser error")
+		return
+	}
+	if skip {
+		return
+	}
+	p.Progress.UpdateTotalCounters()
+	go func() { p.Progress.UpdateAndSend() }()
+}
+
 // RunReportProgressUpgrade reports the upgrade progress to aggregator API.
 func (p *Plugin) RunReportProgressUpgrade() {
 	if p.id != PluginId05 {
@@ -614,14 +882,9 @@ func (p *Plugin) RunReportProgressUpgrade() {
 		return
 	}
 	// Get ConfigV1 client for Cluster Operators
-	restConfig, err := CreateKubeRestConfig()
-	if err != nil {
-		// TODO return error or move to init
-		return
-	}
-	oc, err := occlient.NewForConfig(restConfig)
-	if err != nil {
-		// TODO return error and/or move to init
+	oc := p.clientConfig
+	if oc == nil {
+		log.Warn("Config client not initialized. Skipping upgrade progress report.")
 		return
 	}
 	log.Debugf("Starting upgrade progress report...")
@@ -680,12 +943,18 @@ func (p *Plugin) RunDependencyWaiter() error {
 	currentCheckCount := int64(0)
 	limitCheckCount := int64(2000)
 	lastCheckCount := int64(0)
-	sleepIntervalSeconds := 10
+	sleepInterval := p.BlockerPollInterval
+	if sleepInterval <= 0 {
+		sleepInterval = BlockerPollInterval * time.Second
+	}
 
 	// TODO move timeout to global config.
 	timeInit := time.Now()
 	timeLimit := timeInit.Add(6 * time.Hour)
 
+	stallDetector := newBlockerStallDetector(p.BlockerStallTimeout, timeInit)
+	blockerStalled := false
+
 	log.Infof("Initializing dependency waiter for plugin[%s] blocked by[%s]...", p.Name(), pluginBlocker)
 	// msgPrefix := fmt.Sprintf("Dependency controller for plugin=%s blocked by=%s", p.Name(), pluginBlocker)
 	backoffSeconds := []int{1, 2, 4, 8, 16}
@@ -745,6 +1014,19 @@ func (p *Plugin) RunDependencyWaiter() error {
 			pluginMessageState = "waiting-for"
 		}
 
+		// Condition 3) check blocker is stalled: running without progress for the stall window,
+		// while not blocked by another plugin.
+		if stallDetector.Observe(blockerProgressCount, isBlockerBlocked(pStatusBlocker), checkTime) &&
+			pStatusBlocker.Status == "running" {
+			pluginMessageState = "blocker-stalled"
+			if !blockerStalled {
+				blockerStalled = true
+				p.reportBlockerStalled(pluginBlocker, pod, stallDetector.StalledFor(checkTime), blockerProgressCount)
+			}
+		} else {
+			blockerStalled = false
+		}
+
 		// mount the plugin message and update API
 		log.Infof("%s: sending message=%s", msgPrefixReconciling, pluginMessageState)
 		msg := fmt.Sprintf("status=%s=%s=(0/%d/0)=[%d/%d]", pluginMessageState, pluginBlocker, remaining, currentCheckCount, limitCheckCount)
@@ -755,15 +1037,28 @@ func (p *Plugin) RunDependencyWaiter() error {
 			log.Infof("Plugin[%s] with status[%s] is in unblocker condition!", pluginBlocker, pStatusBlocker.Status)
 
 			// Check if the blocker plugin failed and propagate failure to dependent plugins
-			// Exception: artifacts collector (99-openshift-artifacts-collector) should always run
-			if pStatusBlocker.Status == "failed" && p.ID() != PluginId99 {
-				log.Errorf("Blocker plugin[%s] failed. Propagating failure to dependent plugin[%s]", pluginBlocker, p.Name())
-				return fmt.Errorf("blocker plugin %s failed, stopping execution of dependent plugin %s", pluginBlocker, p.Name())
+			// based on the failure policy. The artifacts collector (99-openshift-artifacts-collector)
+			// always continue by default.
+			if pStatusBlocker.Status == "failed" {
+				if err := p.handleBlockerFailed(pluginBlocker); err != nil {
+					return err
+				}
 			}
 
 			break
 		}
 
+		if blockerStalled {
+			if p.BlockerStallPolicy == BlockerStallPolicyFail {
+				return fmt.Errorf("blocker plugin %s stalled for %v, stopping execution of dependent plugin %s", pluginBlocker, stallDetector.StalledFor(checkTime), p.Name())
+			}
+			if p.BlockerStallPolicy == BlockerStallPolicyContinue {
+				log.Warnf("Blocker plugin[%s] stalled for %v. Continuing the execution of plugin[%s] by policy.", pluginBlocker, stallDetector.StalledFor(checkTime), p.Name())
+				break
+			}
+			log.Warnf("%s: blocker plugin[%s] stalled for %v, waiting by policy...", msgPrefixReconciling, pluginBlocker, stallDetector.StalledFor(checkTime))
+		}
+
 		// TODO review the state: pod failed or completed
 		if podPhase == "Failed" || podPhase == "NotReady" {
 			lastCheckCount = blockerProgressCount
@@ -782,7 +1077,7 @@ func (p *Plugin) RunDependencyWaiter() error {
 			lastCheckCount = blockerProgressCount
 			currentCheckCount = 0
 			log.Debugf("%s: skipping timeout when blocker is progressing: %d/%d...", msgPrefixReconciling, lastCheckCount, currentCheckCount)
-			time.Sleep(time.Duration(sleepIntervalSeconds))
+			time.Sleep(sleepInterval)
 			continue
 		}
 
@@ -790,7 +1085,7 @@ func (p *Plugin) RunDependencyWaiter() error {
 		if pStatusBlocker.Progress != nil && strings.HasPrefix(pStatusBlocker.Progress.Message, "status=blocked-by") {
 			currentCheckCount = 0
 			log.Debugf("%s: skipping timeout when is already blocked", msgPrefixReconciling)
-			time.Sleep(time.Duration(sleepIntervalSeconds))
+			time.Sleep(sleepInterval)
 			continue
 		}
 
@@ -800,7 +1095,7 @@ func (p *Plugin) RunDependencyWaiter() error {
 			strings.HasPrefix(pluginMessageState, "status=blocked-by") {
 			currentCheckCount = 0
 			log.Debugf("%s: skipping timeout when blocker's plugin is also blocked", msgPrefixReconciling)
-			time.Sleep(time.Duration(sleepIntervalSeconds))
+			time.Sleep(sleepInterval)
 			continue
 		}
 
@@ -810,21 +1105,82 @@ func (p *Plugin) RunDependencyWaiter() error {
 		// lastCheckCount = blockerProgressCount
 		// currentCheckCount += 1
 		// if currentCheckCount >= limitCheckCount {
-		if timeInit.After(timeLimit) {
+		if time.Now().After(timeLimit) {
 			// TODO send update message?
 			return fmt.Errorf("timeout waiting condition 'complete' for plugin[%s]", p.name)
 		}
-		log.Infof("%s: waiting %d seconds for the next check...", msgPrefixReconciling, sleepIntervalSeconds)
-		time.Sleep(time.Duration(sleepIntervalSeconds) * time.Second)
+		log.Infof("%s: waiting %v for the next check...", msgPrefixReconciling, sleepInterval)
+		time.Sleep(sleepInterval)
 	}
 
 	log.Infof("Plugin blocker waiter is unlocked.")
 	return nil
 }
 
+// handleBlockerFailed applies the failure policy when the blocker plugin has failed,
+// returning error when the failure must be propagated to the dependent plugin.
+func (p *Plugin) handleBlockerFailed(pluginBlocker string) error {
+	switch p.BlockerFailurePolicy {
+	case BlockerFailurePolicyContinue:
+		log.Warnf("Blocker plugin[%s] failed. Continuing the execution of plugin[%s] by policy.", pluginBlocker, p.Name())
+		if err := NewJUnitTestReport(&JUnitTestReport{
+			Filepath: filepath.Join(p.workspace().JUnitDir(), BlockerFailedJUnit),
+			Result:   "failed",
+			Name:     fmt.Sprintf("[opct] blocker plugin %s completed successfully", pluginBlocker),
+			Message:  fmt.Sprintf("Blocker plugin %s failed, plugin %s has been executed by policy %q. Results may be impacted by the upstream failure.", pluginBlocker, p.Name(), p.BlockerFailurePolicy),
+		}).Write(); err != nil {
+			log.Errorf("unable to write JUnit for failed blocker plugin %s: %v", pluginBlocker, err)
+		}
+	case BlockerFailurePolicySkipWithJUnit:
+		log.Warnf("Blocker plugin[%s] failed. Skipping the execution of plugin[%s] by policy.", pluginBlocker, p.Name())
+		p.skipReason = fmt.Sprintf("Blocker plugin %s failed, skipping the execution of plugin %s by policy %q.", pluginBlocker, p.Name(), p.BlockerFailurePolicy)
+	default:
+		log.Errorf("Blocker plugin[%s] failed. Propagating failure to dependent plugin[%s]", pluginBlocker, p.Name())
+		return fmt.Errorf("blocker plugin %s failed, stopping execution of dependent plugin %s", pluginBlocker, p.Name())
+	}
+	return nil
+}
+
+// reportBlockerStalled publishes the stalled state of the blocker plugin, collecting
+// the last log lines of the blocker pod into a JUnit failure.
+func (p *Plugin) reportBlockerStalled(pluginBlocker string, pod *kcorev1.Pod, stalledFor time.Duration, completed int64) {
+	log.Errorf("Blocker plugin[%s] is stalled: no progress for %v (completed=%d). Policy: %s", pluginBlocker, stalledFor, completed, p.BlockerStallPolicy)
+
+	podLogs := "unable to collect logs: kubernetes client not initialized"
+	if p.clientKube != nil {
+		podLogs = GetPluginPodLogs(p.clientKube, pod, BlockerStallLogTailLines)
+	}
+	if err := NewJUnitTestReport(&JUnitTestReport{
+		Filepath: filepath.Join(p.workspace().JUnitDir(), BlockerStalledJUnit),
+		Result:   "failed",
+		Name:     fmt.Sprintf("[opct] blocker plugin %s is progressing", pluginBlocker),
+		Message:  fmt.Sprintf("Blocker plugin %s stalled with no progress for %v (completed=%d), policy %q applied to plugin %s", pluginBlocker, stalledFor, completed, p.BlockerStallPolicy, p.Name()),
+		Output:   podLogs,
+	}).Write(); err != nil {
+		log.Errorf("unable to write JUnit for stalled blocker plugin %s: %v", pluginBlocker, err)
+	}
+}
+
 // Summary shows the summary and exit.
 func (p *Plugin) Summary() {
 	log.Infof(">> Summary: %s", p.Progress.GetTotalCountersString())
+	if stats := p.Progress.ParserStats(); stats.Lines > 0 {
+		log.Infof(">> Output parser: %s grammars=%v", stats, stats.Grammars)
+		if stats.Errors > 0 {
+			log.Warnf(">> Output parser errors by kind: %v, last errors: %v", stats.ErrorsByKind, stats.LastErrors)
+		}
+	}
+
+	if report := p.FailureAnalysis; report != nil {
+		log.Infof(">> Failure analysis: %d failures in %d signatures", report.Failures, len(report.Signatures))
+		for idx, s := range report.Signatures {
+			if idx >= failureAnalysisSummaryLimit {
+				log.Infof(">> Failure analysis: %d more signatures, see %s", len(report.Signatures)-idx, FailureAnalysisName+p.ShardSuffix()+".json")
+				break
+			}
+			fmt.Printf("%d %s [%s] %s\n", s.Count, s.ID, strings.Join(s.SIGs, ","), s.Signature)
+		}
+	}
 
 	log.Println("Showing summary by rank of slower test")
 	// TODO make as an option:
@@ -841,16 +1197,36 @@ func (p *Plugin) Summary() {
 // ProcessJUnit collects the JUnit results, parse it and save to result dir.
 func (p *Plugin) ProcessJUnit() error {
 	log.Info("JUnit processor started!")
-	xmlFiles, err := filepath.Glob("/tmp/shared/junit/junit_e2e_*.xml")
+	ws := p.workspace()
+	xmlFiles, err := filepath.Glob(filepath.Join(ws.JUnitDir(), "junit_e2e_*.xml"))
 	if err != nil {
 		return fmt.Errorf("error finding XML files: %w", err)
 	}
+	// The results written by the runner are the primary JUnit, reported to the aggregator.
+	// Without results, the runner status is the primary JUnit, reporting the crash of
+	// the runner with the other synthetic JUnits merged.
+	if p.Runner != nil {
+		runnerFiles, err := p.Runner.JUnitFiles()
+		if err != nil {
+			log.Warnf("unable to find the JUnit results of runner %s: %v", p.Runner.Name(), err)
+		}
+		runnerStatus := filepath.Join(ws.JUnitDir(), RunnerStatusJUnit)
+		if len(runnerFiles) == 0 && slices.Contains(xmlFiles, runnerStatus) {
+			runnerFiles = append(runnerFiles, runnerStatus)
+		}
+		for _, file := range xmlFiles {
+			if !slices.Contains(runnerFiles, file) {
+				runnerFiles = append(runnerFiles, file)
+			}
+		}
+		xmlFiles = runnerFiles
+	}
 	if len(xmlFiles) == 0 {
 		if p.id != PluginId80 {
 			return fmt.Errorf("no JUnit/XMLs files found")
 		}
 		// TODO move this check/fallback to somewhere more appropriated?
-		xmlSkip := "/tmp/shared/junit/junit_e2e_replay_skip.xml"
+		xmlSkip := filepath.Join(ws.JUnitDir(), "junit_e2e_replay_skip.xml")
 		if err := NewJUnitTestReport(&JUnitTestReport{
 			Filepath: xmlSkip,
 			Result:   "skipped",
@@ -863,8 +1239,13 @@ func (p *Plugin) ProcessJUnit() error {
 		xmlFiles = append(xmlFiles, xmlSkip)
 	}
 
+	// resultFileName returns the result file name, suffixed by shard when the plugin is sharded.
+	resultFileName := func(xmlFilePath string) string {
+		name := filepath.Base(xmlFilePath)
+		return strings.TrimSuffix(name, ".xml") + p.ShardSuffix() + ".xml"
+	}
 	for _, xmlFilePath := range xmlFiles {
-		newFilePath := filepath.Join(ResultsDir, filepath.Base(xmlFilePath))
+		newFilePath := ws.Results(resultFileName(xmlFilePath))
 		log.Infof("moving XML file [%s] to [%s]", xmlFilePath, newFilePath)
 
 		// Copy file instead of move, because the move issue:
@@ -887,30 +1268,72 @@ func (p *Plugin) ProcessJUnit() error {
 	}
 
 	xmlFile := xmlFiles[0]
-	resultJunitFile := filepath.Join(ResultsDir, filepath.Base(xmlFile))
+	resultJunitFile := ws.Results(resultFileName(xmlFile))
+
+	// Report the tests removed from the suite, by the version matrix and in disconnected
+	// mode, the failed or stalled blocker plugin, and the runner exit status, in the
+	// results: only the primary JUnit is packaged.
+	for _, name := range []string{VersionExcludedJUnit, DisconnectedEgressJUnit, BlockerFailedJUnit, BlockerStalledJUnit, RunnerStatusJUnit} {
+		extraJUnit := filepath.Join(ws.JUnitDir(), name)
+		if _, err := os.Stat(extraJUnit); err != nil || xmlFile == extraJUnit {
+			continue
+		}
+		log.Infof("Appending the tests of %s to %s", extraJUnit, resultJunitFile)
+		if err := MergeJUnitTestCases(resultJunitFile, extraJUnit); err != nil {
+			log.Errorf("unable to append the tests of %s to the results: %v", name, err)
+		}
+	}
+	failuresSuiteFile := ws.Work(fmt.Sprintf("failures-%s%s-suite.txt", p.ID(), p.ShardSuffix()))
 
 	if err := p.ParseAndExtractFailuresFromJunit(
-		"/tmp/shared/suite.list",
+		ws.SuiteList(),
 		resultJunitFile,
-		"/tmp/shared/failures.list",
-		fmt.Sprintf("/tmp/failures-%s-suite.txt", p.ID()),
+		ws.Shared("failures.list"),
+		failuresSuiteFile,
 	); err != nil {
 		return fmt.Errorf("error parsing JUnit: %w", err)
 	}
 
-	if err := p.SaveToConfigMap(fmt.Sprintf("/tmp/failures-%s-suite.txt", p.ID())); err != nil {
+	// The output of the failed tests read from the stream is attached to the failures.
+	failureReport, err := p.SaveFailureExcerpts(ws.Shared("failures.list"))
+	if err != nil {
+		log.Errorf("unable to save the failure excerpts: %v", err)
+	}
+
+	if err := p.SaveToConfigMap(failuresSuiteFile, failureReport); err != nil {
 		return fmt.Errorf("error saving to ConfigMap: %w", err)
 	}
 
-	// Save XML to worker result control file
-	res, err := os.OpenFile(ResultsDoneFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	// The failure analysis and the monitor summary are saved to the results before
+	// packaging the results.
+	if p.FailureAnalysis, err = p.AnalyzeFailures(resultJunitFile); err != nil {
+		log.Errorf("unable to analyze the failures: %v", err)
+	}
+	if _, err := p.SummarizeMonitors(); err != nil {
+		log.Errorf("unable to summarize the monitors: %v", err)
+	}
+
+	// The results package, with the JUnit and the artifacts, is uploaded by the worker.
+	resultsPackage, err := p.PackageResults(resultJunitFile)
 	if err != nil {
-		return fmt.Errorf("error opening file %s: %w", ResultsDoneFile, err)
+		return fmt.Errorf("error packaging the results: %w", err)
+	}
+	// The digest of the plugin 99 is the collector manifest, saved by the collector.
+	if p.id != PluginId99 {
+		if err := p.SaveResultsDigest(); err != nil {
+			log.Errorf("unable to save the digest of the results manifest: %v", err)
+		}
+	}
+
+	// Save the results package to worker result control file
+	res, err := os.OpenFile(ws.ResultsDoneFile(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", ws.ResultsDoneFile(), err)
 	}
 	defer res.Close()
 
-	log.Infof("Notify worker for done: writing JUnit file %s to result file %s", resultJunitFile, ResultsDoneFile)
-	_, err = res.WriteString(resultJunitFile)
+	log.Infof("Notify worker for done: writing results package %s to result file %s", resultsPackage, ws.ResultsDoneFile())
+	_, err = res.WriteString(resultsPackage)
 	if err != nil {
 		return fmt.Errorf("error writing to file: %w", err)
 	}
@@ -953,7 +1376,21 @@ func (p *Plugin) ParseAndExtractFailuresFromJunit(suiteList, xmlFile, outFailure
 
 	var ts TestSuite
 	if err := xml.Unmarshal(xmlData, &ts); err != nil {
-		return fmt.Errorf("error parsing XML data: %w", err)
+		// JUnit with multiple test suites (example: ginkgo runner) are parsed as one suite.
+		var suites struct {
+			XMLName xml.Name    `xml:"testsuites"`
+			Suites  []TestSuite `xml:"testsuite"`
+		}
+		if serr := xml.Unmarshal(xmlData, &suites); serr != nil || len(suites.Suites) == 0 {
+			return fmt.Errorf("error parsing XML data: %w", err)
+		}
+		ts = suites.Suites[0]
+		for _, suite := range suites.Suites[1:] {
+			ts.Tests += suite.Tests
+			ts.Skipped += suite.Skipped
+			ts.Failures += suite.Failures
+			ts.TestCases = append(ts.TestCases, suite.TestCases...)
+		}
 	}
 
 	// Iterate over the test cases
@@ -980,8 +1417,23 @@ func (p *Plugin) ParseAndExtractFailuresFromJunit(suiteList, xmlFile, outFailure
 		return fmt.Errorf("error saving failures to file: %w", err)
 	}
 
-	if err := ParseSuiteFailures(outFailuresXML, suiteList, outFailuresSuite); err != nil {
-		return fmt.Errorf("error saving failures to file: %w", err)
+	if p.runsSuiteList() {
+		if err := ParseSuiteFailures(outFailuresXML, suiteList, outFailuresSuite); err != nil {
+			return fmt.Errorf("error saving failures to file: %w", err)
+		}
+	} else {
+		// The tests of the other runners are not in the suite list: the failures are saved
+		// without the synthetic tests of the plugin.
+		runnerFailures := []string{}
+		for _, failure := range failures {
+			if !strings.HasPrefix(failure, `"[opct] `) {
+				runnerFailures = append(runnerFailures, failure+"\n")
+			}
+		}
+		log.Infof("Found %d test failures of runner %s on %s", len(runnerFailures), p.Runner.Name(), outFailuresXML)
+		if err := os.WriteFile(outFailuresSuite, []byte(strings.Join(runnerFailures, "")), 0644); err != nil {
+			return fmt.Errorf("error saving failures to file: %w", err)
+		}
 	}
 
 	// Summary. TODO/Q: should we print only in debug mode?
@@ -991,8 +1443,9 @@ func (p *Plugin) ParseAndExtractFailuresFromJunit(suiteList, xmlFile, outFailure
 	return nil
 }
 
-// SaveToConfigMap saves the failures tests to a ConfigMap.
-func (p *Plugin) SaveToConfigMap(outFailuresSuite string) error {
+// SaveToConfigMap saves the failures tests to a ConfigMap, with the failure excerpts
+// of the report when not nil.
+func (p *Plugin) SaveToConfigMap(outFailuresSuite string, report *FailureReport) error {
 
 	// Read the failureSuiteFile from file
 	failureSuiteData, err := os.ReadFile(outFailuresSuite)
@@ -1003,13 +1456,21 @@ func (p *Plugin) SaveToConfigMap(outFailuresSuite string) error {
 	// Create a ConfigMap object
 	configMap := &kcorev1.ConfigMap{
 		ObjectMeta: kmmetav1.ObjectMeta{
-			Name:      fmt.Sprintf("plugin-failures-%s", p.ID()),
+			Name:      fmt.Sprintf("plugin-failures-%s%s", p.ID(), p.ShardSuffix()),
 			Namespace: EnvNamespace,
+			Labels:    map[string]string{FailuresLabel: p.ID()},
 		},
 		Data: map[string]string{
 			"replay.list": string(failureSuiteData),
 		},
 	}
+	if report != nil {
+		failures, err := configMapFailures(report)
+		if err != nil {
+			return fmt.Errorf("error rendering failure excerpts: %w", err)
+		}
+		configMap.Data[FailuresConfigMapKey] = failures
+	}
 
 	// Create the ConfigMap using the Kubernetes client
 	if p.clientKube == nil {
@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -323,3 +324,14 @@ func TestGetSuiteNameEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestShareBinary(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "openshift-tests-plugin")
+	if err := shareBinary(dst); err != nil {
+		t.Fatalf("shareBinary() unexpected error: %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil || info.Mode().Perm() != 0755 || info.Size() == 0 {
+		t.Errorf("shared binary = %v (%v), want executable file", info, err)
+	}
+}
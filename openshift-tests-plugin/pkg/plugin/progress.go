@@ -8,13 +8,15 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/utils/ptr"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/parser"
 )
 
 // PluginProgress holds the progress state.
@@ -29,7 +31,13 @@ type PluginProgress struct {
 	ProgressMessage *string
 	TestMap         map[string]*TestProgress
 
-	svc *pluginProgressService
+	svc    *pluginProgressService
+	parser *parser.Parser
+
+	// failures is the output of the failed tests, by test name.
+	failuresMu      sync.Mutex
+	failures        map[string]*FailureExcerpt
+	failuresDropped int
 }
 
 // NewPluginProgress creates a new PluginProgress service.
@@ -39,9 +47,15 @@ func NewPluginProgress() *PluginProgress {
 		svc: &pluginProgressService{
 			url: ProgressURL,
 		},
+		parser: parser.New(),
 	}
 }
 
+// SetURL sets the progress endpoint of the sonobuoy worker.
+func (ps *PluginProgress) SetURL(url string) {
+	ps.svc.url = url
+}
+
 // Set update counters for progress updater.
 func (ps *PluginProgress) Set(v *PluginProgress) {
 	if v.StartedCount != nil {
@@ -181,65 +195,48 @@ func (ps *PluginProgress) UpdateAndSend() {
 }
 
 // ParserOpenShiftTestsOutputLine parse the openshift-tests output line and update the counters.
+// The lines not reporting the status of a test (output, monitors and invalid lines) are skipped.
 func (ps *PluginProgress) ParserOpenShiftTestsOutputLine(line string) (skip bool, err error) {
-	switch {
-	case strings.HasPrefix(line, "started:"):
+	if ps.parser == nil {
+		ps.parser = parser.New()
+	}
+	ev := ps.parser.Parse(line)
+	switch ev.Type {
+	case parser.EventStarted:
 		ps.Inc(&PluginProgress{StartedCount: ptr.To(int64(1))})
-		res := &resultLineParser{ParserName: "started"}
-		re := regexp.MustCompile(`^started\:\s(?P<Counter>\d+\/\d+\/\d+)\s(?P<TestName>.*)`)
-		match := re.FindStringSubmatch(line)
-		if len(match) != 3 {
-			log.Warnf("parser (%s): unexpected expression to extract results: %v", res.ParserName, match)
-			return true, nil
+		ps.TestMap[ev.Name] = &TestProgress{
+			TestName:  ev.Name,
+			StartedAt: ev.StartedAt.Format(time.RFC3339),
+			Result:    "started",
 		}
-		testName := match[2]
-		ps.TestMap[testName] = &TestProgress{
-			TestName: testName,
-		}
-		ps.TestMap[testName].Result = "started"
-
-	case strings.HasPrefix(line, "passed:"), strings.HasPrefix(line, "passed ("):
-		ps.Inc(&PluginProgress{PassedCount: ptr.To(int64(1))})
 
-		res := &resultLineParser{ParserName: "passed"}
-		err := res.ExtractTestTimeFromLine(line)
-		if err != nil {
-			log.Warnf("parser (%s): error extracting test time: %v", res.ParserName, err)
-			return true, nil
+	case parser.EventResult:
+		switch ev.Result {
+		case "passed":
+			ps.Inc(&PluginProgress{PassedCount: ptr.To(int64(1))})
+		case "skipped":
+			ps.Inc(&PluginProgress{SkippedCount: ptr.To(int64(1))})
+		case "failed":
+			ps.Inc(&PluginProgress{FailedCount: ptr.To(int64(1))})
 		}
-		if err := res.CalculateFields(ps.TestMap); err != nil {
-			log.Warnf("parser (%s): error calculating fields: %v", res.ParserName, err)
-			return true, nil
+		if ev.Err != nil {
+			log.Warnf("parser: line %d: %v", ev.Line, ev.Err)
 		}
-
-	case strings.HasPrefix(line, "skipped:"), strings.HasPrefix(line, "skipped ("):
-		ps.Inc(&PluginProgress{SkippedCount: ptr.To(int64(1))})
-
-		res := &resultLineParser{ParserName: "skipped"}
-		err := res.ExtractTestTimeFromLine(line)
-		if err != nil {
-			log.Warnf("parser (%s): error extracting test time: %v", res.ParserName, err)
-			return true, nil
+		if ev.Result == "failed" && len(ev.Output) > 0 {
+			ps.recordFailureOutput(ev)
 		}
-		if err := res.CalculateFields(ps.TestMap); err != nil {
-			log.Warnf("parser (%s): error calculating fields: %v", res.ParserName, err)
-			return true, nil
+		ps.TestMap[ev.Name] = &TestProgress{
+			TestName:        ev.Name,
+			StartedAt:       ev.StartedAt.Format(time.RFC3339),
+			EndAt:           ev.EndAt.Format(time.RFC3339),
+			TimeTook:        ev.Duration.String(),
+			TimeTookSeconds: ev.Duration.Seconds(),
+			Result:          ev.Result,
 		}
 
-	case strings.HasPrefix(line, "failed:"), strings.HasPrefix(line, "failed ("):
-		ps.Inc(&PluginProgress{FailedCount: ptr.To(int64(1))})
-
-		res := &resultLineParser{ParserName: "failed"}
-		err := res.ExtractTestTimeFromLine(line)
-		if err != nil {
-			log.Warnf("parser (%s): error extracting test time: %v", res.ParserName, err)
-			return true, nil
-		}
-
-		if err := res.CalculateFields(ps.TestMap); err != nil {
-			log.Warnf("parser (%s): error calculating fields: %v", res.ParserName, err)
-			return true, nil
-		}
+	case parser.EventInvalid:
+		log.Warnf("parser: line %d: %v: %s", ev.Line, ev.Err, ev.Text)
+		return true, nil
 
 	default:
 		return true, nil
@@ -247,6 +244,14 @@ func (ps *PluginProgress) ParserOpenShiftTestsOutputLine(line string) (skip bool
 	return false, nil
 }
 
+// ParserStats returns the counters of the openshift-tests output parsed.
+func (ps *PluginProgress) ParserStats() parser.Stats {
+	if ps.parser == nil {
+		return parser.Stats{}
+	}
+	return ps.parser.Stats()
+}
+
 // LoadTotalTestsFromSuite loads and parses the suite file (output of openshift-tests --dry-run),
 // filtering only suite test names.
 func (ps *PluginProgress) LoadTotalTestsFromSuite(suiteFile string) (err error) {
@@ -0,0 +1,261 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/version"
+	log "github.com/sirupsen/logrus"
+	kcorev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ResultsPackageName is the directory with the results uploaded by the sonobuoy worker,
+	// created in ResultsDir and suffixed by the shard.
+	ResultsPackageName = "results"
+	// ResultsManifestName is the manifest of the results package, with the checksum of
+	// each file of the package.
+	ResultsManifestName = "results-manifest.json"
+	// ResultsManifestVersion is the version of the results manifest format.
+	ResultsManifestVersion = "v1"
+
+	// ResultsDigestLabel labels the ConfigMaps with the digest of the results manifest
+	// of each plugin, read by the artifacts collector.
+	ResultsDigestLabel = "opct.redhat.com/results-digest"
+	// ResultsDigestPluginKey and ResultsDigestKey are the keys of the plugin name and the
+	// manifest digest (SHA-256) in the results digest ConfigMap.
+	ResultsDigestPluginKey = "plugin"
+	ResultsDigestKey       = "manifest.sha256"
+)
+
+// ResultsFile is a file of the results package.
+type ResultsFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ResultsManifest holds the files of the results package, with the provenance of the
+// results. The digest of the manifest is recorded out of the results (ConfigMap), then
+// recorded by the artifacts collector in its manifest.
+type ResultsManifest struct {
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Plugin is the plugin name, suffixed by the shard.
+	Plugin        string         `json:"plugin"`
+	PluginVersion string         `json:"pluginVersion"`
+	Suite         string         `json:"suite,omitempty"`
+	Files         []*ResultsFile `json:"files"`
+}
+
+// PackageResults creates the results package: the directory in ResultsDir with the JUnit
+// results and the artifacts saved by the plugin in ResultsDir (example: the inventory
+// snapshots, the failure excerpts, the failure analysis and the monitor summary).
+// The package is informed to the sonobuoy worker by the done file, and uploaded as a
+// tarball extracted by the aggregator in the plugin results. Only the JUnit file is
+// packaged: the other JUnit files copied to ResultsDir are discarded, not uploaded by
+// the worker, the synthetic JUnits (example: removed tests, blocker and runner status)
+// are merged into the JUnit file by ProcessJUnit.
+func (p *Plugin) PackageResults(junitFile string) (string, error) {
+	ws := p.workspace()
+	dir := ws.Results(ResultsPackageName + p.ShardSuffix())
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("error removing results package %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating results package %s: %w", dir, err)
+	}
+	entries, err := os.ReadDir(ws.ResultsDir)
+	if err != nil {
+		return "", fmt.Errorf("error reading results directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		src := ws.Results(name)
+		switch {
+		case strings.HasPrefix(name, ResultsPackageName), src == ws.ResultsDoneFile():
+			continue
+		case strings.HasSuffix(name, ".xml") && src != junitFile:
+			continue
+		}
+		if err := linkTree(src, filepath.Join(dir, name)); err != nil {
+			return "", fmt.Errorf("error adding %s to results package: %w", name, err)
+		}
+	}
+	digest, err := p.writeResultsManifest(dir)
+	if err != nil {
+		return "", err
+	}
+	p.ResultsDigest = digest
+	return dir, nil
+}
+
+// writeResultsManifest writes the manifest of the results package, returning the digest
+// (SHA-256) of the manifest.
+func (p *Plugin) writeResultsManifest(dir string) (string, error) {
+	files, err := ListResultsFiles(dir)
+	if err != nil {
+		return "", fmt.Errorf("error listing results package: %w", err)
+	}
+	manifest := &ResultsManifest{
+		Version:       ResultsManifestVersion,
+		CreatedAt:     time.Now().UTC(),
+		Plugin:        p.FullName() + p.ShardSuffix(),
+		PluginVersion: version.GetFullVersion(),
+		Suite:         p.SuiteName,
+		Files:         files,
+	}
+	file := filepath.Join(dir, ResultsManifestName)
+	if err := saveJSON(file, manifest); err != nil {
+		return "", fmt.Errorf("error writing results manifest: %w", err)
+	}
+	digest, _, err := FileSHA256(file)
+	if err != nil {
+		return "", fmt.Errorf("error computing digest of results manifest: %w", err)
+	}
+	log.Infof("Results manifest created at %s (sha256 %s)", file, digest)
+	return digest, nil
+}
+
+// ListResultsFiles returns the files of the results package, with the size and checksum,
+// without the manifest.
+func ListResultsFiles(dir string) ([]*ResultsFile, error) {
+	files := []*ResultsFile{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == ResultsManifestName {
+			return nil
+		}
+		sum, size, err := FileSHA256(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, &ResultsFile{Name: filepath.ToSlash(rel), Size: size, SHA256: sum})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, err
+}
+
+// ReadResultsManifest reads the manifest of the results package, returning the manifest
+// and its digest (SHA-256).
+func ReadResultsManifest(dir string) (*ResultsManifest, string, error) {
+	file := filepath.Join(dir, ResultsManifestName)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading results manifest: %w", err)
+	}
+	manifest := &ResultsManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid results manifest %s: %w", file, err)
+	}
+	if manifest.Version != ResultsManifestVersion {
+		return nil, "", fmt.Errorf("unsupported results manifest version %q, want %q", manifest.Version, ResultsManifestVersion)
+	}
+	sum := sha256.Sum256(data)
+	return manifest, hex.EncodeToString(sum[:]), nil
+}
+
+// SaveResultsDigest saves the digest of the results manifest to a ConfigMap, read by
+// the artifacts collector to record the digest in the collector manifest.
+func (p *Plugin) SaveResultsDigest() error {
+	if p.clientKube == nil {
+		return fmt.Errorf("kubernetes client not initialized")
+	}
+	configMap := &kcorev1.ConfigMap{
+		ObjectMeta: kmmetav1.ObjectMeta{
+			Name:      fmt.Sprintf("plugin-results-%s%s", p.ID(), p.ShardSuffix()),
+			Namespace: EnvNamespace,
+			Labels:    map[string]string{ResultsDigestLabel: "true"},
+		},
+		Data: map[string]string{
+			ResultsDigestPluginKey: p.FullName() + p.ShardSuffix(),
+			ResultsDigestKey:       p.ResultsDigest,
+		},
+	}
+	cms := p.clientKube.CoreV1().ConfigMaps(EnvNamespace)
+	_, err := cms.Create(context.TODO(), configMap, kmmetav1.CreateOptions{})
+	if kerrors.IsAlreadyExists(err) {
+		_, err = cms.Update(context.TODO(), configMap, kmmetav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error saving results digest ConfigMap: %w", err)
+	}
+	return nil
+}
+
+// FileSHA256 returns the hex encoded SHA-256 and the size of the file.
+func FileSHA256(file string) (string, int64, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer fd.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, fd)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// linkTree links the file, or the files of the directory, to dst. The files are copied
+// when not able to be linked (example: different devices).
+func linkTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case !d.Type().IsRegular():
+			return nil
+		}
+		if err := os.Link(path, target); err == nil {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies the regular file src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// TestPackageResults tests the results package has the JUnit results and the artifacts
+// of ResultsDir, without the other JUnit files and the done file, and the manifest with
+// the checksum of the files. The manifest digest is saved to the ConfigMap.
+func TestPackageResults(t *testing.T) {
+	p, err := NewPlugin(PluginName10)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	p.ShardIndex, p.ShardCount = 1, 2
+	ws := NewWorkspace(t.TempDir(), "")
+	if err := ws.Create(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetWorkspace(ws)
+	p.SetClients(kfake.NewSimpleClientset(), nil, nil)
+
+	junit := ws.Results("junit_e2e__20240705-202749-shard-1.xml")
+	files := map[string]string{
+		junit: "<testsuite/>",
+		ws.Results("junit_e2e_skip_excluded-shard-1.xml"):                          "<testsuite/>",
+		ws.Results("inventory_start.json"):                                         "{}",
+		ws.Results("inventory_end.json"):                                           "{}",
+		ws.Results(InventoryDiffFile):                                              "{}",
+		ws.Results(FailureExcerptsName + p.ShardSuffix() + ".json"):                "{}",
+		ws.Results(filepath.Join(FailureExcerptsName+p.ShardSuffix(), "0001.txt")): "test a",
+		ws.ResultsDoneFile():                                                       "",
+	}
+	for file, data := range files {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// packaged twice, the previous package is replaced.
+	for i := 0; i < 2; i++ {
+		dir, err := p.PackageResults(junit)
+		if err != nil {
+			t.Fatalf("PackageResults() unexpected error: %v", err)
+		}
+		if dir != ws.Results(ResultsPackageName+"-shard-1") {
+			t.Errorf("PackageResults() = %s, want the package of the shard", dir)
+		}
+		got := []string{}
+		err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				rel, _ := filepath.Rel(dir, path)
+				got = append(got, rel)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		want := []string{
+			"failure-excerpts-shard-1.json", "failure-excerpts-shard-1/0001.txt", "inventory_diff.json",
+			"inventory_end.json", "inventory_start.json", "junit_e2e__20240705-202749-shard-1.xml", ResultsManifestName,
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("PackageResults() files = %v, want %v", got, want)
+		}
+
+		manifest, digest, err := ReadResultsManifest(dir)
+		if err != nil {
+			t.Fatalf("ReadResultsManifest() unexpected error: %v", err)
+		}
+		if digest != p.ResultsDigest || manifest.Plugin != "10-openshift-kube-conformance-shard-1" || len(manifest.Files) != len(want)-1 {
+			t.Errorf("results manifest = %+v (sha256 %s), want the files of the package (sha256 %s)", manifest, digest, p.ResultsDigest)
+		}
+		files, err := ListResultsFiles(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, f := range files {
+			if *f != *manifest.Files[i] {
+				t.Errorf("results manifest file %+v, want %+v", manifest.Files[i], f)
+			}
+		}
+	}
+
+	if err := p.SaveResultsDigest(); err != nil {
+		t.Fatalf("SaveResultsDigest() unexpected error: %v", err)
+	}
+	cms, err := p.clientKube.CoreV1().ConfigMaps(EnvNamespace).List(context.TODO(), kmmetav1.ListOptions{LabelSelector: ResultsDigestLabel})
+	if err != nil || len(cms.Items) != 1 {
+		t.Fatalf("results digest ConfigMaps = %v (%v), want one", cms, err)
+	}
+	if data := cms.Items[0].Data; data[ResultsDigestKey] != p.ResultsDigest || data[ResultsDigestPluginKey] != "10-openshift-kube-conformance-shard-1" {
+		t.Errorf("results digest ConfigMap data = %v, want the digest %s", data, p.ResultsDigest)
+	}
+}
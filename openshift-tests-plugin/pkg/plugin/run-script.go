@@ -1,9 +1,12 @@
 package plugin
 
 import (
+	"encoding/json"
 	"fmt"
-	"html/template"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -11,6 +14,16 @@ import (
 // OpenShiftTestsBinPath is the binary path for openshift-tests.
 const OpenShiftTestsBinPath = "/usr/bin/openshift-tests"
 
+// OpenShiftTestsRunScriptHeader is the header of the run/start script.
+const OpenShiftTestsRunScriptHeader = "#!/usr/bin/env bash\n"
+
+// OpenShiftTestsSkipTest is the test reported by the run/start script when the
+// runner is skipped.
+const OpenShiftTestsSkipTest = "[opct] openshift-tests runner"
+
+// shellSafeArg matches arguments which does not require quoting in the shell.
+var shellSafeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
 // OpenShiftTestsRunFile holds the openshift-tests command options to create run/start script.
 type OpenShiftTestsRunCommand struct {
 	BinPath        string
@@ -20,34 +33,21 @@ type OpenShiftTestsRunCommand struct {
 	MaxParallel    string
 	JUnitDir       string
 	FiFoPath       string
+	DoneRecordPath string
+	StderrPath     string
 	ToImage        string
 	FromRepository string
 	Options        string
 	File           string
-}
+	// Provider is the cloud provider configuration (--provider), as JSON or provider name.
+	Provider string
+	// RunFile is the run/start script executed by the tests container.
+	RunFile string
 
-// OpenShiftTestsRunBaseTemplate is the template for the run the openshift-tests command.
-var OpenShiftTestsRunBaseTemplate = `
-{{ .BinPath }} {{ .Command }} {{ .SuiteName }} \
-  --junit-dir="{{ .JUnitDir }}" \
-  --max-parallel-tests="{{ .MaxParallel }}" \
-{{- if .Monitortests }}
-  --monitor="{{ .Monitortests }}" \
-{{- end}}
-{{- if .ToImage }}
-  --to-image="{{ .ToImage }}" \
-{{- end}}
-{{- if .FromRepository }}
-  --from-repository="{{ .FromRepository }}" \
-{{- end}}
-{{- if .Options }}
-  --options="{{ .Options }}" \
-{{- end}}
-{{- if .File }}
-  --file="{{ .File }}" \
-{{- end}}
-  | tee -a {{ .FiFoPath }} || true
-`
+	// ExtraArgs holds additional openshift-tests flags appended to the command,
+	// example: --provider, --cluster-stability, --shard-count.
+	ExtraArgs []string
+}
 
 // NewOpenShiftRunCommand creates a new OpenShiftTestsRunCommand wraper.
 func NewOpenShiftRunCommand(command, suiteName string) *OpenShiftTestsRunCommand {
@@ -59,50 +59,149 @@ func NewOpenShiftRunCommand(command, suiteName string) *OpenShiftTestsRunCommand
 		MaxParallel:  DefaultOpenShiftTestsRunMaxParallel,
 		Monitortests: DefaultOpenShiftTestsRunMonitors,
 		FiFoPath:     FiFoPath,
+
+		DoneRecordPath: OpenShiftTestsDoneRecord,
+		StderrPath:     OpenShiftTestsStderrLog,
+		RunFile:        OpenShiftTestsRunFile,
 	}
 }
 
-// Create creates run/start script for openshift-tests.
-func (ocmd *OpenShiftTestsRunCommand) Create() error {
-	tmpl, err := template.New("cmd").Parse(OpenShiftTestsRunBaseTemplate)
-	if err != nil {
-		return fmt.Errorf("error creating template for run command: %w", err)
+// AddFlag appends an openshift-tests flag to the command. The flag name can be
+// informed with or without the prefix '--', and the value is optional.
+func (ocmd *OpenShiftTestsRunCommand) AddFlag(name, value string) *OpenShiftTestsRunCommand {
+	flag := "--" + strings.TrimPrefix(name, "--")
+	if len(value) > 0 {
+		flag = fmt.Sprintf("%s=%s", flag, value)
 	}
+	ocmd.ExtraArgs = append(ocmd.ExtraArgs, flag)
+	return ocmd
+}
 
-	runFile, err := os.Create(OpenShiftTestsRunFile)
-	if err != nil {
-		return fmt.Errorf("error creating run file: %w", err)
+// Args builds the openshift-tests command as an argument list (argv).
+func (ocmd *OpenShiftTestsRunCommand) Args() []string {
+	args := []string{ocmd.BinPath, ocmd.Command}
+	if len(ocmd.SuiteName) > 0 {
+		args = append(args, ocmd.SuiteName)
 	}
-	defer runFile.Close()
-
-	err = tmpl.Execute(runFile, ocmd)
-	if err != nil {
-		return fmt.Errorf("error rendering template for run command: %w", err)
+	args = append(args,
+		fmt.Sprintf("--junit-dir=%s", ocmd.JUnitDir),
+		fmt.Sprintf("--max-parallel-tests=%s", ocmd.MaxParallel),
+	)
+	if len(ocmd.Monitortests) > 0 {
+		args = append(args, fmt.Sprintf("--monitor=%s", ocmd.Monitortests))
+	}
+	if len(ocmd.ToImage) > 0 {
+		args = append(args, fmt.Sprintf("--to-image=%s", ocmd.ToImage))
+	}
+	if len(ocmd.FromRepository) > 0 {
+		args = append(args, fmt.Sprintf("--from-repository=%s", ocmd.FromRepository))
+	}
+	if len(ocmd.Options) > 0 {
+		args = append(args, fmt.Sprintf("--options=%s", ocmd.Options))
+	}
+	if len(ocmd.File) > 0 {
+		args = append(args, fmt.Sprintf("--file=%s", ocmd.File))
 	}
+	if len(ocmd.Provider) > 0 {
+		args = append(args, fmt.Sprintf("--provider=%s", ocmd.Provider))
+	}
+	return append(args, ocmd.ExtraArgs...)
+}
 
-	log.Infof("Run file created at %s", OpenShiftTestsRunFile)
-	return nil
+// Name returns the runner name.
+func (ocmd *OpenShiftTestsRunCommand) Name() string {
+	return RunnerNameOpenShiftTests
+}
+
+// ParseOutputLine parses the openshift-tests output line, updating the progress.
+func (ocmd *OpenShiftTestsRunCommand) ParseOutputLine(progress *PluginProgress, line string) (bool, error) {
+	return progress.ParserOpenShiftTestsOutputLine(line)
+}
+
+// JUnitFiles returns the JUnit results written by openshift-tests, named
+// junit_e2e__<timestamp>.xml.
+func (ocmd *OpenShiftTestsRunCommand) JUnitFiles() ([]string, error) {
+	return filepath.Glob(filepath.Join(ocmd.JUnitDir, "junit_e2e__*.xml"))
+}
+
+// Script serializes the openshift-tests command to the run/start script.
+func (ocmd *OpenShiftTestsRunCommand) Script() string {
+	return runScript(ocmd.Args(), ocmd.StderrPath, ocmd.FiFoPath, ocmd.DoneRecordPath)
+}
+
+// SkipScript serializes the run/start script reporting the runner as skipped.
+func (ocmd *OpenShiftTestsRunCommand) SkipScript() string {
+	skipLine := fmt.Sprintf(`skipped: (0.0s) 2024-07-05T20:27:49 "%s"`, OpenShiftTestsSkipTest)
+	return fmt.Sprintf("%secho %s | tee -a %s || true\n",
+		OpenShiftTestsRunScriptHeader, ShellQuote(skipLine), ShellQuote(ocmd.FiFoPath))
+}
+
+// Create creates run/start script for openshift-tests.
+func (ocmd *OpenShiftTestsRunCommand) Create() error {
+	return writeRunFile(ocmd.RunFile, ocmd.Script())
 }
 
 // CreateSkip creates skip test on run/start script.
 func (ocmd *OpenShiftTestsRunCommand) CreateSkip() error {
-	SkipScript := `echo "skipped: (0.0s) 2024-07-05T20:27:49 \"[opct] openshift-tests runner\"" | tee -a {{ .FiFoPath }} || true`
-	tmpl, err := template.New("cmd").Parse(SkipScript)
-	if err != nil {
-		return fmt.Errorf("error creating template for run command: %w", err)
+	return writeRunFile(ocmd.RunFile, ocmd.SkipScript())
+}
+
+// runScript serializes the runner command to the run/start script, quoting each
+// argument for the shell, and sending the output to the FIFO. The script writes
+// the done record (RunnerStatus) with the exit status of the runner, always
+// exiting with success to not break the tests container.
+func runScript(args []string, stderrPath, fifoPath, doneRecordPath string) string {
+	// Positional arguments (binary, command and suite) are kept in the first line,
+	// and each flag is rendered in a new line.
+	lines := []string{}
+	for _, arg := range args {
+		if len(lines) > 0 && !strings.HasPrefix(arg, "--") && !strings.HasPrefix(lines[len(lines)-1], "--") {
+			lines[len(lines)-1] += " " + ShellQuote(arg)
+			continue
+		}
+		lines = append(lines, ShellQuote(arg))
 	}
+	// json.Marshal does not fail for string slices.
+	command, _ := json.Marshal(args)
+
+	var script strings.Builder
+	script.WriteString(OpenShiftTestsRunScriptHeader)
+	script.WriteString("started_at=\"$(date -u +%Y-%m-%dT%H:%M:%SZ)\"\n")
+	fmt.Fprintf(&script, "%s \\\n  2> >(tee %s >&2) \\\n  | tee -a %s\n",
+		strings.Join(lines, " \\\n  "), ShellQuote(stderrPath), ShellQuote(fifoPath))
+	script.WriteString("exit_code=\"${PIPESTATUS[0]}\"\n")
+	script.WriteString("signal=\"\"\n")
+	script.WriteString("if [[ ${exit_code} -gt 128 ]]; then\n")
+	script.WriteString("  signal=\"SIG$(kill -l $((exit_code - 128)) 2>/dev/null || true)\"\n")
+	script.WriteString("fi\n")
+	script.WriteString("jq -n \\\n")
+	script.WriteString("  --argjson exitCode \"${exit_code}\" \\\n")
+	script.WriteString("  --arg signal \"${signal}\" \\\n")
+	script.WriteString("  --arg startedAt \"${started_at}\" \\\n")
+	script.WriteString("  --arg finishedAt \"$(date -u +%Y-%m-%dT%H:%M:%SZ)\" \\\n")
+	fmt.Fprintf(&script, "  --argjson command %s \\\n", ShellQuote(string(command)))
+	fmt.Fprintf(&script, "  --arg stderr \"$(tail -n %d %s 2>/dev/null)\" \\\n", RunnerStderrTailLines, ShellQuote(stderrPath))
+	script.WriteString("  '{exitCode: $exitCode, startedAt: $startedAt, finishedAt: $finishedAt, command: $command}\n")
+	script.WriteString("   + (if $signal != \"\" then {signal: $signal} else {} end)\n")
+	script.WriteString("   + (if $stderr != \"\" then {stderr: ($stderr | split(\"\\n\"))} else {} end)' \\\n")
+	fmt.Fprintf(&script, "  > %s || true\n", ShellQuote(doneRecordPath))
+	script.WriteString("exit 0\n")
+	return script.String()
+}
 
-	runFile, err := os.Create(OpenShiftTestsRunFile)
-	if err != nil {
+// writeRunFile writes the run/start script consumed by the tests container/process.
+func writeRunFile(path, script string) error {
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
 		return fmt.Errorf("error creating run file: %w", err)
 	}
-	defer runFile.Close()
+	log.Infof("Run file created at %s", path)
+	return nil
+}
 
-	err = tmpl.Execute(runFile, ocmd)
-	if err != nil {
-		return fmt.Errorf("error rendering template for run command: %w", err)
+// ShellQuote quotes the argument to be safely interpreted by the shell as a single word.
+func ShellQuote(arg string) string {
+	if shellSafeArg.MatchString(arg) {
+		return arg
 	}
-
-	log.Infof("Run file created at %s", OpenShiftTestsRunFile)
-	return nil
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
 }
@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tdata "github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/test"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in test/testdata")
+
+// goldenPath is the path of the golden files in the source tree, used to update them.
+const goldenPath = "../../test"
+
+// assertGolden compares the data with the golden file embedded in the test data,
+// updating the golden file in the source tree when the flag -update is set.
+func assertGolden(t *testing.T, golden string, got string) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.WriteFile(filepath.Join(goldenPath, golden), []byte(got), 0644); err != nil {
+			t.Fatalf("error updating golden file %s: %v", golden, err)
+		}
+		return
+	}
+	want, err := tdata.TestData.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("error reading golden file %s (use -update to create it): %v", golden, err)
+	}
+	if got != string(want) {
+		t.Errorf("golden file %s mismatch:\n--- got ---\n%s\n--- want ---\n%s", golden, got, string(want))
+	}
+}
+
+// TestOpenShiftTestsRunCommandScript tests the run script serialization with golden files.
+func TestOpenShiftTestsRunCommandScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		cmd    func() *OpenShiftTestsRunCommand
+	}{
+		{
+			name:   "default conformance suite",
+			golden: "testdata/run-script/run-default.sh",
+			cmd: func() *OpenShiftTestsRunCommand {
+				return NewOpenShiftRunCommand("run", PluginSuite20)
+			},
+		},
+		{
+			name:   "replay with suite file",
+			golden: "testdata/run-script/run-replay.sh",
+			cmd: func() *OpenShiftTestsRunCommand {
+				c := NewOpenShiftRunCommand("run", PluginSuite80)
+				c.File = "/tmp/shared/suite.list"
+				c.MaxParallel = "1"
+				return c
+			},
+		},
+		{
+			name:   "upgrade with mirror and unsafe values",
+			golden: "testdata/run-script/run-upgrade.sh",
+			cmd: func() *OpenShiftTestsRunCommand {
+				c := NewOpenShiftRunCommand("run-upgrade", PluginSuite05)
+				c.ToImage = "quay.io/openshift-release-dev/ocp-release@sha256:abc; rm -rf /"
+				c.FromRepository = "mirror.example.com:5000/ocp $(whoami)"
+				c.Options = "abort-at=99,input='a b'"
+				return c
+			},
+		},
+		{
+			name:   "extra flags",
+			golden: "testdata/run-script/run-extra-flags.sh",
+			cmd: func() *OpenShiftTestsRunCommand {
+				c := NewOpenShiftRunCommand("run", PluginSuite20)
+				c.AddFlag("--provider", `{"type":"aws","region":"us-east-1","zone":"us-east-1a","multizone":true}`).
+					AddFlag("cluster-stability", "Disruptive").
+					AddFlag("--shard-count", "4").
+					AddFlag("--dry-run", "")
+				return c
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.golden, tt.cmd().Script())
+		})
+	}
+
+	t.Run("skip script", func(t *testing.T) {
+		assertGolden(t, "testdata/run-script/run-skip.sh", NewOpenShiftRunCommand("run-upgrade", PluginSuite05).SkipScript())
+	})
+}
+
+// TestShellQuote tests the shell quoting of arguments.
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "--junit-dir=/tmp/shared/junit", want: "--junit-dir=/tmp/shared/junit"},
+		{arg: "", want: "''"},
+		{arg: "a b", want: "'a b'"},
+		{arg: "it's", want: `'it'\''s'`},
+		{arg: "$(id)", want: "'$(id)'"},
+		{arg: `"[sig-arch] test"`, want: `'"[sig-arch] test"'`},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.arg); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+// TestOpenShiftTestsRunCommandArgs tests the argument list is built without shell quoting.
+func TestOpenShiftTestsRunCommandArgs(t *testing.T) {
+	c := NewOpenShiftRunCommand("run", PluginSuite20)
+	c.Options = "a b"
+	c.Provider = `{"type":"aws"}`
+	got := c.Args()
+	want := []string{
+		OpenShiftTestsBinPath, "run", PluginSuite20,
+		"--junit-dir=" + OpenShiftTestsJUnitDir,
+		"--max-parallel-tests=" + DefaultOpenShiftTestsRunMaxParallel,
+		"--monitor=" + DefaultOpenShiftTestsRunMonitors,
+		"--options=a b",
+		`--provider={"type":"aws"}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Args() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Args()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// GinkgoE2EBinPath is the default path of the ginkgo-based e2e binary.
+	GinkgoE2EBinPath = "/usr/bin/e2e.test"
+
+	// DefaultGinkgoFocus and DefaultGinkgoSkip select the specs executed by the
+	// ginkgo runner, the same defaults of the Sonobuoy e2e plugin.
+	DefaultGinkgoFocus = `\[Conformance\]`
+	DefaultGinkgoSkip  = `\[Disruptive\]|NoExecuteTaintManager`
+
+	// ginkgoSpecSeparator is the line printed by ginkgo between the specs.
+	ginkgoSpecSeparator = "------------------------------"
+)
+
+// reGinkgoSpecResult matches the result line of a spec in the ginkgo v2 output,
+// example: '• [FAILED] [12.345 seconds]'. Passed specs have no state.
+var reGinkgoSpecResult = regexp.MustCompile(`^(•|S|P)((?:\s*\[[A-Z ]+\])*)\s*\[([0-9.]+) seconds\]$`)
+
+// reGinkgoWillRun matches the count of specs selected by focus and skip, printed when
+// the suite starts, example: 'Will run 3 of 7000 specs'.
+var reGinkgoWillRun = regexp.MustCompile(`^Will run (\d+) of \d+ specs`)
+
+// reGinkgoSpecLocation matches the code location printed after the spec name,
+// example: 'test/e2e/apps/deployment.go:132'.
+var reGinkgoSpecLocation = regexp.MustCompile(`^\S+\.go:\d+$`)
+
+// GinkgoRunCommand holds the command options of ginkgo-based e2e binaries, such as
+// the Kubernetes e2e.test, which report the JUnit results in the report directory.
+type GinkgoRunCommand struct {
+	BinPath  string
+	JUnitDir string
+	Focus    string
+	Skip     string
+	// Timeout is the ginkgo suite timeout. The ginkgo default (1h) is used when zero.
+	Timeout time.Duration
+
+	FiFoPath       string
+	DoneRecordPath string
+	StderrPath     string
+
+	// ExtraArgs holds additional flags appended to the command.
+	ExtraArgs []string
+
+	// specName is the spec running, candidate the line expected to be a spec name,
+	// and result the state of a spec reported before the name, tracked while
+	// parsing the output.
+	specName   string
+	candidate  string
+	expectName bool
+	result     *TestProgress
+}
+
+// NewGinkgoRunCommand creates the ginkgo runner for the e2e binary, using the
+// default binary path when not set.
+func NewGinkgoRunCommand(binPath string) *GinkgoRunCommand {
+	if len(binPath) == 0 {
+		binPath = GinkgoE2EBinPath
+	}
+	return &GinkgoRunCommand{
+		BinPath:  binPath,
+		JUnitDir: OpenShiftTestsJUnitDir,
+		Focus:    DefaultGinkgoFocus,
+		Skip:     DefaultGinkgoSkip,
+
+		FiFoPath:       FiFoPath,
+		DoneRecordPath: OpenShiftTestsDoneRecord,
+		StderrPath:     OpenShiftTestsStderrLog,
+	}
+}
+
+// Name returns the runner name.
+func (g *GinkgoRunCommand) Name() string {
+	return RunnerNameGinkgo
+}
+
+// Args builds the e2e command as an argument list (argv). The verbose output is
+// required to parse the progress, and the JUnit is written to the report directory.
+func (g *GinkgoRunCommand) Args() []string {
+	args := []string{g.BinPath,
+		"--ginkgo.v",
+		"--ginkgo.no-color",
+		fmt.Sprintf("--report-dir=%s", g.JUnitDir),
+	}
+	if len(g.Focus) > 0 {
+		args = append(args, fmt.Sprintf("--ginkgo.focus=%s", g.Focus))
+	}
+	if len(g.Skip) > 0 {
+		args = append(args, fmt.Sprintf("--ginkgo.skip=%s", g.Skip))
+	}
+	if g.Timeout > 0 {
+		args = append(args, fmt.Sprintf("--ginkgo.timeout=%s", g.Timeout))
+	}
+	return append(args, g.ExtraArgs...)
+}
+
+// Script serializes the e2e command to the run/start script.
+func (g *GinkgoRunCommand) Script() string {
+	return runScript(g.Args(), g.StderrPath, g.FiFoPath, g.DoneRecordPath)
+}
+
+// JUnitFiles returns the JUnit results written by the e2e binary in the report
+// directory, named junit_<index>.xml.
+func (g *GinkgoRunCommand) JUnitFiles() ([]string, error) {
+	return filepath.Glob(filepath.Join(g.JUnitDir, "junit_[0-9]*.xml"))
+}
+
+// ParseOutputLine parses the ginkgo verbose output. The spec name is printed after
+// the spec separator, followed by the code location, and the result line is printed
+// when the spec finishes. The specs reported only when finished (example: failures
+// in succinct mode) have the result line followed by the spec name.
+func (g *GinkgoRunCommand) ParseOutputLine(progress *PluginProgress, line string) (bool, error) {
+	line = strings.TrimSpace(line)
+	// The total of the progress is the count of specs selected, the suite list of
+	// openshift-tests is not used by the ginkgo runner.
+	if match := reGinkgoWillRun.FindStringSubmatch(line); match != nil {
+		total, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return true, fmt.Errorf("ginkgo parser: invalid spec count %q: %w", match[1], err)
+		}
+		progress.Set(&PluginProgress{TotalCount: ptr.To(total)})
+		return true, nil
+	}
+	if strings.HasPrefix(line, ginkgoSpecSeparator) {
+		g.specName, g.candidate, g.expectName, g.result = "", "", true, nil
+		return true, nil
+	}
+	if match := reGinkgoSpecResult.FindStringSubmatch(line); match != nil {
+		result := ginkgoSpecState(match[1], match[2])
+		seconds, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return true, fmt.Errorf("ginkgo parser: invalid spec duration %q: %w", match[3], err)
+		}
+		took := time.Duration(seconds * float64(time.Second))
+		res := &TestProgress{Result: result, TimeTook: took.String(), TimeTookSeconds: seconds}
+		if len(g.specName) == 0 {
+			g.result, g.expectName = res, true
+			return true, nil
+		}
+		res.TestName = g.specName
+		g.specName = ""
+		g.record(progress, res)
+		return false, nil
+	}
+	if len(g.candidate) > 0 {
+		candidate := g.candidate
+		g.candidate = ""
+		if !reGinkgoSpecLocation.MatchString(line) {
+			return true, nil
+		}
+		g.startOrRecord(progress, candidate)
+		return false, nil
+	}
+	if g.expectName && len(line) > 0 {
+		g.candidate, g.expectName = line, false
+	}
+	return true, nil
+}
+
+// startOrRecord records the result reported before the spec name, or starts the spec.
+func (g *GinkgoRunCommand) startOrRecord(progress *PluginProgress, line string) {
+	name := strings.Join(strings.Fields(strings.ReplaceAll(line, "[It]", "")), " ")
+	if g.result != nil {
+		g.result.TestName = name
+		g.record(progress, g.result)
+		g.result = nil
+		return
+	}
+	g.specName = name
+	progress.Inc(&PluginProgress{StartedCount: ptr.To(int64(1))})
+	progress.TestMap[name] = &TestProgress{TestName: name, Result: "started"}
+}
+
+// record updates the progress counters with the spec result.
+func (g *GinkgoRunCommand) record(progress *PluginProgress, res *TestProgress) {
+	switch res.Result {
+	case "passed":
+		progress.Inc(&PluginProgress{PassedCount: ptr.To(int64(1))})
+	case "skipped":
+		progress.Inc(&PluginProgress{SkippedCount: ptr.To(int64(1))})
+	default:
+		progress.Inc(&PluginProgress{FailedCount: ptr.To(int64(1))})
+	}
+	progress.TestMap[res.TestName] = res
+}
+
+// ginkgoSpecState returns the test result of the spec state reported by ginkgo.
+func ginkgoSpecState(marker, states string) string {
+	switch {
+	case marker == "S", marker == "P", strings.Contains(states, "[SKIPPED]"), strings.Contains(states, "[PENDING]"):
+		return "skipped"
+	case strings.Contains(states, "[FAILED]"), strings.Contains(states, "[PANICKED]"),
+		strings.Contains(states, "[TIMEDOUT]"), strings.Contains(states, "[INTERRUPTED]"),
+		strings.Contains(states, "[ABORTED]"):
+		return "failed"
+	}
+	return "passed"
+}
@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EnvTestsRunner selects the test runner executed by the conformance plugins.
+	EnvTestsRunner = "TESTS_RUNNER"
+
+	// RunnerNameOpenShiftTests runs the suites with openshift-tests. Default.
+	RunnerNameOpenShiftTests = "openshift-tests"
+	// RunnerNameGinkgo runs a ginkgo-based e2e binary, example: Kubernetes e2e.test.
+	RunnerNameGinkgo = "ginkgo"
+)
+
+// Runner is the test tooling executed by the plugin. The runner builds the command
+// executed in the tests container (shared-volume) or as subprocess, parses the
+// output to report the progress, and locates the JUnit results.
+type Runner interface {
+	// Name returns the runner name, used in the logs and reports.
+	Name() string
+	// Args builds the runner command as an argument list (argv).
+	Args() []string
+	// Script serializes the runner command to the run/start script.
+	Script() string
+	// ParseOutputLine parses a line of the runner output, updating the progress.
+	// Lines not reporting a test result are skipped.
+	ParseOutputLine(progress *PluginProgress, line string) (skip bool, err error)
+	// JUnitFiles returns the JUnit results written by the runner.
+	JUnitFiles() ([]string, error)
+}
+
+// initRunner sets the test runner from environment TESTS_RUNNER. The default runner
+// is openshift-tests, configured by OTRunner. The ginkgo runner is supported by the
+// conformance plugins not sharded, running the e2e binary GINKGO_E2E_BINARY with the
+// specs filtered by GINKGO_FOCUS and GINKGO_SKIP.
+func (p *Plugin) initRunner() {
+	cfg := p.settings().Runner
+	envRunner := cfg.Name
+	switch envRunner {
+	case "", RunnerNameOpenShiftTests:
+		return
+	case RunnerNameGinkgo:
+		if p.id != PluginId10 && p.id != PluginId20 {
+			log.Warnf("Runner %q is not supported by plugin %s, using %q", envRunner, p.name, p.Runner.Name())
+			return
+		}
+	default:
+		log.Errorf("invalid %s %q, using default %q", EnvTestsRunner, envRunner, RunnerNameOpenShiftTests)
+		return
+	}
+
+	runner := NewGinkgoRunCommand(cfg.GinkgoBinary)
+	runner.JUnitDir = p.OTRunner.JUnitDir
+	runner.FiFoPath = p.OTRunner.FiFoPath
+	runner.DoneRecordPath = p.OTRunner.DoneRecordPath
+	runner.StderrPath = p.OTRunner.StderrPath
+	runner.Timeout = p.Timeout
+	if cfg.GinkgoFocus != nil {
+		runner.Focus = *cfg.GinkgoFocus
+	}
+	if cfg.GinkgoSkip != nil {
+		runner.Skip = *cfg.GinkgoSkip
+	}
+	if p.ShardCount > 1 {
+		log.Errorf("Runner %q does not support sharding (shard %d/%d), using %q", envRunner, p.ShardIndex+1, p.ShardCount, p.Runner.Name())
+		return
+	}
+	log.Infof("Using runner %q: %s focus=%q skip=%q", envRunner, runner.BinPath, runner.Focus, runner.Skip)
+	p.Runner = runner
+}
+
+// runsSuiteList returns true when the runner executes the suite list of openshift-tests.
+// The other runners select the tests (example: ginkgo focus and skip), reporting test
+// names not found in the suite list.
+func (p *Plugin) runsSuiteList() bool {
+	_, ok := p.Runner.(*OpenShiftTestsRunCommand)
+	return p.Runner == nil || ok
+}
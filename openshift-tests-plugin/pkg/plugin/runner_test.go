@@ -0,0 +1,206 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestInitRunner tests the runner selection from the environment.
+func TestInitRunner(t *testing.T) {
+	tests := []struct {
+		name       string
+		plugin     string
+		shardCount int
+		env        map[string]string
+		wantRunner string
+		wantArgs   []string
+	}{
+		{name: "default", plugin: PluginName20, wantRunner: RunnerNameOpenShiftTests},
+		{name: "invalid", plugin: PluginName20, env: map[string]string{EnvTestsRunner: "pytest"}, wantRunner: RunnerNameOpenShiftTests},
+		{name: "ginkgo not supported by replay", plugin: PluginName80, env: map[string]string{EnvTestsRunner: RunnerNameGinkgo}, wantRunner: RunnerNameOpenShiftTests},
+		{name: "ginkgo not supported by shards", plugin: PluginName10, shardCount: 2, env: map[string]string{EnvTestsRunner: RunnerNameGinkgo}, wantRunner: RunnerNameOpenShiftTests},
+		{
+			name:       "ginkgo defaults",
+			plugin:     PluginName10,
+			env:        map[string]string{EnvTestsRunner: RunnerNameGinkgo},
+			wantRunner: RunnerNameGinkgo,
+			wantArgs: []string{GinkgoE2EBinPath, "--ginkgo.v", "--ginkgo.no-color", "--report-dir=" + OpenShiftTestsJUnitDir,
+				"--ginkgo.focus=" + DefaultGinkgoFocus, "--ginkgo.skip=" + DefaultGinkgoSkip, "--ginkgo.timeout=2h0m0s"},
+		},
+		{
+			name:   "ginkgo custom binary and filters",
+			plugin: PluginName20,
+			env: map[string]string{EnvTestsRunner: RunnerNameGinkgo, "GINKGO_E2E_BINARY": "/tmp/shared/k8s-e2e.test",
+				"GINKGO_FOCUS": `\[sig-network\]`, "GINKGO_SKIP": ""},
+			wantRunner: RunnerNameGinkgo,
+			wantArgs: []string{"/tmp/shared/k8s-e2e.test", "--ginkgo.v", "--ginkgo.no-color", "--report-dir=" + OpenShiftTestsJUnitDir,
+				`--ginkgo.focus=\[sig-network\]`, "--ginkgo.timeout=4h0m0s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			p, err := NewPlugin(tt.plugin)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			p.ShardCount = tt.shardCount
+			p.initRunner()
+			if p.Runner.Name() != tt.wantRunner {
+				t.Fatalf("initRunner() runner = %q, want %q", p.Runner.Name(), tt.wantRunner)
+			}
+			if tt.wantArgs != nil && !reflect.DeepEqual(p.Runner.Args(), tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", p.Runner.Args(), tt.wantArgs)
+			}
+		})
+	}
+}
+
+// TestGinkgoRunCommandScript tests the run script of the ginkgo runner with golden files.
+func TestGinkgoRunCommandScript(t *testing.T) {
+	g := NewGinkgoRunCommand("")
+	g.Timeout = 2 * time.Hour
+	assertGolden(t, "testdata/run-script/run-ginkgo.sh", g.Script())
+}
+
+// TestGinkgoRunCommandParseOutputLine tests the progress is parsed from the ginkgo
+// verbose output.
+func TestGinkgoRunCommandParseOutputLine(t *testing.T) {
+	output := `Running Suite: Kubernetes e2e suite - /usr/bin
+===============================================
+Random Seed: 1720211269 - will randomize all specs
+
+Will run 3 of 7000 specs
+------------------------------
+[sig-node] Pods should be submitted and removed [NodeConformance] [Conformance]
+test/e2e/common/node/pods.go:227
+  STEP: Creating a kubernetes client @ 07/05/24 20:27:49.000
+• [2.103 seconds]
+------------------------------
+[sig-apps] Deployment deployment should support rollover [Conformance]
+test/e2e/apps/deployment.go:132
+  [FAILED] in [It] - test/e2e/apps/deployment.go:140 @ 07/05/24 20:28:01.000
+• [FAILED] [12.345 seconds]
+[sig-apps] Deployment [It] deployment should support rollover [Conformance]
+test/e2e/apps/deployment.go:132
+
+  [FAILED] timed out waiting for the condition
+------------------------------
+S [SKIPPED] [0.001 seconds]
+[sig-storage] CSI Volumes [It] should skip [Conformance]
+test/e2e/storage/csi.go:10
+------------------------------
+SSSSSSSSSS
+
+Ran 2 of 7000 Specs in 14.449 seconds
+FAIL! -- 1 Passed | 1 Failed | 0 Pending | 6998 Skipped`
+
+	g := NewGinkgoRunCommand("")
+	progress := NewPluginProgress()
+	for _, line := range strings.Split(output, "\n") {
+		if _, err := g.ParseOutputLine(progress, line); err != nil {
+			t.Fatalf("ParseOutputLine(%q) unexpected error: %v", line, err)
+		}
+	}
+	if got := progress.GetTotalCountersString(); got != "T/C/P/F/S=3/0/1/1/1" {
+		t.Errorf("counters = %s, want the specs selected, passed, failed and skipped", got)
+	}
+	want := map[string]string{
+		"[sig-node] Pods should be submitted and removed [NodeConformance] [Conformance]": "passed",
+		"[sig-apps] Deployment deployment should support rollover [Conformance]":          "failed",
+		"[sig-storage] CSI Volumes should skip [Conformance]":                             "skipped",
+	}
+	if len(progress.TestMap) != len(want) {
+		t.Errorf("TestMap has %d tests, want %d: %v", len(progress.TestMap), len(want), progress.TestMap)
+	}
+	for name, result := range want {
+		test, ok := progress.TestMap[name]
+		if !ok {
+			t.Errorf("test %q not found in progress", name)
+			continue
+		}
+		if test.Result != result {
+			t.Errorf("test %q result = %q, want %q", name, test.Result, result)
+		}
+	}
+	if took := progress.TestMap["[sig-apps] Deployment deployment should support rollover [Conformance]"].TimeTookSeconds; took != 12.345 {
+		t.Errorf("failed test took %v seconds, want 12.345", took)
+	}
+}
+
+// TestOpenShiftTestsRunCommandParseOutputLine tests the progress and the timestamps of
+// the tests are parsed from the openshift-tests output.
+func TestOpenShiftTestsRunCommandParseOutputLine(t *testing.T) {
+	output := `started: 0/1/2 "[sig-apps] test a"
+I0705 20:27:49.123456       1 monitor.go:123] Starting monitor
+started: 0/2/2 "[sig-apps] test b"
+passed: (2.1s) 2024-07-05T20:27:49 "[sig-apps] test a"
+fail [k8s.io/kubernetes/test/e2e/apps/job.go:211]: Timed out after 300.000s.
+failed: (5m2s) 2024-07-05T20:32:51 "[sig-apps] test b"
+passed: invalid "[sig-apps] test c"`
+
+	ocmd := &OpenShiftTestsRunCommand{}
+	progress := NewPluginProgress()
+	for _, line := range strings.Split(output, "\n") {
+		if _, err := ocmd.ParseOutputLine(progress, line); err != nil {
+			t.Fatalf("ParseOutputLine(%q) unexpected error: %v", line, err)
+		}
+	}
+	progress.UpdateTotalCounters()
+	if got := progress.GetTotalCountersString(); got != "T/C/P/F/S=2/2/1/1/0" {
+		t.Errorf("counters = %s, want passed and failed", got)
+	}
+	b := progress.TestMap["[sig-apps] test b"]
+	if b == nil || b.Result != "failed" || b.StartedAt != "2024-07-05T20:27:49Z" || b.EndAt != "2024-07-05T20:32:51Z" || b.TimeTookSeconds != 302 {
+		t.Errorf("test b = %+v, want failed from 2024-07-05T20:27:49Z to 2024-07-05T20:32:51Z", b)
+	}
+	if stats := progress.ParserStats(); stats.Lines != 7 || stats.Monitor != 1 || stats.Output != 1 || stats.Errors != 1 {
+		t.Errorf("ParserStats() = %+v, want one monitor, one output and one invalid line", stats)
+	}
+}
+
+// TestParseAndExtractFailuresFromJunitSuites tests the JUnit with multiple test suites
+// written by the ginkgo runner: the spec names are not in the suite list of
+// openshift-tests, the failures of the runner are saved without the synthetic tests.
+func TestParseAndExtractFailuresFromJunitSuites(t *testing.T) {
+	dir := t.TempDir()
+	junit := filepath.Join(dir, "junit_01.xml")
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" disabled="0" errors="0" failures="1" time="14.4">
+  <testsuite name="Kubernetes e2e suite" tests="3" skipped="1" failures="1" time="14.4">
+    <testcase name="[sig-node] Pods should be submitted and removed [Conformance]" time="2.1"></testcase>
+    <testcase name="[sig-apps] Deployment should support rollover [Conformance]" time="12.3"><failure message="timed out" type="failed">timed out</failure></testcase>
+    <testcase name="[sig-storage] CSI should skip" time="0"><skipped message="skipped"></skipped></testcase>
+  </testsuite>
+  <testsuite name="opct" tests="1" skipped="0" failures="1" time="0.0">
+    <testcase name="[opct] ginkgo runner completed" time="0.0"><failure message="exit code 2">exit code 2</failure></testcase>
+  </testsuite>
+</testsuites>`
+	if err := os.WriteFile(junit, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	suite := filepath.Join(dir, "suite.list")
+	if err := os.WriteFile(suite, []byte("\"[sig-apps] Deployment should support rollover [Conformance] [Suite:k8s]\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	failures := filepath.Join(dir, "failures.list")
+	failuresSuite := filepath.Join(dir, "failures-suite.list")
+	p := &Plugin{Runner: NewGinkgoRunCommand("")}
+	if err := p.ParseAndExtractFailuresFromJunit(suite, junit, failures, failuresSuite); err != nil {
+		t.Fatalf("ParseAndExtractFailuresFromJunit() unexpected error: %v", err)
+	}
+	got, _ := os.ReadFile(failures)
+	if !strings.HasPrefix(string(got), `"[sig-apps] Deployment should support rollover [Conformance]"`) {
+		t.Errorf("failures = %q, want the deployment test", string(got))
+	}
+	got, _ = os.ReadFile(failuresSuite)
+	if string(got) != "\"[sig-apps] Deployment should support rollover [Conformance]\"\n" {
+		t.Errorf("failures of the suite = %q, want the deployment test of the runner", string(got))
+	}
+}
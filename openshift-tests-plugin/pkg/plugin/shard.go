@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ShardTests partitions the suite tests deterministically, returning the sorted
+// list of tests assigned to the shard index (0-based) in a total of count shards.
+// When durations (test name => seconds) are provided, tests are balanced by the
+// historical duration, otherwise tests are assigned by the hash of the name.
+func ShardTests(tests map[string]struct{}, index, count int, durations map[string]float64) ([]string, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid shard count %d: must be greater than zero", count)
+	}
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("invalid shard index %d: must be between 0 and %d", index, count-1)
+	}
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	shard := []string{}
+	if len(durations) == 0 {
+		for _, name := range names {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(name))
+			if int(h.Sum32()%uint32(count)) == index {
+				shard = append(shard, name)
+			}
+		}
+		return shard, nil
+	}
+
+	// Balance by duration (longest processing time first), tests without history
+	// are estimated by the average duration of known tests.
+	known := float64(0)
+	knownCount := 0
+	for _, name := range names {
+		if d, ok := durations[name]; ok {
+			known += d
+			knownCount++
+		}
+	}
+	avg := float64(1)
+	if knownCount > 0 && known > 0 {
+		avg = known / float64(knownCount)
+	}
+	duration := func(name string) float64 {
+		if d, ok := durations[name]; ok {
+			return d
+		}
+		return avg
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return duration(names[i]) > duration(names[j])
+	})
+	totals := make([]float64, count)
+	for _, name := range names {
+		target := 0
+		for i := 1; i < count; i++ {
+			if totals[i] < totals[target] {
+				target = i
+			}
+		}
+		totals[target] += duration(name)
+		if target == index {
+			shard = append(shard, name)
+		}
+	}
+	sort.Strings(shard)
+	return shard, nil
+}
+
+// LoadShardDurations loads the historical test durations from a JSON file with
+// the format: {"<test name>": <duration in seconds>}.
+func LoadShardDurations(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading shard durations file: %w", err)
+	}
+	durations := map[string]float64{}
+	if err := json.Unmarshal(data, &durations); err != nil {
+		return nil, fmt.Errorf("error parsing shard durations file: %w", err)
+	}
+	return durations, nil
+}
+
+// IsSharded returns true when the plugin runs a shard of the suite.
+func (p *Plugin) IsSharded() bool {
+	return p.ShardCount > 1
+}
+
+// ShardSuffix returns the suffix used to name the plugin outputs (ConfigMap, JUnit,
+// failures list) of the shard, or empty when the plugin is not sharded.
+func (p *Plugin) ShardSuffix() string {
+	if !p.IsSharded() {
+		return ""
+	}
+	return fmt.Sprintf("-shard-%d", p.ShardIndex)
+}
+
+// initShardConfig sets the shard from environment when it is not set by flags.
+// SHARD_INDEX is the 0-based index of the shard, SHARD_COUNT is the total of shards,
+// and SHARD_DURATIONS_FILE is the optional historical durations used to balance the shards.
+func (p *Plugin) initShardConfig() {
+	cfg := p.settings().Shard
+	if p.ShardCount == 0 {
+		p.ShardCount = cfg.Count
+		p.ShardIndex = cfg.Index
+	}
+	if len(p.ShardDurationsFile) == 0 {
+		p.ShardDurationsFile = cfg.DurationsFile
+	}
+}
+
+// InitializeShard partitions the suite tests, keeping only the tests of the
+// current shard, and setting the shard list to the openshift-tests '--file'.
+func (p *Plugin) InitializeShard() error {
+	if !p.IsSharded() {
+		return nil
+	}
+	switch p.id {
+	case PluginId10, PluginId20:
+	default:
+		log.Infof("Skipping sharding in plugin %s.", p.name)
+		return nil
+	}
+
+	var durations map[string]float64
+	if len(p.ShardDurationsFile) > 0 {
+		var err error
+		durations, err = LoadShardDurations(p.ShardDurationsFile)
+		if err != nil {
+			log.Errorf("unable to load shard durations, sharding by hash: %v", err)
+		}
+	}
+	tests, err := ShardTests(p.SuiteTests, p.ShardIndex, p.ShardCount, durations)
+	if err != nil {
+		return fmt.Errorf("error sharding the suite: %w", err)
+	}
+	log.Infof("Shard %d/%d: running %d of %d tests", p.ShardIndex, p.ShardCount, len(tests), len(p.SuiteTests))
+
+	p.SuiteTests = make(map[string]struct{}, len(tests))
+	suiteListData := ""
+	for _, testName := range tests {
+		p.SuiteTests[testName] = struct{}{}
+		suiteListData += testName + "\n"
+	}
+	shardFile := p.workspace().Shared(fmt.Sprintf("suite%s.list", p.ShardSuffix()))
+	log.Infof("Writing the shard suite list to file %s", shardFile)
+	if err := os.WriteFile(shardFile, []byte(suiteListData), 0644); err != nil {
+		return fmt.Errorf("error saving shard suite list to file: %w", err)
+	}
+
+	// Update 'openshift-tests run' flag '--file' to active execution with the shard suite file
+	p.OTRunner.File = shardFile
+	return nil
+}
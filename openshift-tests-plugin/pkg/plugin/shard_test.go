@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestSuite creates a suite with the given number of tests.
+func newTestSuite(total int) map[string]struct{} {
+	tests := make(map[string]struct{}, total)
+	for i := 0; i < total; i++ {
+		tests[fmt.Sprintf(`"[sig-test] test %03d [Suite:openshift/conformance/parallel]"`, i)] = struct{}{}
+	}
+	return tests
+}
+
+// TestShardTests tests the suite is deterministically partitioned, assigning each test to one shard.
+func TestShardTests(t *testing.T) {
+	suite := newTestSuite(100)
+	durations := map[string]float64{}
+	idx := 0
+	for name := range suite {
+		durations[name] = float64(idx % 7)
+		idx++
+	}
+	for _, tc := range []struct {
+		name      string
+		durations map[string]float64
+	}{
+		{name: "by hash"},
+		{name: "by duration", durations: durations},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			count := 4
+			seen := map[string]int{}
+			totals := make([]float64, count)
+			for index := 0; index < count; index++ {
+				shard, err := ShardTests(suite, index, count, tc.durations)
+				if err != nil {
+					t.Fatalf("ShardTests(%d/%d) unexpected error: %v", index, count, err)
+				}
+				again, _ := ShardTests(suite, index, count, tc.durations)
+				if strings.Join(shard, "\n") != strings.Join(again, "\n") {
+					t.Errorf("ShardTests(%d/%d) is not deterministic", index, count)
+				}
+				for _, name := range shard {
+					seen[name]++
+					totals[index] += tc.durations[name]
+				}
+			}
+			if len(seen) != len(suite) {
+				t.Errorf("shards have %d tests, want %d", len(seen), len(suite))
+			}
+			for name, n := range seen {
+				if n != 1 {
+					t.Errorf("test %s assigned to %d shards, want 1", name, n)
+				}
+			}
+			if tc.durations == nil {
+				return
+			}
+			for index := 1; index < count; index++ {
+				if diff := totals[index] - totals[0]; diff > 6 || diff < -6 {
+					t.Errorf("unbalanced shards by duration: %v", totals)
+				}
+			}
+		})
+	}
+
+	for _, tc := range []struct{ index, count int }{{0, 0}, {-1, 2}, {2, 2}} {
+		if _, err := ShardTests(suite, tc.index, tc.count, nil); err == nil {
+			t.Errorf("ShardTests(%d/%d) expected error, got nil", tc.index, tc.count)
+		}
+	}
+}
+
+// TestShardSuffix tests the naming of shard outputs.
+func TestShardSuffix(t *testing.T) {
+	p := &Plugin{id: PluginId20}
+	if got := p.ShardSuffix(); got != "" {
+		t.Errorf("ShardSuffix() without sharding = %q, want empty", got)
+	}
+	p.ShardIndex, p.ShardCount = 2, 4
+	if got := p.ShardSuffix(); got != "-shard-2" {
+		t.Errorf("ShardSuffix() = %q, want %q", got, "-shard-2")
+	}
+}
+
+// TestExtractTestsToReplayShards tests the replay list merges the failures from sharded plugins.
+func TestExtractTestsToReplayShards(t *testing.T) {
+	newCM := func(name, id, data string) *kcorev1.ConfigMap {
+		cm := &kcorev1.ConfigMap{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: name, Namespace: EnvNamespace},
+			Data:       map[string]string{"replay.list": data},
+		}
+		if len(id) > 0 {
+			cm.Labels = map[string]string{FailuresLabel: id}
+		}
+		return cm
+	}
+	p, err := NewPlugin(PluginName80)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	p.SuiteFile = filepath.Join(t.TempDir(), "suite.list")
+	p.clientKube = kfake.NewSimpleClientset(
+		newCM("plugin-failures-10", PluginId10, `"[sig-a] test a"`),
+		newCM("plugin-failures-20-shard-0", PluginId20, `"[sig-b] test b"`),
+		newCM("plugin-failures-20-shard-1", PluginId20, "\"[sig-c] test c\"\n[sig-arch] External binary usage"),
+		newCM("plugin-failures-200", "200", `"[sig-d] ignored"`),
+		newCM("plugin-failures-20-copy", "", `"[sig-e] ignored"`),
+	)
+	if err := p.ExtractTestsToReplay(); err != nil {
+		t.Fatalf("ExtractTestsToReplay() unexpected error: %v", err)
+	}
+	want := []string{`"[sig-a] test a"`, `"[sig-b] test b"`, `"[sig-c] test c"`}
+	if len(p.SuiteTests) != len(want) {
+		t.Errorf("SuiteTests = %v, want %v", p.SuiteTests, want)
+	}
+	for _, name := range want {
+		if _, ok := p.SuiteTests[name]; !ok {
+			t.Errorf("SuiteTests missing test %s", name)
+		}
+	}
+	if p.OTRunner.File != p.SuiteFile {
+		t.Errorf("OTRunner.File = %q, want %q", p.OTRunner.File, p.SuiteFile)
+	}
+	if _, err := os.Stat(p.SuiteFile); err != nil {
+		t.Errorf("suite file not created: %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// RunnerModeSharedVolume runs openshift-tests in the tests container, through
+	// the start script and done file in the shared volume. Default.
+	RunnerModeSharedVolume = "shared-volume"
+	// RunnerModeSubprocess runs openshift-tests as a subprocess of the plugin,
+	// when the binaries are co-located in the same container.
+	RunnerModeSubprocess = "subprocess"
+
+	// RunnerKillGracePeriod is the time to wait the process group to finish after
+	// SIGTERM, before sending SIGKILL.
+	RunnerKillGracePeriod = 30 * time.Second
+	// RunnerStderrTailLines is the number of stderr lines kept in the runner status.
+	RunnerStderrTailLines = 50
+	// RunnerMaxLineBytes is the length limit of the output lines, the longer lines
+	// are truncated.
+	RunnerMaxLineBytes = 1024 * 1024
+
+	// RunnerStatusJUnit records the exit status of the runner, merged into the results
+	// JUnit.
+	RunnerStatusJUnit = "junit_e2e_runner_status.xml"
+)
+
+// RunnerStatus holds the exit status of the openshift-tests execution.
+type RunnerStatus struct {
+	ExitCode   int       `json:"exitCode"`
+	Signal     string    `json:"signal,omitempty"`
+	TimedOut   bool      `json:"timedOut,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Command    []string  `json:"command"`
+	Stderr     []string  `json:"stderr,omitempty"`
+}
+
+// Succeeded returns true when the process exited with code zero.
+func (s *RunnerStatus) Succeeded() bool {
+	return s.ExitCode == 0 && len(s.Signal) == 0 && !s.TimedOut
+}
+
+// String returns the summary of the exit status.
+func (s *RunnerStatus) String() string {
+	msg := fmt.Sprintf("exit code %d", s.ExitCode)
+	if len(s.Signal) > 0 {
+		msg = fmt.Sprintf("%s, signal %s", msg, s.Signal)
+	}
+	if s.TimedOut {
+		msg = fmt.Sprintf("%s, timed out", msg)
+	}
+	return fmt.Sprintf("%s, duration %v", msg, s.FinishedAt.Sub(s.StartedAt).Truncate(time.Second))
+}
+
+// ReadRunnerStatus reads the done record written by the run script.
+func ReadRunnerStatus(path string) (*RunnerStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading done record %s: %w", path, err)
+	}
+	status := &RunnerStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("error parsing done record %s: %w", path, err)
+	}
+	return status, nil
+}
+
+// tailBuffer keeps the last lines written to it.
+type tailBuffer struct {
+	sync.Mutex
+	size  int
+	lines []string
+}
+
+// Add appends the line, discarding the oldest when the buffer is full.
+func (b *tailBuffer) Add(line string) {
+	b.Lock()
+	defer b.Unlock()
+	b.lines = append(b.lines, line)
+	if len(b.lines) > b.size {
+		b.lines = b.lines[len(b.lines)-b.size:]
+	}
+}
+
+// Lines returns a copy of the lines in the buffer.
+func (b *tailBuffer) Lines() []string {
+	b.Lock()
+	defer b.Unlock()
+	return append([]string{}, b.lines...)
+}
+
+// RunProcess runs the command in a new process group, sending each stdout line to
+// the onStdout callback, and keeping the last stderr lines. When the context is
+// done, the process group is terminated with SIGTERM, and SIGKILL after the grace period.
+func RunProcess(ctx context.Context, args []string, onStdout func(line string)) (*RunnerStatus, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	status := &RunnerStatus{Command: args}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating stderr pipe: %w", err)
+	}
+
+	status.StartedAt = time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting command %s: %w", args[0], err)
+	}
+	pgid := cmd.Process.Pid
+
+	// Enforce the timeout killing the process group.
+	waitDone := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Warnf("Terminating process group %d: %v", pgid, ctx.Err())
+			_ = syscall.Kill(-pgid, syscall.SIGTERM)
+			select {
+			case <-waitDone:
+			case <-time.After(RunnerKillGracePeriod):
+				log.Warnf("Killing process group %d after grace period %v", pgid, RunnerKillGracePeriod)
+				_ = syscall.Kill(-pgid, syscall.SIGKILL)
+			}
+		case <-waitDone:
+		}
+	}()
+
+	stderrTail := &tailBuffer{size: RunnerStderrTailLines}
+	var wg sync.WaitGroup
+	readLines := func(r io.Reader, w io.Writer, callback func(string)) {
+		defer wg.Done()
+		reader := bufio.NewReaderSize(r, 64*1024)
+		for {
+			line, truncated, err := readLine(reader)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					log.Errorf("error reading output of %s: %v", args[0], err)
+				}
+				// keep draining the pipe, not blocking the process on a full pipe.
+				_, _ = io.Copy(io.Discard, r)
+				return
+			}
+			if truncated {
+				log.Warnf("output line of %s truncated to %d bytes", args[0], RunnerMaxLineBytes)
+			}
+			fmt.Fprintln(w, line)
+			callback(line)
+		}
+	}
+	wg.Add(2)
+	go readLines(stdout, os.Stdout, onStdout)
+	go readLines(stderr, os.Stderr, stderrTail.Add)
+	wg.Wait()
+
+	err = cmd.Wait()
+	close(waitDone)
+	status.FinishedAt = time.Now()
+	status.Stderr = stderrTail.Lines()
+	status.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if cmd.ProcessState == nil {
+		return status, fmt.Errorf("error waiting command %s: %w", args[0], err)
+	}
+	status.ExitCode = cmd.ProcessState.ExitCode()
+	if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.Signal = unix.SignalName(ws.Signal())
+	}
+	return status, nil
+}
+
+// readLine reads the next line, truncated to RunnerMaxLineBytes, discarding the remaining
+// bytes of the longer lines.
+func readLine(r *bufio.Reader) (line string, truncated bool, err error) {
+	var buf []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			// the last line without line break is returned before the error.
+			if len(buf) > 0 {
+				return string(buf), truncated, nil
+			}
+			return "", false, err
+		}
+		if keep := RunnerMaxLineBytes - len(buf); len(chunk) > keep {
+			chunk, truncated = chunk[:max(keep, 0)], true
+		}
+		buf = append(buf, chunk...)
+		if !isPrefix {
+			return string(buf), truncated, nil
+		}
+	}
+}
+
+// initRunnerMode sets the runner mode from environment OPENSHIFT_TESTS_RUNNER_MODE,
+// falling back to shared-volume when openshift-tests is not co-located.
+func (p *Plugin) initRunnerMode() {
+	envMode := p.settings().Runner.Mode
+	switch envMode {
+	case "":
+		return
+	case RunnerModeSharedVolume:
+		p.RunnerMode = RunnerModeSharedVolume
+	case RunnerModeSubprocess:
+		if p.Runner == nil {
+			log.Warnf("Runner mode %q is not supported by plugin %s, using %q", envMode, p.name, p.RunnerMode)
+			return
+		}
+		binPath := p.Runner.Args()[0]
+		if _, err := exec.LookPath(binPath); err != nil {
+			log.Warnf("Runner mode %q requires %s binary at %s: %v. Using %q", envMode, p.Runner.Name(), binPath, err, p.RunnerMode)
+			return
+		}
+		p.RunnerMode = RunnerModeSubprocess
+	default:
+		log.Errorf("invalid OPENSHIFT_TESTS_RUNNER_MODE %q, using default %q", envMode, p.RunnerMode)
+	}
+}
+
+// RunSubprocess runs the test runner as a subprocess, parsing the output to report
+// the progress, and recording the exit status in the JUnit when the runner has not
+// written the results.
+func (p *Plugin) RunSubprocess() error {
+	ctx := context.Background()
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	log.Infof("Running %s as subprocess (timeout %v): %s", p.Runner.Name(), p.Timeout, strings.Join(p.Runner.Args(), " "))
+	status, err := RunProcess(ctx, p.Runner.Args(), p.processOutputLine)
+	if err != nil {
+		return fmt.Errorf("error running %s: %w", p.Runner.Name(), err)
+	}
+	p.recordRunnerStatus(status)
+	p.DoneControl = true
+	return nil
+}
+
+// writeRunnerStatusJUnit records the runner exit status in the JUnit directory. The
+// status is a failure when the runner crashed, was killed or timed out, or exited with
+// error without the JUnit results: the failed tests are reported in the JUnit results.
+func (p *Plugin) writeRunnerStatusJUnit(status *RunnerStatus) error {
+	junit := &JUnitTestReport{
+		Filepath: filepath.Join(p.OTRunner.JUnitDir, RunnerStatusJUnit),
+		Result:   "passed",
+		Name:     fmt.Sprintf("[opct] %s runner completed", p.Runner.Name()),
+		Message:  fmt.Sprintf("%s finished with %s", p.Runner.Name(), status),
+	}
+	junit.Output = junit.Message
+	crashed := len(status.Signal) > 0 || status.TimedOut
+	if crashed || (!status.Succeeded() && !p.hasRunnerJUnit()) {
+		junit.Result = "failed"
+		junit.Output = fmt.Sprintf("%s\ncommand: %s\nstderr (last %d lines):\n%s", junit.Message,
+			strings.Join(status.Command, " "), len(status.Stderr), strings.Join(status.Stderr, "\n"))
+	}
+	return NewJUnitTestReport(junit).Write()
+}
+
+// processDoneRecord reads the done record written by the tests container, recording
+// the exit status in the JUnit: a failure when the runner crashed, or was killed (e.g.
+// OOM).
+func (p *Plugin) processDoneRecord(path string) {
+	status, err := ReadRunnerStatus(path)
+	if err != nil {
+		log.Warnf("Run: unable to read %s exit status: %v", p.Runner.Name(), err)
+		return
+	}
+	p.recordRunnerStatus(status)
+}
+
+// recordRunnerStatus logs the exit status of the runner, and records it in the JUnit
+// merged into the results.
+func (p *Plugin) recordRunnerStatus(status *RunnerStatus) {
+	if status.Succeeded() {
+		log.Infof("Run: %s finished with %s", p.Runner.Name(), status)
+	} else {
+		log.Warnf("Run: %s finished with %s", p.Runner.Name(), status)
+		for _, line := range status.Stderr {
+			log.Warnf("Run: %s stderr: %s", p.Runner.Name(), line)
+		}
+		if !p.hasRunnerJUnit() {
+			log.Errorf("Run: %s finished without JUnit results in %s", p.Runner.Name(), p.OTRunner.JUnitDir)
+		}
+	}
+	if err := p.writeRunnerStatusJUnit(status); err != nil {
+		log.Errorf("unable to write runner status JUnit: %v", err)
+	}
+}
+
+// hasRunnerJUnit checks if the runner has written the JUnit results.
+func (p *Plugin) hasRunnerJUnit() bool {
+	files, err := p.Runner.JUnitFiles()
+	return err == nil && len(files) > 0
+}
@@ -0,0 +1,265 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFakeBinary creates an executable script simulating openshift-tests.
+func writeFakeBinary(t *testing.T, script string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "openshift-tests")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("error creating fake binary: %v", err)
+	}
+	return bin
+}
+
+// TestRunProcess tests the subprocess output is read and the exit status is captured.
+func TestRunProcess(t *testing.T) {
+	bin := writeFakeBinary(t, `echo 'started: 0/1/1 "[sig-a] test"'
+echo 'passed: (1.2s) 2024-07-05T20:27:49 "[sig-a] test"'
+echo "error: something went wrong" >&2
+exit 3
+`)
+	lines := []string{}
+	status, err := RunProcess(context.Background(), []string{bin, "run", "openshift/conformance"}, func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("RunProcess() unexpected error: %v", err)
+	}
+	if status.ExitCode != 3 || status.Succeeded() {
+		t.Errorf("RunProcess() exit code = %d (succeeded=%v), want 3", status.ExitCode, status.Succeeded())
+	}
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "passed:") {
+		t.Errorf("RunProcess() stdout lines = %q, want started and passed lines", lines)
+	}
+	if len(status.Stderr) != 1 || status.Stderr[0] != "error: something went wrong" {
+		t.Errorf("RunProcess() stderr = %q, want error line", status.Stderr)
+	}
+	if status.FinishedAt.Before(status.StartedAt) {
+		t.Errorf("RunProcess() finished %v before started %v", status.FinishedAt, status.StartedAt)
+	}
+}
+
+// TestRunProcessTimeout tests the process group is terminated when the timeout is reached.
+func TestRunProcessTimeout(t *testing.T) {
+	bin := writeFakeBinary(t, "sleep 30 &\nwait\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	status, err := RunProcess(ctx, []string{bin}, func(string) {})
+	if err != nil {
+		t.Fatalf("RunProcess() unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("RunProcess() took %v, want the process group terminated by timeout", elapsed)
+	}
+	if !status.TimedOut || status.Signal != "SIGTERM" || status.Succeeded() {
+		t.Errorf("RunProcess() status = %+v, want timed out and terminated", status)
+	}
+}
+
+// TestRunProcessLongLine tests the lines longer than the limit are truncated, and the
+// output is read until the process finishes.
+func TestRunProcessLongLine(t *testing.T) {
+	bin := writeFakeBinary(t, `head -c 3145728 /dev/zero | tr '\0' 'x'
+echo
+echo 'passed: (1.2s) 2024-07-05T20:27:49 "[sig-a] test"'
+head -c 3145728 /dev/zero | tr '\0' 'y'
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	lines := []string{}
+	status, err := RunProcess(ctx, []string{bin}, func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("RunProcess() unexpected error: %v", err)
+	}
+	if status.TimedOut || !status.Succeeded() {
+		t.Errorf("RunProcess() status = %+v, want the process finished", status)
+	}
+	if len(lines) != 3 || len(lines[0]) != RunnerMaxLineBytes || !strings.HasPrefix(lines[1], "passed:") || len(lines[2]) != RunnerMaxLineBytes {
+		t.Errorf("RunProcess() read %d lines, want the long lines truncated and the passed line", len(lines))
+	}
+}
+
+// TestRunSubprocessStatusJUnit tests the exit status is recorded in the JUnit, reported
+// as failure only when the runner exited with failure without producing the JUnit results.
+func TestRunSubprocessStatusJUnit(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantFailure bool
+		want        string
+	}{
+		{name: "tests failed with results", script: "echo '<testsuite/>' > \"$JUNIT_DIR/junit_e2e__20240705-202749.xml\"\nexit 1\n", want: "exit code 1"},
+		{name: "crashed without results", script: "echo 'fatal error: out of memory' >&2\nexit 2\n", wantFailure: true, want: "exit code 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPlugin(PluginName20)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			p.OTRunner.JUnitDir = t.TempDir()
+			p.OTRunner.BinPath = writeFakeBinary(t, tt.script)
+			t.Setenv("JUNIT_DIR", p.OTRunner.JUnitDir)
+
+			if err := p.RunSubprocess(); err != nil {
+				t.Fatalf("RunSubprocess() unexpected error: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(p.OTRunner.JUnitDir, RunnerStatusJUnit))
+			if err != nil {
+				t.Fatalf("RunSubprocess() runner status JUnit not found: %v", err)
+			}
+			if strings.Contains(string(data), "<failure") != tt.wantFailure || !strings.Contains(string(data), tt.want) {
+				t.Errorf("RunSubprocess() runner status JUnit = %s, want failure %v with %q", string(data), tt.wantFailure, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteRunnerStatusJUnit tests the exit status is recorded in the JUnit.
+func TestWriteRunnerStatusJUnit(t *testing.T) {
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	p.OTRunner.JUnitDir = t.TempDir()
+	now := time.Now()
+	status := &RunnerStatus{
+		ExitCode:   -1,
+		Signal:     "SIGKILL",
+		StartedAt:  now.Add(-time.Minute),
+		FinishedAt: now,
+		Command:    []string{OpenShiftTestsBinPath, "run"},
+		Stderr:     []string{"fatal error: out of memory"},
+	}
+	if err := p.writeRunnerStatusJUnit(status); err != nil {
+		t.Fatalf("writeRunnerStatusJUnit() unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(p.OTRunner.JUnitDir, "junit_e2e_runner_status.xml"))
+	if err != nil {
+		t.Fatalf("error reading JUnit: %v", err)
+	}
+	for _, want := range []string{"<failure", "signal SIGKILL", "fatal error: out of memory"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JUnit = %s, want to contain %q", string(data), want)
+		}
+	}
+}
+
+// TestProcessDoneRecord tests the exit status is recorded in the JUnit, reported as
+// failure when openshift-tests crashed, even with partial JUnit results.
+func TestProcessDoneRecord(t *testing.T) {
+	crashed := `{"exitCode":137,"signal":"SIGKILL","startedAt":"2024-07-05T20:00:00Z","finishedAt":"2024-07-05T20:27:49Z",
+"command":["/usr/bin/openshift-tests","run","openshift/conformance"],"stderr":["fatal error: out of memory"]}`
+	tests := []struct {
+		name        string
+		record      string
+		results     bool
+		wantJUnit   bool
+		wantFailure bool
+		want        []string
+	}{
+		{name: "succeeded", record: `{"exitCode":0,"startedAt":"2024-07-05T20:00:00Z","finishedAt":"2024-07-05T20:27:49Z"}`,
+			wantJUnit: true, want: []string{"exit code 0"}},
+		{name: "tests failed with results", record: `{"exitCode":1,"startedAt":"2024-07-05T20:00:00Z","finishedAt":"2024-07-05T20:27:49Z"}`, results: true,
+			wantJUnit: true, want: []string{"exit code 1"}},
+		{name: "crashed without results", record: crashed, wantJUnit: true, wantFailure: true,
+			want: []string{"exit code 137, signal SIGKILL", "fatal error: out of memory"}},
+		{name: "crashed with results", record: crashed, results: true, wantJUnit: true, wantFailure: true,
+			want: []string{"exit code 137, signal SIGKILL", "fatal error: out of memory"}},
+		{name: "missing record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPlugin(PluginName20)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			p.OTRunner.JUnitDir = t.TempDir()
+			record := filepath.Join(t.TempDir(), "done.json")
+			if len(tt.record) > 0 {
+				if err := os.WriteFile(record, []byte(tt.record), 0644); err != nil {
+					t.Fatalf("error writing done record: %v", err)
+				}
+			}
+			if tt.results {
+				if err := os.WriteFile(filepath.Join(p.OTRunner.JUnitDir, "junit_e2e__20240705-202749.xml"), []byte("<testsuite/>"), 0644); err != nil {
+					t.Fatalf("error writing JUnit: %v", err)
+				}
+			}
+
+			p.processDoneRecord(record)
+
+			data, err := os.ReadFile(filepath.Join(p.OTRunner.JUnitDir, RunnerStatusJUnit))
+			if !tt.wantJUnit {
+				if err == nil {
+					t.Errorf("processDoneRecord() wrote unexpected runner status JUnit: %s", string(data))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("processDoneRecord() runner status JUnit not found: %v", err)
+			}
+			if strings.Contains(string(data), "<failure") != tt.wantFailure {
+				t.Errorf("JUnit = %s, want failure %v", string(data), tt.wantFailure)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("JUnit = %s, want to contain %q", string(data), want)
+				}
+			}
+		})
+	}
+}
+
+// TestProcessJUnitRunnerStatus tests the runner exit status is reported in the packaged
+// results with the JUnit of the runner.
+func TestProcessJUnitRunnerStatus(t *testing.T) {
+	p := newProcessJUnitPlugin(t)
+	p.recordRunnerStatus(&RunnerStatus{ExitCode: -1, Signal: "SIGKILL", TimedOut: true})
+	if err := p.ProcessJUnit(); err != nil {
+		t.Fatalf("ProcessJUnit() unexpected error: %v", err)
+	}
+	junit := packagedJUnit(t, p)
+	for _, want := range []string{"[sig-node] test a", "[opct] openshift-tests runner completed", "signal SIGKILL, timed out", `failures="1"`} {
+		if !strings.Contains(junit, want) {
+			t.Errorf("packaged JUnit = %s, want to contain %q", junit, want)
+		}
+	}
+}
+
+// TestProcessJUnitRunnerCrashed tests the runner crash is the primary JUnit of the results
+// without the runner results, with the failure of the blocker plugin merged.
+func TestProcessJUnitRunnerCrashed(t *testing.T) {
+	p := newProcessJUnitPlugin(t)
+	runnerResults, err := p.Runner.JUnitFiles()
+	if err != nil || len(runnerResults) != 1 || os.Remove(runnerResults[0]) != nil {
+		t.Fatalf("unable to remove the runner results %v: %v", runnerResults, err)
+	}
+	p.BlockerFailurePolicy = BlockerFailurePolicyContinue
+	if err := p.handleBlockerFailed(PluginName10); err != nil {
+		t.Fatalf("handleBlockerFailed() unexpected error: %v", err)
+	}
+	p.recordRunnerStatus(&RunnerStatus{ExitCode: 137, Signal: "SIGKILL", Stderr: []string{"fatal error: out of memory"}})
+	if err := p.ProcessJUnit(); err != nil {
+		t.Fatalf("ProcessJUnit() unexpected error: %v", err)
+	}
+	junit := packagedJUnit(t, p)
+	for _, want := range []string{"[opct] openshift-tests runner completed", "exit code 137, signal SIGKILL", "fatal error: out of memory",
+		"[opct] blocker plugin " + PluginName10 + " completed successfully", `failures="2"`} {
+		if !strings.Contains(junit, want) {
+			t.Errorf("packaged JUnit = %s, want to contain %q", junit, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/discovery"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SuiteDiscoveryResult is the suite discovered by the tests container (exec discover-suite).
+	SuiteDiscoveryResult = "/tmp/shared/suite.discovery.json"
+	// SuiteDiscoveryJUnit reports the errors discovering the suite.
+	SuiteDiscoveryJUnit = "junit_e2e_discovery_error.xml"
+
+	// K8sConformanceTestsList is the Kubernetes conformance tests extracted by the init
+	// container on OpenShift 4.20+, and InitErrorFile the error extracting the tests.
+	K8sConformanceTestsList = "/tmp/shared/k8s-conformance-tests.list"
+	InitErrorFile           = "/tmp/shared/init-error.log"
+)
+
+// SuiteCandidates returns the suites of the plugin by priority for the OpenShift version:
+// the suite informed by the user (override), followed by the suites of the version matrix.
+// The suites of all versions are returned when the version is unknown.
+func (p *Plugin) SuiteCandidates(ocpVersion, override string) []string {
+	candidates := []string{}
+	if len(override) > 0 {
+		candidates = append(candidates, override)
+	}
+	if p.VersionMatrix == nil {
+		return candidates
+	}
+	for _, suite := range p.VersionMatrix.Resolve(p.id, ocpVersion).Suites {
+		if !slices.Contains(candidates, suite) {
+			candidates = append(candidates, suite)
+		}
+	}
+	return candidates
+}
+
+// WriteSuiteDiscoveryJUnit reports the errors discovering the suite in the JUnit
+// directory, processed by the plugin as the results.
+func WriteSuiteDiscoveryJUnit(junitDir string, result *discovery.Result) error {
+	if err := os.MkdirAll(junitDir, 0755); err != nil {
+		return err
+	}
+	return NewJUnitTestReport(&JUnitTestReport{
+		Filepath: filepath.Join(junitDir, SuiteDiscoveryJUnit),
+		Result:   "failed",
+		Name:     fmt.Sprintf("[opct] suite discovery for plugin %s", result.Plugin),
+		Message:  fmt.Sprintf("unable to discover the tests of the suites %v", result.Candidates),
+		Output:   strings.Join(result.Errors, "\n"),
+	}).Write()
+}
+
+// applySuiteDiscovery sets the suite discovered by the tests container. When the
+// discovery failed, the plugin finishes without running the tests, reporting the
+// discovery errors in the JUnit.
+func (p *Plugin) applySuiteDiscovery(file string) {
+	result, err := discovery.ReadResult(file)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Errorf("unable to read the suite discovery result: %v", err)
+		}
+		return
+	}
+	for _, msg := range result.Errors {
+		log.Warnf("Suite discovery: %s", msg)
+	}
+	if result.Failed() {
+		log.Errorf("Suite discovery failed for plugin %s, skipping the tests execution", p.name)
+		p.discoveryFailed = true
+		return
+	}
+	log.Infof("Suite discovery: using suite %s with %d tests from %s (%s)", result.Suite, result.Count, result.Source, result.SourceKind)
+	p.SuiteName = result.Suite
+	if p.OTRunner == nil {
+		return
+	}
+	p.OTRunner.SuiteName = result.Suite
+	if result.RunWithFile {
+		p.OTRunner.File = result.SuiteList
+	}
+}
@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/discovery"
+)
+
+// suite10Parallel is the Kubernetes conformance suite on OpenShift 4.20+.
+const suite10Parallel = "kubernetes/conformance/parallel"
+
+func TestSuiteCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   string
+		version  string
+		override string
+		want     []string
+	}{
+		{name: "kube conformance 4.19", plugin: PluginName10, version: "4.19.10", want: []string{PluginSuite10}},
+		{name: "kube conformance 4.20", plugin: PluginName10, version: "4.20.0-rc.1", want: []string{suite10Parallel}},
+		{name: "kube conformance 5.0", plugin: PluginName10, version: "5.0.0", want: []string{suite10Parallel}},
+		{name: "kube conformance unknown version", plugin: PluginName10, want: []string{suite10Parallel, PluginSuite10}},
+		{name: "override first", plugin: PluginName10, version: "4.19.0", override: suite10Parallel,
+			want: []string{suite10Parallel, PluginSuite10}},
+		{name: "override deduplicated", plugin: PluginName20, version: "4.20.0", override: PluginSuite20, want: []string{PluginSuite20}},
+		{name: "openshift conformance", plugin: PluginName20, version: "4.20.0", want: []string{PluginSuite20}},
+		{name: "no default suite", plugin: PluginName05, version: "4.20.0", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPlugin(tt.plugin)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			if got := p.SuiteCandidates(tt.version, tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuiteCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySuiteDiscovery(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name       string
+		result     *discovery.Result
+		wantSuite  string
+		wantFile   string
+		wantFailed bool
+	}{
+		{
+			name:      "missing result",
+			wantSuite: PluginSuite10,
+		},
+		{
+			name: "openshift-tests suite",
+			result: &discovery.Result{Suite: PluginSuite10, Source: OpenShiftTestsBinPath, SourceKind: discovery.SourceKindOpenShiftTests,
+				SuiteList: "/tmp/suite.list", Count: 10},
+			wantSuite: PluginSuite10,
+		},
+		{
+			name: "extension suite runs with the suite list",
+			result: &discovery.Result{Suite: suite10Parallel, Source: "k8s-tests-ext", SourceKind: discovery.SourceKindExtension,
+				RunWithFile: true, SuiteList: "/tmp/suite.list", Count: 10},
+			wantSuite: suite10Parallel,
+			wantFile:  "/tmp/suite.list",
+		},
+		{
+			name:       "failed",
+			result:     &discovery.Result{Candidates: []string{PluginSuite10}, Errors: []string{"none of the suites is available"}},
+			wantSuite:  PluginSuite10,
+			wantFailed: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".json")
+			if tt.result != nil {
+				if err := tt.result.Save(file); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := NewPlugin(PluginName10)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			p.SuiteName = PluginSuite10
+			p.OTRunner.SuiteName = PluginSuite10
+			p.applySuiteDiscovery(file)
+			if p.SuiteName != tt.wantSuite || p.OTRunner.SuiteName != tt.wantSuite {
+				t.Errorf("#%d suite = %q (runner %q), want %q", i, p.SuiteName, p.OTRunner.SuiteName, tt.wantSuite)
+			}
+			if p.OTRunner.File != tt.wantFile {
+				t.Errorf("#%d runner file = %q, want %q", i, p.OTRunner.File, tt.wantFile)
+			}
+			if p.discoveryFailed != tt.wantFailed {
+				t.Errorf("#%d discoveryFailed = %v, want %v", i, p.discoveryFailed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestWriteSuiteDiscoveryJUnit(t *testing.T) {
+	dir := t.TempDir()
+	result := &discovery.Result{Plugin: PluginName10, Candidates: []string{suite10Parallel},
+		Errors: []string{"unable to list the suites of /usr/bin/k8s-tests-ext: exit status 1"}}
+	if err := WriteSuiteDiscoveryJUnit(dir, result); err != nil {
+		t.Fatalf("WriteSuiteDiscoveryJUnit() unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, SuiteDiscoveryJUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<failure") || !strings.Contains(string(data), "k8s-tests-ext") {
+		t.Errorf("JUnit does not report the discovery errors:\n%s", data)
+	}
+}
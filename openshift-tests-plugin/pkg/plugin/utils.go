@@ -2,7 +2,10 @@ package plugin
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -25,3 +28,34 @@ func watchForFile(filePath string, doneCallback func()) error {
 	}
 	return nil
 }
+
+// shareBinary copies the running binary to dst, allowing the tests container to
+// call the plugin commands.
+func shareBinary(dst string) error {
+	src, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error discovering the executable: %w", err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("error copying %s: %w", src, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
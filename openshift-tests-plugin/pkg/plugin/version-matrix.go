@@ -0,0 +1,133 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/matrix"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EnvVersionMatrixFile is the version matrix file replacing the built-in matrix.
+	EnvVersionMatrixFile = "VERSION_MATRIX_FILE"
+
+	// VersionExcludedJUnit reports the tests removed from the suite by the version
+	// matrix. The name must be sorted after the openshift-tests JUnit.
+	VersionExcludedJUnit = "junit_e2e_skip_excluded.xml"
+)
+
+// initVersionConfig resolves the settings of the version matrix for the cluster
+// version, read from the ClusterVersion. The defaults are kept when the version is
+// not available.
+func (p *Plugin) initVersionConfig() {
+	if p.clientConfig == nil || p.VersionMatrix == nil {
+		return
+	}
+	version, err := clusterVersion(p.clientConfig)
+	if err != nil {
+		log.Warnf("unable to read the cluster version, using the version matrix defaults: %v", err)
+		return
+	}
+	cfg := p.ResolveVersionConfig(version)
+	log.Infof("Version matrix: OpenShift %s, applied %v: suite=%q monitors=%q maxParallel=%d timeout=%s excludedTests=%d",
+		version, cfg.Matched, cfg.Suite, cfg.Monitors, cfg.MaxParallel, cfg.Timeout.Duration, len(cfg.ExcludedTests))
+}
+
+// ResolveVersionConfig resolves the settings of the version matrix for the OpenShift
+// version, and sets them in the plugin.
+func (p *Plugin) ResolveVersionConfig(version string) *matrix.Config {
+	cfg := p.VersionMatrix.Resolve(p.id, version)
+	p.applyVersionConfig(cfg)
+	return cfg
+}
+
+// applyVersionConfig sets the plugin settings resolved by the version matrix. The
+// suite informed in DEFAULT_SUITE_NAME, and the monitors in OPENSHIFT_TESTS_MONITORS,
+// have priority over the settings of the matrix.
+func (p *Plugin) applyVersionConfig(cfg *matrix.Config) {
+	p.VersionConfig = cfg
+	if cfg.Timeout.Duration > 0 {
+		p.Timeout = cfg.Timeout.Duration
+	}
+	if len(cfg.Suite) > 0 && len(p.settings().DefaultSuiteName) == 0 {
+		p.SuiteName = cfg.Suite
+		if p.OTRunner != nil {
+			p.OTRunner.SuiteName = cfg.Suite
+		}
+	}
+	p.ExcludedTestPatterns = nil
+	for _, pattern := range cfg.ExcludedTests {
+		// validated when parsing the matrix.
+		p.ExcludedTestPatterns = append(p.ExcludedTestPatterns, regexp.MustCompile(pattern))
+	}
+	if p.OTRunner == nil {
+		return
+	}
+	p.OTRunner.Monitortests = cfg.Monitors
+	if monitors := p.settings().Runner.Monitors; monitors != nil {
+		p.OTRunner.Monitortests = *monitors
+	}
+	p.OTRunner.MaxParallel = strconv.Itoa(cfg.MaxParallel)
+}
+
+// FilterExcludedTests removes the tests excluded by the version matrix from the suite,
+// reporting them as skipped in the JUnit VersionExcludedJUnit.
+func (p *Plugin) FilterExcludedTests() error {
+	if p.OTRunner == nil || len(p.ExcludedTestPatterns) == 0 {
+		return nil
+	}
+	removed := p.removeSuiteTests(p.ExcludedTestPatterns)
+	if len(removed) == 0 {
+		return nil
+	}
+	version := ""
+	if p.VersionConfig != nil {
+		version = p.VersionConfig.Version
+	}
+	reports := make([]*JUnitTestReport, 0, len(removed))
+	for _, name := range removed {
+		reports = append(reports, &JUnitTestReport{
+			Name:    name,
+			Result:  "skipped",
+			Message: fmt.Sprintf("[opct] test excluded from the suite on OpenShift %s by the version matrix", version),
+		})
+	}
+	if err := WriteJUnitTestSuite(filepath.Join(p.OTRunner.JUnitDir, VersionExcludedJUnit), "opct-excluded", reports); err != nil {
+		return err
+	}
+
+	suiteFile := filepath.Join(filepath.Dir(p.SuiteFile), "suite-excluded.list")
+	if err := WriteTestSuite(p.SuiteTests, suiteFile); err != nil {
+		return fmt.Errorf("error saving suite list to file: %w", err)
+	}
+	log.Infof("Version matrix: removed %d excluded tests, running %d tests from %s", len(removed), len(p.SuiteTests), suiteFile)
+	p.OTRunner.File = suiteFile
+	return nil
+}
+
+// removeSuiteTests removes the tests matching the patterns from the suite, returning
+// the names (unquoted) of the removed tests, sorted.
+func (p *Plugin) removeSuiteTests(patterns []*regexp.Regexp) []string {
+	removed := []string{}
+	for test := range p.SuiteTests {
+		for _, re := range patterns {
+			if re.MatchString(test) {
+				removed = append(removed, test)
+				break
+			}
+		}
+	}
+	for idx, test := range removed {
+		delete(p.SuiteTests, test)
+		// the suite list has the test names quoted.
+		if name, err := strconv.Unquote(test); err == nil {
+			removed[idx] = name
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	ocfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestInitVersionConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		version         string
+		defaultSuite    string
+		wantSuite       string
+		wantTimeout     time.Duration
+		wantMaxParallel string
+	}{
+		{name: "4.19", version: "4.19.3", wantSuite: PluginSuite10, wantTimeout: 2 * time.Hour, wantMaxParallel: "0"},
+		{name: "4.20", version: "4.20.0", wantSuite: suite10Parallel, wantTimeout: 2 * time.Hour, wantMaxParallel: "0"},
+		{name: "default suite priority", version: "4.20.0", defaultSuite: PluginSuite10, wantSuite: PluginSuite10,
+			wantTimeout: 2 * time.Hour, wantMaxParallel: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_SUITE_NAME", tt.defaultSuite)
+			p, err := NewPlugin(PluginName10)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			p.clientConfig = ocfake.NewSimpleClientset(&configv1.ClusterVersion{
+				ObjectMeta: kmmetav1.ObjectMeta{Name: "version"},
+				Status:     configv1.ClusterVersionStatus{Desired: configv1.Release{Version: tt.version}},
+			})
+			p.initVersionConfig()
+			if p.VersionConfig == nil || p.VersionConfig.Version != tt.version {
+				t.Fatalf("VersionConfig = %+v, want version %s", p.VersionConfig, tt.version)
+			}
+			if p.SuiteName != tt.wantSuite || p.OTRunner.SuiteName != tt.wantSuite {
+				t.Errorf("suite = %q (runner %q), want %q", p.SuiteName, p.OTRunner.SuiteName, tt.wantSuite)
+			}
+			if p.Timeout != tt.wantTimeout || p.OTRunner.MaxParallel != tt.wantMaxParallel {
+				t.Errorf("timeout = %v, max parallel = %q, want %v, %q", p.Timeout, p.OTRunner.MaxParallel, tt.wantTimeout, tt.wantMaxParallel)
+			}
+		})
+	}
+}
+
+// TestInitVersionConfigUnavailable tests the defaults are kept when the cluster version is not available.
+func TestInitVersionConfigUnavailable(t *testing.T) {
+	p, err := NewPlugin(PluginName80)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	p.clientConfig = ocfake.NewSimpleClientset()
+	p.initVersionConfig()
+	if p.VersionConfig.Version != "" || p.Timeout != time.Hour || p.OTRunner.MaxParallel != "1" {
+		t.Errorf("VersionConfig = %+v, timeout = %v, max parallel = %q, want the replay defaults", p.VersionConfig, p.Timeout, p.OTRunner.MaxParallel)
+	}
+}
+
+func TestFilterExcludedTests(t *testing.T) {
+	dir := t.TempDir()
+	matrixFile := filepath.Join(dir, "matrix.yaml")
+	if err := os.WriteFile(matrixFile, []byte(`
+plugins:
+  "20": {suite: openshift/conformance, timeout: 4h}
+versions:
+- since: "4.20"
+  plugins:
+    "20": {excludedTests: ['\[sig-storage\] legacy', '\[Feature:Removed\]']}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvVersionMatrixFile, matrixFile)
+	p, err := NewPlugin(PluginName20)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	p.SuiteFile = filepath.Join(dir, "suite.list")
+	p.OTRunner.JUnitDir = filepath.Join(dir, "junit")
+	p.SuiteTests = map[string]struct{}{
+		`"[sig-storage] legacy volume"`:  {},
+		`"[sig-apps] [Feature:Removed]"`: {},
+		`"[sig-apps] deployment"`:        {},
+	}
+
+	// the tests are excluded only on the versions of the matrix entry.
+	p.ResolveVersionConfig("4.19.0")
+	if err := p.FilterExcludedTests(); err != nil || len(p.SuiteTests) != 3 {
+		t.Fatalf("FilterExcludedTests() = %v, suite %v, want all tests on 4.19", err, p.SuiteTests)
+	}
+
+	p.ResolveVersionConfig("4.20.1")
+	if err := p.FilterExcludedTests(); err != nil {
+		t.Fatalf("FilterExcludedTests() unexpected error: %v", err)
+	}
+	if _, ok := p.SuiteTests[`"[sig-apps] deployment"`]; !ok || len(p.SuiteTests) != 1 {
+		t.Errorf("SuiteTests = %v, want only the deployment test", p.SuiteTests)
+	}
+	data, err := os.ReadFile(p.OTRunner.File)
+	if err != nil || strings.TrimSpace(string(data)) != `"[sig-apps] deployment"` {
+		t.Errorf("suite file %s = %q (%v), want the filtered suite", p.OTRunner.File, string(data), err)
+	}
+	junit, err := os.ReadFile(filepath.Join(p.OTRunner.JUnitDir, VersionExcludedJUnit))
+	if err != nil {
+		t.Fatalf("excluded JUnit not created: %v", err)
+	}
+	for _, want := range []string{`tests="2"`, `name="[sig-storage] legacy volume"`, "<skipped", "OpenShift 4.20.1"} {
+		if !strings.Contains(string(junit), want) {
+			t.Errorf("excluded JUnit missing %q:\n%s", want, string(junit))
+		}
+	}
+}
+
+// TestMonitorsConfig tests the monitors of the plugin configuration have priority over
+// the version matrix, the empty value disabling the monitors.
+func TestMonitorsConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		monitors *string
+		want     string
+	}{
+		{name: "version matrix", want: DefaultOpenShiftTestsRunMonitors},
+		{name: "configured", monitors: ptr.To("etcd-log-analyzer,audit-log-analyzer"), want: "etcd-log-analyzer,audit-log-analyzer"},
+		{name: "disabled", monitors: ptr.To(""), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.monitors != nil {
+				t.Setenv("OPENSHIFT_TESTS_MONITORS", *tt.monitors)
+			}
+			p, err := NewPlugin(PluginName20)
+			if err != nil {
+				t.Fatalf("NewPlugin() unexpected error: %v", err)
+			}
+			if p.OTRunner.Monitortests != tt.want {
+				t.Errorf("monitors = %q, want %q", p.OTRunner.Monitortests, tt.want)
+			}
+			if hasFlag := strings.Contains(strings.Join(p.OTRunner.Args(), " "), "--monitor="); hasFlag != (len(tt.want) > 0) {
+				t.Errorf("Args() = %v, want the flag --monitor only when the monitors are enabled", p.OTRunner.Args())
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
+)
+
+// DefaultWorkDir holds the plugin files not shared with the tests container.
+const DefaultWorkDir = "/tmp"
+
+// Workspace holds the directories and the endpoint shared by the plugin with the
+// tests container and the sonobuoy worker. The default workspace is the layout of
+// the plugin pod: the files are named as the constants (example: FiFoPath) in the
+// workspace directories.
+type Workspace struct {
+	// SharedDir is the volume shared with the tests container. Default: /tmp/shared
+	SharedDir string
+	// ResultsDir is the sonobuoy results directory read by the worker. Default: /tmp/sonobuoy/results
+	ResultsDir string
+	// WorkDir holds the plugin files not shared. Default: /tmp
+	WorkDir string
+	// ProgressURL is the progress endpoint of the sonobuoy worker.
+	ProgressURL string
+}
+
+// DefaultWorkspace returns the workspace of the plugin pod.
+func DefaultWorkspace() *Workspace {
+	return &Workspace{
+		SharedDir:   SharedDir,
+		ResultsDir:  ResultsDir,
+		WorkDir:     DefaultWorkDir,
+		ProgressURL: ProgressURL,
+	}
+}
+
+// NewWorkspace returns the workspace rooted in the directory, example: sandbox mode.
+func NewWorkspace(dir, progressURL string) *Workspace {
+	return &Workspace{
+		SharedDir:   filepath.Join(dir, "shared"),
+		ResultsDir:  filepath.Join(dir, "results"),
+		WorkDir:     filepath.Join(dir, "tmp"),
+		ProgressURL: progressURL,
+	}
+}
+
+// workspaceFromConfig returns the default workspace with the settings of the config.
+func workspaceFromConfig(cfg *config.Config) *Workspace {
+	ws := DefaultWorkspace()
+	if len(cfg.Workspace.SharedDir) > 0 {
+		ws.SharedDir = cfg.Workspace.SharedDir
+	}
+	if len(cfg.Workspace.ResultsDir) > 0 {
+		ws.ResultsDir = cfg.Workspace.ResultsDir
+	}
+	if len(cfg.Workspace.WorkDir) > 0 {
+		ws.WorkDir = cfg.Workspace.WorkDir
+	}
+	if len(cfg.Workspace.ProgressURL) > 0 {
+		ws.ProgressURL = cfg.Workspace.ProgressURL
+	}
+	return ws
+}
+
+// Create creates the workspace directories.
+func (w *Workspace) Create() error {
+	for _, dir := range []string{w.SharedDir, w.JUnitDir(), w.ResultsDir, w.WorkDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating workspace directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// Shared returns the path of the file in the shared directory.
+func (w *Workspace) Shared(name string) string {
+	return filepath.Join(w.SharedDir, name)
+}
+
+// Results returns the path of the file in the results directory.
+func (w *Workspace) Results(name string) string {
+	return filepath.Join(w.ResultsDir, name)
+}
+
+// Work returns the path of the file in the work directory.
+func (w *Workspace) Work(name string) string {
+	return filepath.Join(w.WorkDir, name)
+}
+
+// FiFo returns the pipe receiving the runner output.
+func (w *Workspace) FiFo() string { return w.Shared(filepath.Base(FiFoPath)) }
+
+// RunFile returns the run/start script executed by the tests container.
+func (w *Workspace) RunFile() string { return w.Shared(filepath.Base(OpenShiftTestsRunFile)) }
+
+// DoneFile returns the file created by the tests container when the runner finishes.
+func (w *Workspace) DoneFile() string { return w.Shared(filepath.Base(OpenShiftTestsDoneFile)) }
+
+// DoneRecord returns the done record (RunnerStatus) written by the run script.
+func (w *Workspace) DoneRecord() string { return w.Shared(filepath.Base(OpenShiftTestsDoneRecord)) }
+
+// StderrLog returns the runner stderr written by the run script.
+func (w *Workspace) StderrLog() string { return w.Shared(filepath.Base(OpenShiftTestsStderrLog)) }
+
+// JUnitDir returns the directory of the JUnit results.
+func (w *Workspace) JUnitDir() string { return w.Shared(filepath.Base(OpenShiftTestsJUnitDir)) }
+
+// SuiteList returns the suite list written by the tests container.
+func (w *Workspace) SuiteList() string { return w.Shared(filepath.Base(OpenShiftTestsSuiteList)) }
+
+// SuiteListDone returns the file created when the suite list is complete.
+func (w *Workspace) SuiteListDone() string { return w.Shared(filepath.Base(OTestsSuiteListComplete)) }
+
+// ShardFile returns the shard suffix file read by the tests container.
+func (w *Workspace) ShardFile() string { return w.Shared(filepath.Base(OpenShiftTestsShardFile)) }
+
+// PluginBinary returns the plugin binary shared with the tests container.
+func (w *Workspace) PluginBinary() string { return w.Shared(filepath.Base(SharedPluginBinary)) }
+
+// ResultsDoneFile returns the file signaling the worker the plugin is done.
+func (w *Workspace) ResultsDoneFile() string { return w.Results(filepath.Base(ResultsDoneFile)) }
@@ -0,0 +1,52 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/config"
+)
+
+func TestWorkspaceFromConfig(t *testing.T) {
+	ws := workspaceFromConfig(&config.Config{})
+	if *ws != *DefaultWorkspace() || ws.FiFo() != FiFoPath || ws.ResultsDoneFile() != ResultsDoneFile ||
+		ws.JUnitDir() != OpenShiftTestsJUnitDir || ws.SuiteListDone() != OTestsSuiteListComplete {
+		t.Errorf("workspaceFromConfig() = %+v, want the paths of the plugin pod", ws)
+	}
+
+	ws = workspaceFromConfig(&config.Config{Workspace: config.Workspace{
+		SharedDir:   "/work/shared",
+		ProgressURL: "http://127.0.0.1:9000/progress",
+	}})
+	tests := [][2]string{
+		{ws.RunFile(), "/work/shared/start"},
+		{ws.DoneRecord(), "/work/shared/done.json"},
+		{ws.ResultsDir, ResultsDir},
+		{ws.Work("failures.txt"), "/tmp/failures.txt"},
+		{ws.ProgressURL, "http://127.0.0.1:9000/progress"},
+	}
+	for _, tt := range tests {
+		if got, want := tt[0], tt[1]; got != want {
+			t.Errorf("workspace path = %q, want %q", got, want)
+		}
+	}
+}
+
+// TestSetWorkspace tests the runner paths are moved to the workspace.
+func TestSetWorkspace(t *testing.T) {
+	p, err := NewPlugin(PluginName80)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	ws := NewWorkspace("/sandbox", "http://127.0.0.1:9000/progress")
+	p.SetWorkspace(ws)
+	if p.Workspace != ws || p.SuiteFile != "/sandbox/shared/suite.list" || p.OTRunner.File != p.SuiteFile {
+		t.Errorf("SetWorkspace() suite file = %q, runner file = %q, want the workspace suite list", p.SuiteFile, p.OTRunner.File)
+	}
+	if p.OTRunner.FiFoPath != "/sandbox/shared/fifo" || p.OTRunner.JUnitDir != "/sandbox/shared/junit" ||
+		p.OTRunner.RunFile != "/sandbox/shared/start" || p.OTRunner.StderrPath != "/sandbox/shared/stderr.log" {
+		t.Errorf("SetWorkspace() runner = %+v, want the workspace paths", p.OTRunner)
+	}
+	if p.Progress.svc.url != ws.ProgressURL {
+		t.Errorf("SetWorkspace() progress URL = %q, want %q", p.Progress.svc.url, ws.ProgressURL)
+	}
+}
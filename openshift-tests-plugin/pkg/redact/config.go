@@ -0,0 +1,165 @@
+/*
+Package redact removes sensitive data from collected artifacts.
+
+The redaction rules use the must-gather-clean config format (mgc-config-*.yaml),
+supporting the obfuscate types Regex and Keywords, on the targets FileContents,
+FilePath and All, and the omit type File. Unsupported types are rejected when
+loading the config, so no data is collected without the expected redaction.
+*/
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ObfuscateTypeRegex    = "Regex"
+	ObfuscateTypeKeywords = "Keywords"
+
+	TargetFileContents = "FileContents"
+	TargetFilePath     = "FilePath"
+	TargetAll          = "All"
+
+	OmitTypeFile = "File"
+)
+
+// Config is the redaction config in the must-gather-clean format.
+type Config struct {
+	Config struct {
+		Obfuscate []*Obfuscate `json:"obfuscate,omitempty"`
+		Omit      []*Omit      `json:"omit,omitempty"`
+	} `json:"config"`
+}
+
+// Obfuscate is an obfuscation rule.
+type Obfuscate struct {
+	Type   string `json:"type"`
+	Target string `json:"target,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+	// Keywords maps the keyword to the replacement.
+	Keywords map[string]string `json:"keywords,omitempty"`
+	// ReplacementType is accepted for compatibility, the replacement is always static.
+	ReplacementType string `json:"replacementType,omitempty"`
+}
+
+// Omit is a rule excluding files from the output.
+type Omit struct {
+	Type    string `json:"type"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Rule is a compiled obfuscation rule.
+type Rule struct {
+	// Name identifies the rule in the report: <type>#<index>.
+	Name     string
+	Contents bool
+	Path     bool
+	regex    *regexp.Regexp
+	keywords []string
+	replace  map[string]string
+}
+
+// Apply replaces the sensitive data in data, returning the number of replacements.
+// Regex matches are replaced by 'x' keeping the length, and keywords by the replacement.
+func (r *Rule) Apply(data []byte) ([]byte, int) {
+	count := 0
+	if r.regex != nil {
+		data = r.regex.ReplaceAllFunc(data, func(match []byte) []byte {
+			count++
+			return []byte(strings.Repeat("x", len(match)))
+		})
+		return data, count
+	}
+	for _, keyword := range r.keywords {
+		if n := bytes.Count(data, []byte(keyword)); n > 0 {
+			count += n
+			data = bytes.ReplaceAll(data, []byte(keyword), []byte(r.replace[keyword]))
+		}
+	}
+	return data, count
+}
+
+// LoadConfig reads and compiles the redaction config file.
+func LoadConfig(file string) (*Redactor, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading redaction config %s: %w", file, err)
+	}
+	r, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction config %s: %w", file, err)
+	}
+	return r, nil
+}
+
+// ParseConfig parses and compiles the redaction config.
+func ParseConfig(data []byte) (*Redactor, error) {
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+	red := &Redactor{}
+	for idx, ob := range cfg.Config.Obfuscate {
+		rule := &Rule{Name: fmt.Sprintf("%s#%d", ob.Type, idx)}
+		switch ob.Target {
+		case "", TargetFileContents:
+			rule.Contents = true
+		case TargetFilePath:
+			rule.Path = true
+		case TargetAll:
+			rule.Contents, rule.Path = true, true
+		default:
+			return nil, fmt.Errorf("obfuscate #%d: unsupported target %q", idx, ob.Target)
+		}
+		switch ob.Type {
+		case ObfuscateTypeRegex:
+			if len(ob.Regex) == 0 {
+				return nil, fmt.Errorf("obfuscate #%d: empty regex", idx)
+			}
+			re, err := regexp.Compile(ob.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("obfuscate #%d: invalid regex: %w", idx, err)
+			}
+			rule.regex = re
+		case ObfuscateTypeKeywords:
+			if len(ob.Keywords) == 0 {
+				return nil, fmt.Errorf("obfuscate #%d: empty keywords", idx)
+			}
+			rule.replace = ob.Keywords
+			for keyword := range ob.Keywords {
+				if len(keyword) == 0 {
+					return nil, fmt.Errorf("obfuscate #%d: empty keyword", idx)
+				}
+				rule.keywords = append(rule.keywords, keyword)
+			}
+			// longest keywords first, to not replace a keyword contained by another.
+			sort.Slice(rule.keywords, func(i, j int) bool {
+				if len(rule.keywords[i]) != len(rule.keywords[j]) {
+					return len(rule.keywords[i]) > len(rule.keywords[j])
+				}
+				return rule.keywords[i] < rule.keywords[j]
+			})
+		default:
+			return nil, fmt.Errorf("obfuscate #%d: unsupported type %q", idx, ob.Type)
+		}
+		red.Rules = append(red.Rules, rule)
+	}
+	for idx, om := range cfg.Config.Omit {
+		if om.Type != OmitTypeFile {
+			return nil, fmt.Errorf("omit #%d: unsupported type %q", idx, om.Type)
+		}
+		if _, err := path.Match(om.Pattern, ""); err != nil || len(om.Pattern) == 0 {
+			return nil, fmt.Errorf("omit #%d: invalid pattern %q", idx, om.Pattern)
+		}
+		red.Omit = append(red.Omit, om.Pattern)
+	}
+	return red, nil
+}
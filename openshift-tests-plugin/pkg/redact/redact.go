@@ -0,0 +1,432 @@
+package redact
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Redactor applies the obfuscation rules to files, directories and tar.gz archives,
+// streaming the contents line by line. Archives (.tar.gz, .tgz) are redacted entry
+// by entry, including nested archives, and gzip files (.gz) are redacted decompressed.
+type Redactor struct {
+	Rules []*Rule
+	// Omit is the list of file patterns excluded from the output, matching
+	// the relative path or the base name.
+	Omit []string
+}
+
+// Report is the redaction report.
+type Report struct {
+	// Scanned is the number of files processed.
+	Scanned int `json:"scanned"`
+	// Total is the number of replacements.
+	Total int `json:"total"`
+	// Rules is the number of replacements per rule.
+	Rules map[string]int `json:"rules"`
+	// Files is the number of replacements per rule per file, for files with replacements.
+	// Files in archives are reported as <archive>/<path>.
+	Files map[string]map[string]int `json:"files"`
+	// Omitted is the list of files excluded from the output.
+	Omitted []string `json:"omitted,omitempty"`
+}
+
+// NewReport creates an empty report.
+func NewReport() *Report {
+	return &Report{Rules: map[string]int{}, Files: map[string]map[string]int{}}
+}
+
+// record adds the replacement counts of the file.
+func (rp *Report) record(file string, counts map[string]int) {
+	for rule, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if _, ok := rp.Files[file]; !ok {
+			rp.Files[file] = map[string]int{}
+		}
+		rp.Files[file][rule] += count
+		rp.Rules[rule] += count
+		rp.Total += count
+	}
+}
+
+// Merge adds the counts of another report.
+func (rp *Report) Merge(other *Report) {
+	rp.Scanned += other.Scanned
+	rp.Omitted = append(rp.Omitted, other.Omitted...)
+	for file, counts := range other.Files {
+		rp.record(file, counts)
+	}
+}
+
+// Save writes the report as JSON.
+func (rp *Report) Save(file string) error {
+	sort.Strings(rp.Omitted)
+	data, err := json.MarshalIndent(rp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding redaction report: %w", err)
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// Redact redacts src (file or directory) into dst, replacing dst when it is src.
+// The output is created only when all files are redacted: any error processing a
+// file fails the redaction, leaving dst unchanged (or not created).
+func (r *Redactor) Redact(src, dst string) (*Report, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return r.RedactFile(src, dst)
+	}
+	if filepath.Clean(src) != filepath.Clean(dst) {
+		return r.RedactDir(src, dst)
+	}
+	tmpDir := filepath.Clean(dst) + ".redacting"
+	report, err := r.RedactDir(src, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(src); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("error replacing %s: %w", src, err)
+	}
+	if err := os.Rename(tmpDir, dst); err != nil {
+		return nil, fmt.Errorf("error replacing %s: %w", dst, err)
+	}
+	return report, nil
+}
+
+// RedactFile redacts the file src into dst, which can be the same file.
+func (r *Redactor) RedactFile(src, dst string) (*Report, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".redacting-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating redacted file: %w", err)
+	}
+	defer os.Remove(out.Name())
+
+	report := NewReport()
+	err = r.redactEntry(filepath.Base(src), in, out, report)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error redacting %s: %w", src, err)
+	}
+	if err := os.Chmod(out.Name(), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(out.Name(), dst); err != nil {
+		return nil, fmt.Errorf("error saving redacted file %s: %w", dst, err)
+	}
+	return report, nil
+}
+
+// RedactDir redacts the directory src into the new directory dst, removing dst
+// when failed.
+func (r *Redactor) RedactDir(src, dst string) (*Report, error) {
+	if _, err := os.Lstat(dst); err == nil {
+		return nil, fmt.Errorf("destination %s already exists", dst)
+	}
+	report := NewReport()
+	err := filepath.WalkDir(src, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && r.omitted(rel) {
+			report.Omitted = append(report.Omitted, rel)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name, counts := r.redactPath(rel)
+		report.record(name, counts)
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			link, counts := r.redactPath(link)
+			report.record(name, counts)
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return r.redactDirFile(file, target, name, info.Mode().Perm(), report)
+		default:
+			return fmt.Errorf("unsupported file type %s: %s", info.Mode().Type(), rel)
+		}
+	})
+	if err != nil {
+		_ = os.RemoveAll(dst)
+		return nil, fmt.Errorf("error redacting %s: %w", src, err)
+	}
+	return report, nil
+}
+
+// redactDirFile redacts the file into target, reporting it as name.
+func (r *Redactor) redactDirFile(file, target, name string, mode fs.FileMode, report *Report) error {
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	err = r.redactEntry(name, in, out, report)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// redactEntry redacts the contents of the file name from src into dst, by the
+// file type: tar.gz archives, gzip or plain files.
+func (r *Redactor) redactEntry(name string, src io.Reader, dst io.Writer, report *Report) error {
+	switch {
+	case isTarGz(name):
+		gzr, err := gzip.NewReader(src)
+		if errors.Is(err, io.EOF) {
+			// empty file
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive: %w", err)
+		}
+		gzw := gzip.NewWriter(dst)
+		if err := r.redactTar(gzr, gzw, name, report); err != nil {
+			return err
+		}
+		return gzw.Close()
+
+	case strings.HasSuffix(name, ".gz"):
+		gzr, err := gzip.NewReader(src)
+		if errors.Is(err, io.EOF) {
+			// empty file
+			report.Scanned++
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading gzip file: %w", err)
+		}
+		gzw := gzip.NewWriter(dst)
+		counts, err := r.redactContents(gzr, gzw)
+		if err != nil {
+			return err
+		}
+		report.Scanned++
+		report.record(name, counts)
+		return gzw.Close()
+
+	default:
+		counts, err := r.redactContents(src, dst)
+		if err != nil {
+			return err
+		}
+		report.Scanned++
+		report.record(name, counts)
+		return nil
+	}
+}
+
+// redactTar redacts the tar stream src into dst, reporting the files as <archive>/<path>.
+// The entries are spooled to a temporary file when the redacted size can change,
+// otherwise they are streamed directly.
+func (r *Redactor) redactTar(src io.Reader, dst io.Writer, archive string, report *Report) error {
+	tr := tar.NewReader(src)
+	tw := tar.NewWriter(dst)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive %s: %w", archive, err)
+		}
+		rel := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if rel != "." && r.omitted(rel) {
+			report.Omitted = append(report.Omitted, archive+"/"+rel)
+			continue
+		}
+		name, counts := r.redactPath(hdr.Name)
+		entry := archive + "/" + path.Clean(strings.TrimPrefix(name, "./"))
+		report.record(entry, counts)
+		hdr.Name = name
+		if len(hdr.Linkname) > 0 {
+			hdr.Linkname, counts = r.redactPath(hdr.Linkname)
+			report.record(entry, counts)
+		}
+		// the names are encoded from the header fields.
+		delete(hdr.PAXRecords, "path")
+		delete(hdr.PAXRecords, "linkpath")
+
+		if hdr.Typeflag != tar.TypeReg {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return fmt.Errorf("error writing %s: %w", entry, err)
+			}
+			continue
+		}
+		if err := r.redactTarFile(tr, tw, hdr, entry, report); err != nil {
+			return fmt.Errorf("error redacting %s: %w", entry, err)
+		}
+	}
+	return tw.Close()
+}
+
+// redactTarFile redacts the regular file entry, updating the header size.
+func (r *Redactor) redactTarFile(tr io.Reader, tw *tar.Writer, hdr *tar.Header, entry string, report *Report) error {
+	if r.sameSize() && !isGzip(entry) {
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		return r.redactEntry(entry, tr, tw, report)
+	}
+
+	spool, err := os.CreateTemp("", "redact-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+	if err := r.redactEntry(entry, tr, spool, report); err != nil {
+		return err
+	}
+	size, err := spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hdr.Size = size
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, spool)
+	return err
+}
+
+// redactContents redacts src into dst line by line, returning the replacements per rule.
+func (r *Redactor) redactContents(src io.Reader, dst io.Writer) (map[string]int, error) {
+	counts := map[string]int{}
+	reader := bufio.NewReaderSize(src, 64*1024)
+	writer := bufio.NewWriterSize(dst, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			// the line break is kept out of the rules, preserving the lines.
+			eol := bytes.HasSuffix(line, []byte("\n"))
+			if eol {
+				line = line[:len(line)-1]
+			}
+			for _, rule := range r.Rules {
+				if !rule.Contents {
+					continue
+				}
+				var n int
+				line, n = rule.Apply(line)
+				counts[rule.Name] += n
+			}
+			if eol {
+				line = append(line, '\n')
+			}
+			if _, werr := writer.Write(line); werr != nil {
+				return nil, werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return counts, writer.Flush()
+}
+
+// redactPath applies the path rules to name, returning the replacements per rule.
+func (r *Redactor) redactPath(name string) (string, map[string]int) {
+	counts := map[string]int{}
+	redacted := []byte(name)
+	for _, rule := range r.Rules {
+		if rule.Path {
+			var n int
+			redacted, n = rule.Apply(redacted)
+			counts[rule.Name] += n
+		}
+	}
+	return string(redacted), counts
+}
+
+// omitted returns true when the relative path matches an omit pattern.
+func (r *Redactor) omitted(rel string) bool {
+	for _, pattern := range r.Omit {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSize returns true when the redaction keeps the contents size, which is
+// the case of the Regex rules.
+func (r *Redactor) sameSize() bool {
+	for _, rule := range r.Rules {
+		if rule.Contents && rule.regex == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isTarGz(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+func isGzip(name string) bool {
+	return strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+}
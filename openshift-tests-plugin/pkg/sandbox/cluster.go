@@ -0,0 +1,225 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	occlient "github.com/openshift/client-go/config/clientset/versioned"
+	ocfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	log "github.com/sirupsen/logrus"
+	sbclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	sbplugin "github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	sbaggregation "github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	kcorev1 "k8s.io/api/core/v1"
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	krest "k8s.io/client-go/rest"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+// aggregatorPod is the name of the fake sonobuoy aggregator pod.
+const aggregatorPod = "sonobuoy"
+
+// Event* are the types of the events recorded in the timeline of the cluster.
+const (
+	// EventProgress is a progress update received by the worker of the plugin.
+	EventProgress = "progress"
+	// EventStarted is the runner started by the tests container of the plugin.
+	EventStarted = "started"
+	// EventStatus is the status of the plugin changed in the aggregator.
+	EventStatus = "status"
+)
+
+// Event is an event of the workflow recorded in the timeline of the cluster.
+type Event struct {
+	Time    time.Time
+	Plugin  string
+	Type    string
+	Message string
+}
+
+// Cluster is the fake cluster shared by the plugins of a workflow: the kube, config
+// and sonobuoy clients, and the sonobuoy aggregator reporting the status of the plugins
+// in the annotation of the aggregator pod, as read by the dependency waiter.
+type Cluster struct {
+	clientKube     kubernetes.Interface
+	clientConfig   occlient.Interface
+	clientSonobuoy sbclient.Interface
+
+	mu       sync.Mutex
+	status   sbaggregation.Status
+	timeline []Event
+}
+
+// NewCluster creates the fake cluster in the version, on platform None, with all
+// the plugins running in the aggregator.
+func NewCluster(version string) (*Cluster, error) {
+	sonobuoy, err := sbclient.NewSonobuoyClient(&krest.Config{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the sonobuoy client: %w", err)
+	}
+	c := &Cluster{
+		clientKube: kfake.NewSimpleClientset(
+			&kcorev1.Namespace{ObjectMeta: kmmetav1.ObjectMeta{Name: plugin.EnvNamespace}},
+			&kcorev1.Pod{
+				ObjectMeta: kmmetav1.ObjectMeta{
+					Name:      aggregatorPod,
+					Namespace: plugin.EnvNamespace,
+					Labels:    map[string]string{"component": "sonobuoy", "sonobuoy-component": "aggregator"},
+				},
+				Status: kcorev1.PodStatus{Phase: kcorev1.PodRunning},
+			},
+		),
+		clientConfig:   newConfigClient(version),
+		clientSonobuoy: sonobuoy,
+		status:         sbaggregation.Status{Status: sbaggregation.RunningStatus},
+	}
+	for _, p := range [][2]string{
+		{plugin.PluginId05, plugin.PluginName05},
+		{plugin.PluginId10, plugin.PluginName10},
+		{plugin.PluginId20, plugin.PluginName20},
+		{plugin.PluginId80, plugin.PluginName80},
+		{plugin.PluginId99, plugin.PluginName99},
+	} {
+		c.status.Plugins = append(c.status.Plugins, sbaggregation.PluginStatus{
+			Plugin: fmt.Sprintf("%s-%s", p[0], p[1]),
+			Node:   "global",
+			Status: sbaggregation.RunningStatus,
+		})
+	}
+	if err := c.updateAggregator(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// KubeClient returns the fake kube client of the cluster.
+func (c *Cluster) KubeClient() kubernetes.Interface {
+	return c.clientKube
+}
+
+// SetStatus sets the status of the plugin (full name, example: 10-openshift-kube-conformance)
+// in the aggregator, example: complete or failed.
+func (c *Cluster) SetStatus(name, status string) error {
+	return c.setStatus(name, status, "")
+}
+
+// setStatus sets the status and the result status (passed or failed) of the plugin.
+func (c *Cluster) setStatus(name, status, resultStatus string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ps := c.pluginStatus(name)
+	if ps == nil {
+		return fmt.Errorf("plugin %s not found in the aggregator", name)
+	}
+	ps.Status = status
+	ps.ResultStatus = resultStatus
+	c.record(name, EventStatus, status)
+	return c.updateAggregator()
+}
+
+// Status returns the status of the plugin in the aggregator.
+func (c *Cluster) Status(name string) (sbaggregation.PluginStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ps := c.pluginStatus(name); ps != nil {
+		return *ps, true
+	}
+	return sbaggregation.PluginStatus{}, false
+}
+
+// Timeline returns the events recorded in the cluster, in the order received.
+func (c *Cluster) Timeline() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Event{}, c.timeline...)
+}
+
+// FirstEvent returns the first event of the type recorded for the plugin.
+func (c *Cluster) FirstEvent(name, eventType, message string) (Event, bool) {
+	for _, e := range c.Timeline() {
+		if e.Plugin == name && e.Type == eventType && (len(message) == 0 || e.Message == message) {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+// progress forwards the progress update of the plugin to the aggregator.
+func (c *Cluster) progress(name string, update sbplugin.ProgressUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(name, EventProgress, update.Message)
+	ps := c.pluginStatus(name)
+	if ps == nil {
+		return
+	}
+	ps.Progress = &update
+	if err := c.updateAggregator(); err != nil {
+		log.Errorf("sandbox: %v", err)
+	}
+}
+
+// started records the runner started by the tests container of the plugin.
+func (c *Cluster) started(name, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(name, EventStarted, message)
+}
+
+// record appends the event to the timeline. The caller must hold the lock.
+func (c *Cluster) record(name, eventType, message string) {
+	c.timeline = append(c.timeline, Event{Time: time.Now(), Plugin: name, Type: eventType, Message: message})
+}
+
+// pluginStatus returns the status of the plugin. The caller must hold the lock.
+func (c *Cluster) pluginStatus(name string) *sbaggregation.PluginStatus {
+	for i := range c.status.Plugins {
+		if c.status.Plugins[i].Plugin == name {
+			return &c.status.Plugins[i]
+		}
+	}
+	return nil
+}
+
+// updateAggregator writes the status to the aggregator pod. The caller must hold the lock.
+func (c *Cluster) updateAggregator() error {
+	data, err := json.Marshal(c.status)
+	if err != nil {
+		return fmt.Errorf("unable to render the aggregator status: %w", err)
+	}
+	pods := c.clientKube.CoreV1().Pods(plugin.EnvNamespace)
+	pod, err := pods.Get(context.TODO(), aggregatorPod, kmmetav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get the aggregator pod: %w", err)
+	}
+	pod.Annotations = map[string]string{sbaggregation.StatusAnnotationName: string(data)}
+	if _, err := pods.Update(context.TODO(), pod, kmmetav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update the aggregator status: %w", err)
+	}
+	return nil
+}
+
+// newConfigClient creates the config client of a cluster in the version, on platform None.
+func newConfigClient(version string) occlient.Interface {
+	return ocfake.NewSimpleClientset(
+		&configv1.ClusterVersion{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "version"},
+			Status:     configv1.ClusterVersionStatus{Desired: configv1.Release{Version: version}},
+		},
+		&configv1.Infrastructure{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.NonePlatformType}},
+		},
+		&configv1.Network{
+			ObjectMeta: kmmetav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.NetworkStatus{NetworkType: "OVNKubernetes"},
+		},
+	)
+}
@@ -0,0 +1,151 @@
+package sandbox
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/parser"
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+// DefaultLog is the prerecorded openshift-tests log replayed by default.
+//
+//go:embed openshift-tests.log
+var DefaultLog []byte
+
+// errStopped is returned when the sandbox is closed while the tests container waits.
+var errStopped = errors.New("sandbox stopped")
+
+// testLog is the openshift-tests output replayed by the fake tests container.
+type testLog struct {
+	lines []string
+	// tests are the test names started, quoted as in the suite list.
+	tests []string
+	// results are the test results, in the order of the log.
+	results []*plugin.JUnitTestReport
+}
+
+// parseTestLog parses the tests started and the results of the openshift-tests output.
+func parseTestLog(data []byte) *testLog {
+	tl := &testLog{}
+	started := map[string]struct{}{}
+	p := parser.New()
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		tl.lines = append(tl.lines, line)
+		switch ev := p.Parse(line); ev.Type {
+		case parser.EventStarted:
+			if _, ok := started[ev.Name]; !ok {
+				started[ev.Name] = struct{}{}
+				tl.tests = append(tl.tests, strconv.Quote(ev.Name))
+			}
+		case parser.EventResult:
+			report := &plugin.JUnitTestReport{Name: ev.Name, Result: ev.Result, Duration: ev.Duration}
+			if ev.Result == "failed" {
+				// the failure output is reported in the JUnit, as by openshift-tests.
+				report.Message = "failed in the openshift-tests log"
+				if len(ev.Output) > 0 {
+					report.Message = strings.Join(ev.Output, "\n")
+				}
+			}
+			tl.results = append(tl.results, report)
+		}
+	}
+	return tl
+}
+
+// runTestsContainer simulates the tests container: waits for the run script created
+// by the plugin, writes the output to the FIFO, the JUnit and the done files.
+func (s *Sandbox) runTestsContainer() {
+	defer s.wg.Done()
+	ws := s.Workspace
+
+	script, err := s.waitForFile(ws.RunFile())
+	if err != nil {
+		if !errors.Is(err, errStopped) {
+			log.Errorf("sandbox: tests container: %v", err)
+		}
+		return
+	}
+	startedAt := time.Now()
+	if strings.Contains(string(script), plugin.OpenShiftTestsSkipTest) {
+		log.Info("sandbox: tests container: runner skipped by the plugin")
+		s.Cluster.started(s.pluginName(), "skipped")
+		skipLine := fmt.Sprintf(`skipped: (0.0s) %s "%s"`, startedAt.UTC().Format("2006-01-02T15:04:05"), plugin.OpenShiftTestsSkipTest)
+		if err := s.writeFiFo([]string{skipLine}); err != nil {
+			log.Errorf("sandbox: tests container: %v", err)
+		}
+	} else {
+		log.Infof("sandbox: tests container: replaying %d lines of the openshift-tests log", len(s.log.lines))
+		s.Cluster.started(s.pluginName(), "replay")
+		if err := s.replay(startedAt); err != nil {
+			log.Errorf("sandbox: tests container: %v", err)
+		}
+	}
+	if err := os.WriteFile(ws.DoneFile(), []byte{}, 0644); err != nil {
+		log.Errorf("sandbox: tests container: unable to write the done file: %v", err)
+	}
+}
+
+// replay writes the openshift-tests log to the FIFO, the results to the JUnit and the
+// runner status to the done record.
+func (s *Sandbox) replay(startedAt time.Time) error {
+	ws := s.Workspace
+	if err := s.writeFiFo(s.log.lines); err != nil {
+		return err
+	}
+	junit := filepath.Join(ws.JUnitDir(), fmt.Sprintf("junit_e2e__%s.xml", startedAt.UTC().Format("20060102-150405")))
+	if err := plugin.WriteJUnitTestSuite(junit, "openshift-tests", s.log.results); err != nil {
+		return err
+	}
+	data, err := json.Marshal(&plugin.RunnerStatus{
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Command:    []string{"openshift-tests", "run", "--sandbox"},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to render the done record: %w", err)
+	}
+	if err := os.WriteFile(ws.DoneRecord(), data, 0644); err != nil {
+		return fmt.Errorf("unable to write the done record: %w", err)
+	}
+	return nil
+}
+
+// writeFiFo writes the lines to the FIFO once the plugin progress reader is waiting.
+func (s *Sandbox) writeFiFo(lines []string) error {
+	var fifo *os.File
+	for {
+		var err error
+		// The non-blocking open fails (ENXIO) while the FIFO has no reader.
+		fifo, err = os.OpenFile(s.Workspace.FiFo(), os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.ENXIO) {
+			return fmt.Errorf("unable to open the FIFO: %w", err)
+		}
+		select {
+		case <-s.stop:
+			return errStopped
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	defer fifo.Close()
+	for _, line := range lines {
+		if _, err := fifo.WriteString(line + "\n"); err != nil {
+			return fmt.Errorf("unable to write to the FIFO: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package sandbox runs the plugin lifecycle locally, replacing the dependencies of
+// the plugin pod by fakes:
+//   - the cluster is served by fake kube, config and sonobuoy clients, with the objects
+//     read by the plugin (namespace, aggregator status, cluster version and infrastructure);
+//   - the sonobuoy worker is a progress server forwarding the updates sent by the plugin
+//     to the aggregator, and reporting the plugin complete when the results are done;
+//   - the tests container replays a prerecorded openshift-tests log when the plugin
+//     creates the run script, writing the suite list, the JUnit and the done files.
+//
+// The plugins of a workflow (05, 10, 20, 80 and 99) run in sandboxes of the same
+// cluster, blocked by the status of the previous plugin in the aggregator.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	sbplugin "github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	sbaggregation "github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+// DefaultVersion is the OpenShift version of the fake cluster.
+const DefaultVersion = "4.20.0"
+
+// BlockerPollInterval is the interval the plugin in the sandbox checks the blocker plugin.
+const BlockerPollInterval = 200 * time.Millisecond
+
+// Sandbox holds the workspace of a plugin and the fakes of a local plugin run.
+type Sandbox struct {
+	// Workspace is the plugin workspace rooted in the sandbox directory.
+	Workspace *plugin.Workspace
+	// Cluster is the fake cluster, shared by the sandboxes of a workflow.
+	Cluster *Cluster
+
+	log    *testLog
+	worker *worker
+
+	mu sync.Mutex
+	// name is the full name of the plugin set up in the sandbox.
+	name string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates the sandbox in the directory, replaying the openshift-tests log file
+// (default: DefaultLog), in a cluster with all plugins complete: the plugin runs
+// without waiting for the blocker plugin.
+func New(dir, logFile string) (*Sandbox, error) {
+	c, err := NewCluster(DefaultVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, ps := range c.status.Plugins {
+		if err := c.SetStatus(ps.Plugin, sbaggregation.CompleteStatus); err != nil {
+			return nil, err
+		}
+	}
+	return c.NewSandbox(dir, logFile)
+}
+
+// NewSandbox creates the sandbox of a plugin in the cluster, with the workspace in the
+// directory, replaying the openshift-tests log file (default: DefaultLog), and starts
+// the fake sonobuoy worker.
+func (c *Cluster) NewSandbox(dir, logFile string) (*Sandbox, error) {
+	data := DefaultLog
+	if len(logFile) > 0 {
+		var err error
+		if data, err = os.ReadFile(logFile); err != nil {
+			return nil, fmt.Errorf("unable to read the openshift-tests log: %w", err)
+		}
+	}
+	tl := parseTestLog(data)
+	if len(tl.tests) == 0 {
+		return nil, fmt.Errorf("no tests started in the openshift-tests log %s", logFile)
+	}
+
+	ws := plugin.NewWorkspace(dir, "")
+	if err := ws.Create(); err != nil {
+		return nil, err
+	}
+	s := &Sandbox{
+		Workspace: ws,
+		Cluster:   c,
+		log:       tl,
+		stop:      make(chan struct{}),
+	}
+	w, err := startWorker(func(update sbplugin.ProgressUpdate) {
+		c.progress(s.pluginName(), update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.worker = w
+	ws.ProgressURL = w.url
+	log.Infof("Sandbox created in %s (worker progress endpoint %s, %d tests in the openshift-tests log)", dir, w.url, len(tl.tests))
+	return s, nil
+}
+
+// KubeClient returns the fake kube client of the sandbox.
+func (s *Sandbox) KubeClient() kubernetes.Interface {
+	return s.Cluster.clientKube
+}
+
+// Setup sets the workspace and the fake clients of the plugin, and starts the fake
+// tests container and the worker. The control files of a previous run in the sandbox
+// are removed.
+func (s *Sandbox) Setup(p *plugin.Plugin) error {
+	ws := s.Workspace
+	for _, file := range []string{ws.FiFo(), ws.RunFile(), ws.DoneFile(), ws.DoneRecord(), ws.SuiteListDone(), ws.ResultsDoneFile()} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to clean up the sandbox: %w", err)
+		}
+	}
+	s.mu.Lock()
+	s.name = p.FullName()
+	s.mu.Unlock()
+	p.SetWorkspace(ws)
+	p.SetClients(s.Cluster.clientKube, s.Cluster.clientSonobuoy, s.Cluster.clientConfig)
+	p.BlockerPollInterval = BlockerPollInterval
+
+	// The tests container lists the suite before the plugin starts.
+	if err := os.WriteFile(ws.SuiteList(), []byte(strings.Join(s.log.tests, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("unable to write the suite list: %w", err)
+	}
+	if err := os.WriteFile(ws.SuiteListDone(), []byte{}, 0644); err != nil {
+		return fmt.Errorf("unable to write the suite list done file: %w", err)
+	}
+
+	s.wg.Add(2)
+	go s.runTestsContainer()
+	go s.runWorker()
+	return nil
+}
+
+// pluginName returns the full name of the plugin set up in the sandbox.
+func (s *Sandbox) pluginName() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
+
+// Updates returns the progress updates received by the fake sonobuoy worker.
+func (s *Sandbox) Updates() []sbplugin.ProgressUpdate {
+	return s.worker.Updates()
+}
+
+// ResultFile returns the result file reported by the plugin to the sonobuoy worker.
+func (s *Sandbox) ResultFile() (string, error) {
+	data, err := os.ReadFile(s.Workspace.ResultsDoneFile())
+	if err != nil {
+		return "", fmt.Errorf("plugin did not report the results: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ResultJUnit returns the JUnit of the results reported by the plugin: the result file,
+// or the JUnit in the results package (directory) uploaded by the sonobuoy worker.
+func ResultJUnit(result string) (string, error) {
+	info, err := os.Stat(result)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the results: %w", err)
+	}
+	if !info.IsDir() {
+		return result, nil
+	}
+	files, err := filepath.Glob(filepath.Join(result, "*.xml"))
+	if err != nil || len(files) != 1 {
+		return "", fmt.Errorf("results package %s has %d JUnit files, want 1", result, len(files))
+	}
+	return files[0], nil
+}
+
+// Close stops the fake tests container and the fake sonobuoy worker.
+func (s *Sandbox) Close() error {
+	close(s.stop)
+	// Unblock the plugin progress reader waiting for a new writer of the FIFO.
+	if fifo, err := os.OpenFile(s.Workspace.FiFo(), os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+		fifo.Close()
+	}
+	s.wg.Wait()
+	return s.worker.Close()
+}
+
+// waitForFile waits for the content of the file created by the plugin, returning
+// errStopped when the sandbox is closed.
+func (s *Sandbox) waitForFile(path string) ([]byte, error) {
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil && len(data) > 0 {
+			return data, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		select {
+		case <-s.stop:
+			return nil, errStopped
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	kmmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+func TestParseTestLog(t *testing.T) {
+	tl := parseTestLog(DefaultLog)
+	if len(tl.tests) != 8 || len(tl.results) != 8 {
+		t.Fatalf("parseTestLog() = %d tests, %d results, want 8", len(tl.tests), len(tl.results))
+	}
+	counts := map[string]int{}
+	for _, r := range tl.results {
+		counts[r.Result]++
+	}
+	if counts["passed"] != 6 || counts["failed"] != 1 || counts["skipped"] != 1 {
+		t.Errorf("parseTestLog() results = %v, want 6 passed, 1 failed and 1 skipped", counts)
+	}
+	want := `"[Conformance][sig-api-machinery][Feature:APIServer] local kubeconfig \"lb-ext.kubeconfig\" should be present on all masters and work [Suite:openshift/conformance/parallel/minimal]"`
+	if tl.tests[3] != want {
+		t.Errorf("parseTestLog() test = %s, want %s", tl.tests[3], want)
+	}
+	if name := tl.results[3].Name; !strings.Contains(name, `"lb-ext.kubeconfig"`) || tl.results[3].Duration != 3200*time.Millisecond {
+		t.Errorf("parseTestLog() result = %s (%v), want the quotes unescaped and the duration", name, tl.results[3].Duration)
+	}
+}
+
+// TestSandboxRun tests the plugin lifecycle runs locally, reporting the results of the
+// openshift-tests log to the fake sonobuoy worker.
+func TestSandboxRun(t *testing.T) {
+	sb, err := New(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	defer sb.Close()
+
+	p, err := plugin.NewPlugin(plugin.PluginName10)
+	if err != nil {
+		t.Fatalf("NewPlugin() unexpected error: %v", err)
+	}
+	if err := sb.Setup(p); err != nil {
+		t.Fatalf("Setup() unexpected error: %v", err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatalf("Initialize() unexpected error: %v", err)
+	}
+	if len(p.SuiteTests) != 8 {
+		t.Errorf("SuiteTests = %d, want the 8 tests of the log", len(p.SuiteTests))
+	}
+	if err := p.RunDependencyWaiter(); err != nil {
+		t.Fatalf("RunDependencyWaiter() unexpected error: %v", err)
+	}
+	p.RunReportProgress()
+	if err := p.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if data, err := os.ReadFile(sb.Workspace.ShardFile()); err != nil || len(data) != 0 {
+		t.Errorf("shard file = %q (%v), want the empty suffix of the plugin not sharded", string(data), err)
+	}
+	if err := p.ProcessJUnit(); err != nil {
+		t.Fatalf("ProcessJUnit() unexpected error: %v", err)
+	}
+
+	result, err := sb.ResultFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(result) != sb.Workspace.ResultsDir {
+		t.Errorf("ResultFile() = %s, want the results package in %s", result, sb.Workspace.ResultsDir)
+	}
+	junitFile, err := ResultJUnit(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	junit, err := os.ReadFile(junitFile)
+	if err != nil || !strings.Contains(string(junit), `failures="1"`) {
+		t.Errorf("result JUnit = %s (%v), want the failure of the log", string(junit), err)
+	}
+	cm, err := sb.KubeClient().CoreV1().ConfigMaps(plugin.EnvNamespace).Get(context.TODO(), "plugin-failures-10", kmmetav1.GetOptions{})
+	if err != nil || !strings.Contains(cm.Data["replay.list"], "[sig-apps] Job Using a pod failure policy") {
+		t.Errorf("failures ConfigMap = %v (%v), want the failed test to replay", cm, err)
+	}
+	if err == nil && !strings.Contains(cm.Data[plugin.FailuresConfigMapKey], "fail [k8s.io/kubernetes/test/e2e/apps/job.go:211]: Timed out after 300.000s.") {
+		t.Errorf("failures ConfigMap %s = %s, want the failure output of the log", plugin.FailuresConfigMapKey, cm.Data[plugin.FailuresConfigMapKey])
+	}
+	// the artifacts are uploaded in the results package.
+	for _, name := range []string{plugin.FailureExcerptsName + ".json", filepath.Join(plugin.FailureExcerptsName, "0001.txt"), "inventory_start.json"} {
+		if _, err := os.Stat(filepath.Join(result, name)); err != nil {
+			t.Errorf("%s not saved to the results package: %v", name, err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(result, plugin.FailureAnalysisName+".json"))
+	if err != nil || !strings.Contains(string(data), `"signature": "Timed out after \u003cduration\u003e."`) {
+		t.Errorf("failure analysis = %s (%v), want the signature of the failure", string(data), err)
+	}
+	waiting := false
+	for _, update := range sb.Updates() {
+		if strings.HasPrefix(update.Message, "status=waiting-for=openshift-cluster-upgrade") {
+			waiting = true
+		}
+	}
+	if !waiting {
+		t.Errorf("Updates() = %+v, want the dependency waiter progress", sb.Updates())
+	}
+}
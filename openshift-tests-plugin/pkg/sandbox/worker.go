@@ -0,0 +1,103 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	sbplugin "github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	sbaggregation "github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+)
+
+// worker is the fake sonobuoy worker, recording the progress updates sent by the plugin.
+type worker struct {
+	url      string
+	server   *http.Server
+	onUpdate func(sbplugin.ProgressUpdate)
+
+	mu      sync.Mutex
+	updates []sbplugin.ProgressUpdate
+}
+
+// startWorker starts the progress endpoint of the worker on a local port, forwarding
+// the updates received to the aggregator.
+func startWorker(onUpdate func(sbplugin.ProgressUpdate)) (*worker, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the sonobuoy worker: %w", err)
+	}
+	w := &worker{url: fmt.Sprintf("http://%s/progress", ln.Addr()), onUpdate: onUpdate}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/progress", w.handleProgress)
+	w.server = &http.Server{Handler: mux}
+	go func() {
+		if err := w.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Errorf("sandbox: sonobuoy worker stopped: %v", err)
+		}
+	}()
+	return w, nil
+}
+
+// handleProgress records the progress update.
+func (w *worker) handleProgress(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var update sbplugin.ProgressUpdate
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		http.Error(rw, fmt.Sprintf("invalid progress update: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.mu.Lock()
+	w.updates = append(w.updates, update)
+	w.mu.Unlock()
+	log.Debugf("sandbox: progress update received: %s", update.Message)
+	w.onUpdate(update)
+}
+
+// Updates returns a copy of the progress updates received.
+func (w *worker) Updates() []sbplugin.ProgressUpdate {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]sbplugin.ProgressUpdate{}, w.updates...)
+}
+
+// runWorker waits for the results of the plugin, reporting the plugin complete to the
+// aggregator as the sonobuoy worker does once the results are submitted.
+func (s *Sandbox) runWorker() {
+	defer s.wg.Done()
+	data, err := s.waitForFile(s.Workspace.ResultsDoneFile())
+	if err != nil {
+		if !errors.Is(err, errStopped) {
+			log.Errorf("sandbox: worker: %v", err)
+		}
+		return
+	}
+	resultStatus := "passed"
+	junitFile, err := ResultJUnit(strings.TrimSpace(string(data)))
+	var junit []byte
+	if err == nil {
+		junit, err = os.ReadFile(junitFile)
+	}
+	if err != nil {
+		log.Errorf("sandbox: worker: unable to read the results: %v", err)
+		resultStatus = "unknown"
+	} else if strings.Contains(string(junit), "<failure") {
+		resultStatus = "failed"
+	}
+	if err := s.Cluster.setStatus(s.pluginName(), sbaggregation.CompleteStatus, resultStatus); err != nil {
+		log.Errorf("sandbox: worker: %v", err)
+	}
+}
+
+// Close stops the progress endpoint.
+func (w *worker) Close() error {
+	return w.server.Close()
+}
@@ -0,0 +1,449 @@
+/*
+Package simulator simulates the openshift-tests execution of a suite, for deterministic
+testing of the plugin parsing and reporting.
+
+The tests of the suite list run in a virtual clock, limited by the parallelism, with the
+outcome and the duration of each test drawn from the distribution by a seeded random
+source: the same options produce the same output. The simulator writes the openshift-tests
+stdout (started and result lines, the failure output and the summary) as consumed by the
+plugin from the FIFO, and the JUnit and the done files written by the tests container.
+
+Flaky tests fail in the first attempt and pass when retried, after the suite.
+*/
+package simulator
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+// Outcome* are the outcomes of the tests in the distribution.
+const (
+	OutcomePassed  = "passed"
+	OutcomeFailed  = "failed"
+	OutcomeSkipped = "skipped"
+	OutcomeFlaky   = "flaky"
+)
+
+// DefaultParallelism is the number of tests running at the same time, as openshift-tests.
+const DefaultParallelism = 30
+
+// timestampLayout is the layout of the timestamp in the result lines.
+const timestampLayout = "2006-01-02T15:04:05"
+
+// Distribution is the ratio of the outcomes and the durations of the tests.
+type Distribution struct {
+	Passed  float64
+	Failed  float64
+	Skipped float64
+	Flaky   float64
+	// DurationMedian is the median duration of the tests, log-normal distributed.
+	DurationMedian time.Duration
+	// DurationMax is the maximum duration of a test, example: the test timeout.
+	DurationMax time.Duration
+}
+
+// DefaultDistribution returns the distribution of a conformance suite.
+func DefaultDistribution() Distribution {
+	return Distribution{
+		Passed:         0.90,
+		Failed:         0.02,
+		Skipped:        0.07,
+		Flaky:          0.01,
+		DurationMedian: 5 * time.Second,
+		DurationMax:    15 * time.Minute,
+	}
+}
+
+// ParseDistribution parses the ratio of the outcomes, example: passed=90,failed=2,skipped=7,flaky=1,
+// into the default distribution. The outcomes not set have the ratio zero.
+func ParseDistribution(value string) (Distribution, error) {
+	d := DefaultDistribution()
+	d.Passed, d.Failed, d.Skipped, d.Flaky = 0, 0, 0, 0
+	for _, item := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return d, fmt.Errorf("invalid outcome %q, want <outcome>=<ratio>", item)
+		}
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil || ratio < 0 {
+			return d, fmt.Errorf("invalid ratio of the outcome %q: %q", key, val)
+		}
+		switch strings.TrimSpace(key) {
+		case OutcomePassed:
+			d.Passed = ratio
+		case OutcomeFailed:
+			d.Failed = ratio
+		case OutcomeSkipped:
+			d.Skipped = ratio
+		case OutcomeFlaky:
+			d.Flaky = ratio
+		default:
+			return d, fmt.Errorf("unknown outcome %q, want one of: passed, failed, skipped, flaky", key)
+		}
+	}
+	if d.Passed+d.Failed+d.Skipped+d.Flaky <= 0 {
+		return d, fmt.Errorf("invalid distribution %q: the sum of the ratios must be positive", value)
+	}
+	return d, nil
+}
+
+// Options are the options of the simulated run.
+type Options struct {
+	// Tests are the names of the tests, quoted as in the suite list.
+	Tests        []string
+	Distribution Distribution
+	// Parallelism is the number of tests running at the same time. Default: DefaultParallelism.
+	Parallelism int
+	// Seed is the seed of the random source of the outcomes and the durations.
+	Seed int64
+	// StartTime is the start time of the virtual clock. Default: now.
+	StartTime time.Time
+	// Speed is the ratio of the simulated time to the wall clock, example: 60 writes one
+	// minute of execution per second. Zero writes the output without waiting.
+	Speed float64
+}
+
+// Summary are the counters of the final outcomes of the tests.
+type Summary struct {
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	Flaky   int `json:"flaky"`
+}
+
+// Report is the result of the simulated run.
+type Report struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Summary    Summary
+	// Results are the test cases of the JUnit, the flaky tests are reported by the failed
+	// and the passed attempts.
+	Results []*plugin.JUnitTestReport
+}
+
+// execution is a test attempt running in the virtual clock.
+type execution struct {
+	index    int
+	name     string
+	outcome  string
+	attempt  int
+	start    time.Time
+	duration time.Duration
+	end      time.Time
+}
+
+// executions is the heap of the running tests, by end time and start order.
+type executions []*execution
+
+func (e executions) Len() int { return len(e) }
+func (e executions) Less(i, j int) bool {
+	if e[i].end.Equal(e[j].end) {
+		return e[i].index < e[j].index
+	}
+	return e[i].end.Before(e[j].end)
+}
+func (e executions) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e *executions) Push(x any)   { *e = append(*e, x.(*execution)) }
+func (e *executions) Pop() any {
+	old := *e
+	x := old[len(old)-1]
+	*e = old[:len(old)-1]
+	return x
+}
+
+// Simulator writes the output of a simulated openshift-tests run.
+type Simulator struct {
+	opts Options
+	rnd  *rand.Rand
+
+	out       *bufio.Writer
+	wallStart time.Time
+	clock     time.Time
+	started   int
+	failures  int
+}
+
+// New creates the simulator with the options.
+func New(opts Options) *Simulator {
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = DefaultParallelism
+	}
+	if opts.StartTime.IsZero() {
+		opts.StartTime = time.Now().UTC()
+	}
+	if opts.Distribution.DurationMedian <= 0 {
+		opts.Distribution.DurationMedian = DefaultDistribution().DurationMedian
+	}
+	if opts.Distribution.DurationMax <= 0 {
+		opts.Distribution.DurationMax = DefaultDistribution().DurationMax
+	}
+	return &Simulator{opts: opts, rnd: rand.New(rand.NewSource(opts.Seed))}
+}
+
+// Run writes the openshift-tests output of the simulated run to the writer, returning
+// the report of the results.
+func (s *Simulator) Run(ctx context.Context, w io.Writer) (*Report, error) {
+	s.out = bufio.NewWriter(w)
+	s.wallStart = time.Now()
+	s.clock = s.opts.StartTime
+	report := &Report{StartedAt: s.opts.StartTime}
+
+	if err := s.writeLine("openshift-tests version: simulated (seed %d, %d tests, parallelism %d)", s.opts.Seed, len(s.opts.Tests), s.opts.Parallelism); err != nil {
+		return nil, err
+	}
+	pending := make([]*execution, 0, len(s.opts.Tests))
+	for i, name := range s.opts.Tests {
+		pending = append(pending, &execution{index: i, name: name, attempt: 1})
+	}
+	flaky, failing := []string{}, []string{}
+	retries := []*execution{}
+	running := &executions{}
+	total := len(s.opts.Tests)
+	for len(pending) > 0 || running.Len() > 0 || len(retries) > 0 {
+		if len(pending) == 0 && running.Len() == 0 {
+			// the failed attempts of the flaky tests are retried after the suite.
+			if err := s.writeLine("Retrying %d failed tests\n", len(retries)); err != nil {
+				return nil, err
+			}
+			pending, retries = retries, nil
+		}
+		for running.Len() < s.opts.Parallelism && len(pending) > 0 {
+			e := pending[0]
+			pending = pending[1:]
+			s.start(e)
+			heap.Push(running, e)
+			if err := s.writeLine("started: %d/%d/%d %s\n", s.failures, s.started, total, e.name); err != nil {
+				return nil, err
+			}
+		}
+		e := heap.Pop(running).(*execution)
+		if err := s.wait(ctx, e.end); err != nil {
+			return nil, err
+		}
+		result := &plugin.JUnitTestReport{Name: unquoteTestName(e.name), Duration: e.duration}
+		switch e.outcome {
+		case OutcomeSkipped:
+			result.Result = OutcomeSkipped
+			result.Message = "skipped by the simulator"
+			report.Summary.Skipped++
+		case OutcomeFailed, OutcomeFlaky:
+			result.Result = OutcomeFailed
+			result.Message, result.Output = s.failureOutput(e)
+			if err := s.writeLine("%s\n", result.Output); err != nil {
+				return nil, err
+			}
+			s.failures++
+			if e.outcome == OutcomeFlaky {
+				retries = append(retries, &execution{index: e.index, name: e.name, outcome: OutcomePassed, attempt: e.attempt + 1})
+			} else {
+				failing = append(failing, e.name)
+				report.Summary.Failed++
+			}
+		default:
+			result.Result = OutcomePassed
+			if e.attempt > 1 {
+				flaky = append(flaky, e.name)
+				report.Summary.Flaky++
+			} else {
+				report.Summary.Passed++
+			}
+		}
+		report.Results = append(report.Results, result)
+		if err := s.writeLine("%s: (%s) %s %s\n", result.Result, formatDuration(e.duration), e.end.Format(timestampLayout), e.name); err != nil {
+			return nil, err
+		}
+	}
+	report.FinishedAt = s.clock
+	if err := s.writeSummary(report, flaky, failing); err != nil {
+		return nil, err
+	}
+	if err := s.out.Flush(); err != nil {
+		return nil, fmt.Errorf("unable to write the output: %w", err)
+	}
+	return report, nil
+}
+
+// start draws the outcome and the duration of the test attempt, started in the clock.
+func (s *Simulator) start(e *execution) {
+	s.started++
+	if len(e.outcome) == 0 {
+		e.outcome = s.drawOutcome()
+	}
+	e.start = s.clock
+	e.duration = s.drawDuration(e.outcome)
+	e.end = e.start.Add(e.duration)
+}
+
+// drawOutcome returns an outcome by the ratios of the distribution.
+func (s *Simulator) drawOutcome() string {
+	d := s.opts.Distribution
+	r := s.rnd.Float64() * (d.Passed + d.Failed + d.Skipped + d.Flaky)
+	for _, o := range []struct {
+		outcome string
+		ratio   float64
+	}{{OutcomePassed, d.Passed}, {OutcomeFailed, d.Failed}, {OutcomeSkipped, d.Skipped}, {OutcomeFlaky, d.Flaky}} {
+		if r < o.ratio {
+			return o.outcome
+		}
+		r -= o.ratio
+	}
+	return OutcomePassed
+}
+
+// drawDuration returns a log-normal duration around the median, limited to the maximum.
+// The skipped tests finish immediately.
+func (s *Simulator) drawDuration(outcome string) time.Duration {
+	if outcome == OutcomeSkipped {
+		return time.Duration(s.rnd.Intn(500)) * time.Millisecond
+	}
+	d := time.Duration(float64(s.opts.Distribution.DurationMedian) * math.Exp(s.rnd.NormFloat64()))
+	d = d.Round(100 * time.Millisecond)
+	if d < 100*time.Millisecond {
+		d = 100 * time.Millisecond
+	}
+	if d > s.opts.Distribution.DurationMax {
+		d = s.opts.Distribution.DurationMax
+	}
+	return d
+}
+
+// failureOutput returns the failure message and the output printed by the failed test.
+func (s *Simulator) failureOutput(e *execution) (string, string) {
+	message := fmt.Sprintf("fail [github.com/openshift/origin/test/extended/simulated/simulated.go:%d]: simulated failure of the test %d (attempt %d)", 100+e.index%900, e.index, e.attempt)
+	output := strings.Join([]string{
+		fmt.Sprintf("%s: INFO: running the test %d", e.start.Format("Jan _2 15:04:05.000"), e.index),
+		fmt.Sprintf("%s: INFO: unexpected error waiting for the simulated condition", e.end.Format("Jan _2 15:04:05.000")),
+		message,
+	}, "\n")
+	return message, output
+}
+
+// writeSummary writes the flaky and the failing tests, and the counters of the run.
+func (s *Simulator) writeSummary(report *Report, flaky, failing []string) error {
+	for _, section := range []struct {
+		title string
+		tests []string
+	}{{"Flaky tests", flaky}, {"Failing tests", failing}} {
+		if len(section.tests) == 0 {
+			continue
+		}
+		if err := s.writeLine("%s:\n\n%s\n", section.title, strings.Join(section.tests, "\n")); err != nil {
+			return err
+		}
+	}
+	sum := report.Summary
+	counters := fmt.Sprintf("%d pass, %d flaky, %d skip (%s)", sum.Passed+sum.Flaky, sum.Flaky, sum.Skipped, report.FinishedAt.Sub(report.StartedAt).Round(time.Second))
+	if sum.Failed > 0 {
+		return s.writeLine("error: %d fail, %s", sum.Failed, counters)
+	}
+	return s.writeLine("%s", counters)
+}
+
+// wait advances the clock to the time, waiting for the wall clock by the speed.
+func (s *Simulator) wait(ctx context.Context, t time.Time) error {
+	if t.After(s.clock) {
+		s.clock = t
+	}
+	if s.opts.Speed <= 0 {
+		return ctx.Err()
+	}
+	if err := s.out.Flush(); err != nil {
+		return fmt.Errorf("unable to write the output: %w", err)
+	}
+	wall := s.wallStart.Add(time.Duration(float64(s.clock.Sub(s.opts.StartTime)) / s.opts.Speed))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(wall)):
+		return nil
+	}
+}
+
+// writeLine writes the formatted line to the output.
+func (s *Simulator) writeLine(format string, args ...any) error {
+	if _, err := fmt.Fprintf(s.out, format+"\n", args...); err != nil {
+		return fmt.Errorf("unable to write the output: %w", err)
+	}
+	return nil
+}
+
+// WriteFiles writes the files of the tests container in the workspace: the JUnit of the
+// results, the done record with the runner status (exit code 1 when a test failed) and
+// the done file.
+func (r *Report) WriteFiles(ws *plugin.Workspace) error {
+	junit := filepath.Join(ws.JUnitDir(), fmt.Sprintf("junit_e2e__%s.xml", r.StartedAt.UTC().Format("20060102-150405")))
+	if err := plugin.WriteJUnitTestSuite(junit, "openshift-tests", r.Results); err != nil {
+		return err
+	}
+	status := &plugin.RunnerStatus{
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		Command:    []string{"openshift-tests", "run", "--simulated"},
+	}
+	if r.Summary.Failed > 0 {
+		status.ExitCode = 1
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("unable to render the done record: %w", err)
+	}
+	if err := os.WriteFile(ws.DoneRecord(), data, 0644); err != nil {
+		return fmt.Errorf("unable to write the done record: %w", err)
+	}
+	if err := os.WriteFile(ws.DoneFile(), []byte{}, 0644); err != nil {
+		return fmt.Errorf("unable to write the done file: %w", err)
+	}
+	return nil
+}
+
+// ReadSuiteList reads the test names of the suite list, quoted as in the openshift-tests output.
+func ReadSuiteList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the suite list: %w", err)
+	}
+	defer file.Close()
+	tests := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, `"`) {
+			continue
+		}
+		tests = append(tests, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read the suite list: %w", err)
+	}
+	return tests, nil
+}
+
+// formatDuration formats the test duration as openshift-tests, example: 2.1s or 5m2s.
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
+
+// unquoteTestName returns the test name of the openshift-tests output.
+func unquoteTestName(name string) string {
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		return unquoted
+	}
+	return strings.Trim(name, `"`)
+}
@@ -0,0 +1,165 @@
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-plugins/openshift-tests-plugin/pkg/plugin"
+)
+
+// suite returns the test names of a suite with the count of tests.
+func suite(count int) []string {
+	tests := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		tests = append(tests, fmt.Sprintf(`"[sig-simulated] test %05d should \"pass\" [Suite:openshift/conformance/parallel]"`, i))
+	}
+	return tests
+}
+
+func TestParseDistribution(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    [4]float64
+		wantErr bool
+	}{
+		{value: "passed=90,failed=2,skipped=7,flaky=1", want: [4]float64{90, 2, 7, 1}},
+		{value: "passed=1, failed=1", want: [4]float64{1, 1, 0, 0}},
+		{value: "passed=0", wantErr: true},
+		{value: "passed=-1,failed=1", wantErr: true},
+		{value: "timeout=1", wantErr: true},
+		{value: "passed", wantErr: true},
+	}
+	for _, tt := range tests {
+		d, err := ParseDistribution(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDistribution(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got := [4]float64{d.Passed, d.Failed, d.Skipped, d.Flaky}; !tt.wantErr && got != tt.want {
+			t.Errorf("ParseDistribution(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestRunDeterministic tests the same options produce the same output.
+func TestRunDeterministic(t *testing.T) {
+	run := func(seed int64) string {
+		var out bytes.Buffer
+		opts := Options{Tests: suite(200), Distribution: DefaultDistribution(), Seed: seed, StartTime: time.Date(2024, 7, 5, 20, 0, 0, 0, time.UTC)}
+		if _, err := New(opts).Run(context.TODO(), &out); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		return out.String()
+	}
+	if run(1) != run(1) {
+		t.Errorf("Run() output differs with the same seed")
+	}
+	if run(1) == run(2) {
+		t.Errorf("Run() output is the same with different seeds")
+	}
+}
+
+// TestRunLoad tests the plugin parser reports the counters of a simulated run of a
+// large suite, and the JUnit and the done files match the output.
+func TestRunLoad(t *testing.T) {
+	level := log.GetLevel()
+	log.SetLevel(log.FatalLevel)
+	defer log.SetLevel(level)
+
+	const count = 20000
+	var out bytes.Buffer
+	report, err := New(Options{Tests: suite(count), Distribution: DefaultDistribution(), Seed: 46}).Run(context.TODO(), &out)
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	sum := report.Summary
+	if got := sum.Passed + sum.Failed + sum.Skipped + sum.Flaky; got != count {
+		t.Fatalf("Run() summary = %+v, want %d tests", sum, count)
+	}
+	// the outcomes follow the distribution, with 3% of tolerance.
+	d := DefaultDistribution()
+	for _, o := range []struct {
+		name  string
+		got   int
+		ratio float64
+	}{{OutcomePassed, sum.Passed, d.Passed}, {OutcomeFailed, sum.Failed, d.Failed}, {OutcomeSkipped, sum.Skipped, d.Skipped}, {OutcomeFlaky, sum.Flaky, d.Flaky}} {
+		if math.Abs(float64(o.got)/count-o.ratio) > 0.03 {
+			t.Errorf("Run() %s = %d, want the ratio %.2f of %d tests", o.name, o.got, o.ratio, count)
+		}
+	}
+
+	progress := plugin.NewPluginProgress()
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		if _, err := progress.ParserOpenShiftTestsOutputLine(scanner.Text()); err != nil {
+			t.Fatalf("ParserOpenShiftTestsOutputLine() unexpected error: %v", err)
+		}
+	}
+	progress.UpdateTotalCounters()
+	// the flaky tests are reported by the failed and the passed attempts.
+	want := fmt.Sprintf("T/C/P/F/S=%d/%d/%d/%d/%d", count+sum.Flaky, count+sum.Flaky, sum.Passed+sum.Flaky, sum.Failed+sum.Flaky, sum.Skipped)
+	if got := progress.GetTotalCountersString(); got != want {
+		t.Errorf("parser counters = %s, want %s", got, want)
+	}
+	if stats := progress.ParserStats(); stats.Errors != 0 || len(progress.TestMap) != count {
+		t.Errorf("parser errors = %v, tests = %d, want no errors and %d tests", stats.LastErrors, len(progress.TestMap), count)
+	}
+
+	ws := plugin.NewWorkspace(t.TempDir(), "")
+	if err := ws.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := report.WriteFiles(ws); err != nil {
+		t.Fatalf("WriteFiles() unexpected error: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(ws.JUnitDir(), "junit_e2e__*.xml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("JUnit files = %v (%v), want one openshift-tests JUnit", files, err)
+	}
+	junit, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs := fmt.Sprintf(`tests="%d" skipped="%d" failures="%d"`, count+sum.Flaky, sum.Skipped, sum.Failed+sum.Flaky); !strings.Contains(string(junit), attrs) {
+		t.Errorf("JUnit = %s, want the suite %s", string(junit[:200]), attrs)
+	}
+	status := plugin.RunnerStatus{}
+	data, err := os.ReadFile(ws.DoneRecord())
+	if err != nil || json.Unmarshal(data, &status) != nil || status.ExitCode != 1 || !status.FinishedAt.After(status.StartedAt) {
+		t.Errorf("done record = %s (%v), want the runner failed", string(data), err)
+	}
+	if _, err := os.Stat(ws.DoneFile()); err != nil {
+		t.Errorf("done file not created: %v", err)
+	}
+}
+
+// TestRunSpeed tests the output is written by the speed of the simulated time.
+func TestRunSpeed(t *testing.T) {
+	d := DefaultDistribution()
+	d.DurationMedian, d.DurationMax = time.Second, time.Second
+	opts := Options{Tests: suite(4), Distribution: d, Parallelism: 1, Speed: 20}
+	start := time.Now()
+	if _, err := New(opts).Run(context.TODO(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Run() took %v, want about 200ms for 4s of tests at speed 20", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err := New(opts).Run(ctx, &bytes.Buffer{}); err == nil {
+		t.Errorf("Run() with the context canceled, want error")
+	}
+}
@@ -0,0 +1,193 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	kcorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultNamespace is the namespace of the plugins.
+	DefaultNamespace = "opct"
+	// DefaultCollectorSelector selects the artifacts collector pod.
+	DefaultCollectorSelector = "sonobuoy-plugin=99-openshift-artifacts-collector"
+
+	DefaultRetries = 5
+	DefaultBackoff = 2 * time.Second
+)
+
+// permanentError is an error which must not be retried.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks the error to not be retried by Retry.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Retry calls fn until it succeeds, returns a permanent error or the attempts are
+// exhausted, doubling the backoff after each failure.
+func Retry(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		var perr *permanentError
+		if errors.As(err, &perr) {
+			return perr.err
+		}
+		if i == attempts-1 {
+			break
+		}
+		log.Warnf("attempt %d/%d failed, retrying in %v: %v", i+1, attempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+}
+
+// Client uploads artifacts to the upload API.
+type Client struct {
+	// URL is the upload API URL, which must be https.
+	URL   string
+	Token string
+	// HTTPClient verifies the server certificate (NewHTTPClient). Default: http.DefaultClient.
+	HTTPClient *http.Client
+	Retries    int
+	Backoff    time.Duration
+}
+
+// Upload uploads the file as the artifact name, retrying on transient errors,
+// and validating the checksum saved by the server.
+func (c *Client) Upload(ctx context.Context, file, name string) (*UploadResponse, error) {
+	if !reArtifactName.MatchString(name) {
+		return nil, fmt.Errorf("invalid artifact name %q, must match %s", name, reArtifactName)
+	}
+	if !strings.HasPrefix(c.URL, "https://") {
+		return nil, fmt.Errorf("invalid upload URL %q, the token is sent only over https", c.URL)
+	}
+	sum, err := fileSHA256(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	retries, backoff := c.Retries, c.Backoff
+	if retries <= 0 {
+		retries = DefaultRetries
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	var res *UploadResponse
+	err = Retry(ctx, retries, backoff, func() error {
+		var err error
+		res, err = c.upload(ctx, file, name, sum)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to upload %s: %w", name, err)
+	}
+	return res, nil
+}
+
+// upload sends the file, returning a permanent error when the request is rejected.
+func (c *Client) upload(ctx context.Context, file, name, sum string) (*UploadResponse, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+	defer fd.Close()
+	info, err := fd.Stat()
+	if err != nil {
+		return nil, Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(c.URL, "/")+UploadPath+name, fd)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set(HeaderSHA256, sum)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	res := &UploadResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	decodeErr := json.Unmarshal(body, res)
+	if resp.StatusCode != http.StatusCreated {
+		err := fmt.Errorf("upload rejected (status %s): %s", resp.Status, res.Error)
+		switch {
+		case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout,
+			resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusUnprocessableEntity:
+			// transient errors, including the checksum mismatch of corrupted transfers.
+			return nil, err
+		}
+		return nil, Permanent(err)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("invalid response: %w", decodeErr)
+	}
+	if res.SHA256 != sum {
+		return nil, fmt.Errorf("checksum mismatch: saved %s, want %s", res.SHA256, sum)
+	}
+	return res, nil
+}
+
+// DiscoverCollectorURL returns the upload API URL of the running collector pod.
+func DiscoverCollectorURL(ctx context.Context, client kubernetes.Interface, namespace, selector string, port int) (string, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", fmt.Errorf("error listing collector pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == kcorev1.PodRunning && len(pod.Status.PodIP) > 0 {
+			return fmt.Sprintf("https://%s:%d", pod.Status.PodIP, port), nil
+		}
+	}
+	return "", fmt.Errorf("no running collector pod found with selector %q in namespace %s", selector, namespace)
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of the file.
+func fileSHA256(file string) (string, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
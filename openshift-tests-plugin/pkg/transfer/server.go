@@ -0,0 +1,218 @@
+/*
+Package transfer implements the artifact hand-off from the conformance plugins
+to the artifacts collector plugin (99-openshift-artifacts-collector).
+
+The collector serves an HTTPS upload API saving the artifacts into the results
+directory. Uploads are authenticated by the service account token of the client,
+validated by the TokenReview API, and the integrity of each artifact is checked
+by the SHA-256 sent by the client before the artifact is saved. The clients verify
+the server certificate by the CA read from a file (example: the service CA) or
+published by the collector in a ConfigMap, so the token is never sent in clear.
+*/
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultPort is the port of the upload API served by the collector.
+	DefaultPort = 8090
+	// UploadPath is the API path to upload the artifact: PUT /v1/artifacts/<name>.
+	UploadPath = "/v1/artifacts/"
+	// HeaderSHA256 holds the hex encoded SHA-256 of the uploaded artifact.
+	HeaderSHA256 = "X-Artifact-Sha256"
+
+	// DefaultMaxSize is the maximum artifact size accepted by the server.
+	DefaultMaxSize = 2 << 30
+)
+
+// reArtifactName matches the artifact names accepted by the server: the e2e artifacts
+// of the conformance plugins (prefix 'artifacts_e2e-'), packed by the collector. The
+// other artifacts are owned by the collector (example: the collector manifest and JUnit).
+var reArtifactName = regexp.MustCompile(`^artifacts_e2e-[A-Za-z0-9._-]+$`)
+
+// Authenticator validates the bearer token of the upload requests.
+type Authenticator func(ctx context.Context, token string) error
+
+// NewTokenReviewAuthenticator accepts the tokens of the service accounts in the
+// namespace, validated by the TokenReview API.
+func NewTokenReviewAuthenticator(client kubernetes.Interface, namespace string) Authenticator {
+	prefix := fmt.Sprintf("system:serviceaccount:%s:", namespace)
+	return func(ctx context.Context, token string) error {
+		review, err := client.AuthenticationV1().TokenReviews().Create(ctx, &authv1.TokenReview{
+			Spec: authv1.TokenReviewSpec{Token: token},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("token review failed: %w", err)
+		}
+		if !review.Status.Authenticated {
+			return fmt.Errorf("token not authenticated: %s", review.Status.Error)
+		}
+		if !strings.HasPrefix(review.Status.User.Username, prefix) {
+			return fmt.Errorf("user %q is not a service account of namespace %s", review.Status.User.Username, namespace)
+		}
+		return nil
+	}
+}
+
+// UploadResponse is the response of the upload API.
+type UploadResponse struct {
+	Name   string `json:"name,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Server is the upload API saving the artifacts into Dir.
+type Server struct {
+	Dir  string
+	Addr string
+	// Auth validates the requests. Requests are rejected when nil.
+	Auth    Authenticator
+	MaxSize int64
+	// TLSConfig holds the serving certificate, required by ListenAndServe.
+	TLSConfig *tls.Config
+}
+
+// Handler returns the HTTP handler of the upload API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc(UploadPath, s.handleUpload)
+	return mux
+}
+
+// ListenAndServe serves the upload API until the context is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	if s.TLSConfig == nil {
+		return fmt.Errorf("TLS config not set, the upload API is served only over TLS")
+	}
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		TLSConfig:         s.TLSConfig,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	log.Infof("Serving artifacts upload API on %s, saving to %s", s.Addr, s.Dir)
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handleUpload saves the artifact when the checksum matches the request.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeResponse(w, http.StatusMethodNotAllowed, &UploadResponse{Error: "method not allowed"})
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.Auth == nil || len(token) == 0 || token == r.Header.Get("Authorization") {
+		writeResponse(w, http.StatusUnauthorized, &UploadResponse{Error: "missing bearer token"})
+		return
+	}
+	if err := s.Auth(r.Context(), token); err != nil {
+		log.Warnf("Upload rejected from %s: %v", r.RemoteAddr, err)
+		writeResponse(w, http.StatusForbidden, &UploadResponse{Error: "forbidden"})
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, UploadPath)
+	if !reArtifactName.MatchString(name) {
+		writeResponse(w, http.StatusBadRequest, &UploadResponse{Error: fmt.Sprintf("invalid artifact name %q", name)})
+		return
+	}
+	want := strings.ToLower(r.Header.Get(HeaderSHA256))
+	if sum, err := hex.DecodeString(want); err != nil || len(sum) != sha256.Size {
+		writeResponse(w, http.StatusBadRequest, &UploadResponse{Error: "missing or invalid header " + HeaderSHA256})
+		return
+	}
+
+	maxSize := s.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	res, status, err := s.save(http.MaxBytesReader(w, r.Body, maxSize), name, want)
+	if err != nil {
+		log.Errorf("Upload of %s failed: %v", name, err)
+		writeResponse(w, status, &UploadResponse{Name: name, Error: err.Error()})
+		return
+	}
+	log.Infof("Artifact %s received (%d bytes, sha256 %s)", name, res.Size, res.SHA256)
+	writeResponse(w, http.StatusCreated, res)
+}
+
+// save writes the body into a temporary file, linked to the artifact when the
+// checksum matches, returning the HTTP status when failed. An existing artifact is
+// not overwritten: the upload is accepted only when it has the same checksum
+// (example: the retry of an upload saved by the server).
+func (s *Server) save(body io.Reader, name, want string) (*UploadResponse, int, error) {
+	tmp, err := os.CreateTemp(s.Dir, ".upload-"+name+"-")
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("error creating file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("artifact larger than %d bytes", maxErr.Limit)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("error receiving artifact: %w", err)
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != want {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("checksum mismatch: received %s, want %s", got, want)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	dst := filepath.Join(s.Dir, name)
+	if err := os.Link(tmp.Name(), dst); err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return nil, http.StatusInternalServerError, fmt.Errorf("error saving artifact: %w", err)
+		}
+		if sum, err := fileSHA256(dst); err != nil || sum != got {
+			return nil, http.StatusConflict, fmt.Errorf("artifact %s already exists", name)
+		}
+	}
+	return &UploadResponse{Name: name, Size: size, SHA256: got}, http.StatusCreated, nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, res *UploadResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
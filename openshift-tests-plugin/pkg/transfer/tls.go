@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	kcorev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultServerName is the server name of the upload API certificate, verified by
+	// the client when connecting to the collector pod IP.
+	DefaultServerName = "artifacts-collector"
+	// CAConfigMap holds the CA of the upload API certificate generated by the collector,
+	// read by the clients when the CA file is not set.
+	CAConfigMap = "artifacts-collector-ca"
+	// CAConfigMapKey is the key of the PEM encoded CA in CAConfigMap.
+	CAConfigMapKey = "ca.crt"
+
+	// DefaultCertValidity is the validity of the certificate generated by the collector.
+	DefaultCertValidity = 7 * 24 * time.Hour
+)
+
+// GenerateCertificate creates the self-signed serving certificate of the server name,
+// returning the certificate and its PEM, used as the CA by the clients.
+func GenerateCertificate(serverName string, validity time.Duration) (tls.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("error generating key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("error generating serial: %w", err)
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: serverName},
+		DNSNames:              []string{serverName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("error creating certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("error encoding key: %w", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	return cert, certPEM, nil
+}
+
+// PublishCA saves the CA of the upload API certificate to CAConfigMap.
+func PublishCA(ctx context.Context, client kubernetes.Interface, namespace string, caPEM []byte) error {
+	cm := &kcorev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CAConfigMap, Namespace: namespace},
+		Data:       map[string]string{CAConfigMapKey: string(caPEM)},
+	}
+	cms := client.CoreV1().ConfigMaps(namespace)
+	_, err := cms.Create(ctx, cm, metav1.CreateOptions{})
+	if kerrors.IsAlreadyExists(err) {
+		_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error saving ConfigMap %s: %w", CAConfigMap, err)
+	}
+	return nil
+}
+
+// ReadCA reads the CA of the upload API certificate from CAConfigMap.
+func ReadCA(ctx context.Context, client kubernetes.Interface, namespace string) ([]byte, error) {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, CAConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading ConfigMap %s: %w", CAConfigMap, err)
+	}
+	ca := cm.Data[CAConfigMapKey]
+	if len(ca) == 0 {
+		return nil, fmt.Errorf("ConfigMap %s has no %s", CAConfigMap, CAConfigMapKey)
+	}
+	return []byte(ca), nil
+}
+
+// NewHTTPClient creates the client trusting only the CA, verifying the certificate of
+// the server name.
+func NewHTTPClient(caPEM []byte, serverName string) (*http.Client, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in the CA")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    pool,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,229 @@
+package transfer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	authv1 "k8s.io/api/authentication/v1"
+	kcorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// tokenAuth accepts only the token 'valid'.
+func tokenAuth(ctx context.Context, token string) error {
+	if token != "valid" {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func writeTestFile(t *testing.T, data string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// TestUpload tests the artifact is saved, and the rejected requests are not retried.
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewTLSServer((&Server{Dir: dir, Auth: tokenAuth, MaxSize: 64}).Handler())
+	defer server.Close()
+	file := writeTestFile(t, "e2e metadata")
+
+	client := &Client{URL: server.URL, Token: "valid", HTTPClient: server.Client(), Retries: 3, Backoff: time.Millisecond}
+	res, err := client.Upload(context.Background(), file, "artifacts_e2e-metadata-plugin.tar.gz")
+	if err != nil {
+		t.Fatalf("Upload() unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "artifacts_e2e-metadata-plugin.tar.gz"))
+	if err != nil || string(data) != "e2e metadata" || res.Size != 12 {
+		t.Errorf("uploaded artifact = %q (%v) response %+v, want file saved", string(data), err, res)
+	}
+
+	// the retry of a saved upload is accepted, an existing artifact is not overwritten.
+	if _, err := client.Upload(context.Background(), file, "artifacts_e2e-metadata-plugin.tar.gz"); err != nil {
+		t.Errorf("Upload() of the same artifact unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		url     string
+		file    string
+		wantErr string
+	}{
+		{name: "artifacts_e2e-a.txt", token: "invalid", file: file, wantErr: "403"},
+		{name: "../artifacts_e2e-a.txt", token: "valid", file: file, wantErr: "invalid artifact name"},
+		{name: "results.txt", token: "valid", file: file, wantErr: "invalid artifact name"},
+		{name: "artifacts_collector_manifest.json", token: "valid", file: file, wantErr: "invalid artifact name"},
+		{name: "artifacts_e2e-large.txt", token: "valid", file: writeTestFile(t, strings.Repeat("x", 65)), wantErr: "413"},
+		{name: "artifacts_e2e-metadata-plugin.tar.gz", token: "valid", file: writeTestFile(t, "other"), wantErr: "already exists"},
+		{name: "artifacts_e2e-a.txt", token: "valid", url: strings.Replace(server.URL, "https://", "http://", 1), file: file, wantErr: "https"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := server.URL
+			if len(tt.url) > 0 {
+				url = tt.url
+			}
+			c := &Client{URL: url, Token: tt.token, HTTPClient: server.Client(), Retries: 3, Backoff: time.Millisecond}
+			_, err := c.Upload(context.Background(), tt.file, tt.name)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || strings.Contains(err.Error(), "attempts") {
+				t.Errorf("Upload() error = %v, want %q without retries", err, tt.wantErr)
+			}
+		})
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("server dir has %d entries, want only the uploaded artifact", len(entries))
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "artifacts_e2e-metadata-plugin.tar.gz"))
+	if err != nil || string(data) != "e2e metadata" {
+		t.Errorf("uploaded artifact = %q (%v), want not overwritten", string(data), err)
+	}
+
+	// the collector artifacts are rejected by the server.
+	req, _ := http.NewRequest(http.MethodPut, server.URL+UploadPath+"artifacts_collector_junit.xml", strings.NewReader("x"))
+	req.Header.Set("Authorization", "Bearer valid")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("upload of a collector artifact status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+// TestGenerateCertificate tests the client verifies the generated certificate by the CA
+// published in the ConfigMap, and the server name.
+func TestGenerateCertificate(t *testing.T) {
+	cert, caPEM, err := GenerateCertificate(DefaultServerName, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCertificate() unexpected error: %v", err)
+	}
+	kclient := kfake.NewSimpleClientset()
+	if err := PublishCA(context.Background(), kclient, "opct", caPEM); err != nil {
+		t.Fatalf("PublishCA() unexpected error: %v", err)
+	}
+	ca, err := ReadCA(context.Background(), kclient, "opct")
+	if err != nil || string(ca) != string(caPEM) {
+		t.Fatalf("ReadCA() = %q (%v), want the published CA", string(ca), err)
+	}
+
+	server := httptest.NewUnstartedServer((&Server{Dir: t.TempDir(), Auth: tokenAuth}).Handler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+
+	for serverName, wantErr := range map[string]string{DefaultServerName: "", "other": "certificate"} {
+		httpClient, err := NewHTTPClient(ca, serverName)
+		if err != nil {
+			t.Fatalf("NewHTTPClient() unexpected error: %v", err)
+		}
+		client := &Client{URL: server.URL, Token: "valid", HTTPClient: httpClient, Retries: 1, Backoff: time.Millisecond}
+		_, err = client.Upload(context.Background(), writeTestFile(t, "suite"), "artifacts_e2e-tests_"+serverName+".txt")
+		if (wantErr == "" && err != nil) || (wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr))) {
+			t.Errorf("server name %s: Upload() error = %v, want %q", serverName, err, wantErr)
+		}
+	}
+	if err := (&Server{Addr: ":0"}).ListenAndServe(context.Background()); err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Errorf("ListenAndServe() error = %v, want TLS required", err)
+	}
+}
+
+// TestUploadRetry tests the transient errors and corrupted transfers are retried.
+func TestUploadRetry(t *testing.T) {
+	dir := t.TempDir()
+	handler := (&Server{Dir: dir, Auth: tokenAuth}).Handler()
+	var calls atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"unavailable"}`))
+		case 2:
+			// corrupted transfer, rejected by the checksum.
+			r.Header.Set(HeaderSHA256, strings.Repeat("0", 64))
+			handler.ServeHTTP(w, r)
+		default:
+			handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, Token: "valid", HTTPClient: server.Client(), Retries: 3, Backoff: time.Millisecond}
+	if _, err := client.Upload(context.Background(), writeTestFile(t, "suite"), "artifacts_e2e-tests_plugin.txt"); err != nil {
+		t.Fatalf("Upload() unexpected error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Upload() requests = %d, want 3", calls.Load())
+	}
+
+	unavailable := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer unavailable.Close()
+	client = &Client{URL: unavailable.URL, Token: "valid", HTTPClient: unavailable.Client(), Retries: 2, Backoff: time.Millisecond}
+	_, err := client.Upload(context.Background(), writeTestFile(t, "suite"), "artifacts_e2e-tests_plugin.txt")
+	if err == nil || !strings.Contains(err.Error(), "failed after 2 attempts") {
+		t.Errorf("Upload() error = %v, want failed after 2 attempts", err)
+	}
+}
+
+// TestTokenReviewAuthenticator tests only service accounts of the namespace are accepted.
+func TestTokenReviewAuthenticator(t *testing.T) {
+	client := kfake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		review := action.(ktesting.CreateAction).GetObject().(*authv1.TokenReview)
+		switch review.Spec.Token {
+		case "opct-sa":
+			review.Status = authv1.TokenReviewStatus{Authenticated: true, User: authv1.UserInfo{Username: "system:serviceaccount:opct:sonobuoy-serviceaccount"}}
+		case "other-sa":
+			review.Status = authv1.TokenReviewStatus{Authenticated: true, User: authv1.UserInfo{Username: "system:serviceaccount:default:builder"}}
+		default:
+			review.Status = authv1.TokenReviewStatus{Error: "invalid bearer token"}
+		}
+		return true, review, nil
+	})
+	auth := NewTokenReviewAuthenticator(client, "opct")
+	for token, wantErr := range map[string]string{"opct-sa": "", "other-sa": "not a service account", "invalid": "not authenticated"} {
+		err := auth(context.Background(), token)
+		if (wantErr == "" && err != nil) || (wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr))) {
+			t.Errorf("token %s: error = %v, want %q", token, err, wantErr)
+		}
+	}
+}
+
+// TestDiscoverCollectorURL tests the URL of the running collector pod is discovered.
+func TestDiscoverCollectorURL(t *testing.T) {
+	pod := func(name string, phase kcorev1.PodPhase, ip string) *kcorev1.Pod {
+		return &kcorev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "opct", Labels: map[string]string{"sonobuoy-plugin": "99-openshift-artifacts-collector"}},
+			Status:     kcorev1.PodStatus{Phase: phase, PodIP: ip},
+		}
+	}
+	client := kfake.NewSimpleClientset(pod("old", kcorev1.PodFailed, "10.0.0.1"), pod("collector", kcorev1.PodRunning, "10.0.0.2"))
+	url, err := DiscoverCollectorURL(context.Background(), client, "opct", DefaultCollectorSelector, DefaultPort)
+	if err != nil || url != "https://10.0.0.2:8090" {
+		t.Errorf("DiscoverCollectorURL() = %q (%v), want running pod URL", url, err)
+	}
+	if _, err := DiscoverCollectorURL(context.Background(), kfake.NewSimpleClientset(), "opct", DefaultCollectorSelector, DefaultPort); err == nil {
+		t.Errorf("DiscoverCollectorURL() must fail without collector pod")
+	}
+}
@@ -20,7 +20,10 @@ declare -gr CTRL_DONE_PLUGIN="/tmp/sonobuoy/results/done"
 declare -gr CTRL_DONE_TESTS="/tmp/shared/done"
 declare -gr CTRL_START_SCRIPT="/tmp/shared/start"
 declare -gr CTRL_SUITE_LIST="/tmp/shared/suite.list"
+declare -gr CTRL_SHARD="/tmp/shared/shard"
 declare -gr CMD_OTESTS="/usr/bin/openshift-tests"
+# plugin binary shared by the plugin container, used to upload the artifacts.
+declare -gr CMD_PLUGIN="/tmp/shared/openshift-tests-plugin"
 
 echo "Starting entrypoint tests..."
 
@@ -40,6 +43,18 @@ function handle_error() {
 }
 trap handle_error ERR
 
+# wait_plugin_binary waits the plugin binary shared by the plugin container.
+function wait_plugin_binary() {
+    for _ in $(seq 1 30); do
+        if [[ -x "${CMD_PLUGIN}" ]]; then
+            return 0
+        fi
+        echo "$(date) waiting for plugin binary [${CMD_PLUGIN}]..."
+        sleep 2
+    done
+    return 1
+}
+
 /usr/bin/oc login "${KUBE_API_URL}" \
     --token="$(cat "${SA_TOKEN_PATH}")" \
     --certificate-authority="${SA_CA_PATH}";
@@ -47,9 +62,7 @@ trap handle_error ERR
 # Extracting the suite list for each plugin (--dry-run).
 # - openshift-tests-replay: skip
 # - openshift-cluster-upgrade: gather suite list for upgrade plugin
-# - openshift-kube-conformance: check if we have extracted k8s conformance tests from OTE (later 4.20 releases).
-#   If yes, use the extracted tests, otherwise use the default suite.
-# - other plugins: gather suite list for plugin
+# - other plugins: discover the suite and the suite list for plugin (exec discover-suite)
 if [[ "${PLUGIN_NAME:-}" == "openshift-tests-replay" ]];
 then
     echo "Skipping suite list for plugin ${PLUGIN_NAME:-}"
@@ -63,80 +76,16 @@ elif [[ "${PLUGIN_NAME:-}" == "openshift-cluster-upgrade" ]] && [[ "${RUN_MODE:-
         --dry-run -o ${CTRL_SUITE_LIST}
 
 elif [[ "${PLUGIN_NAME:-}" != "openshift-cluster-upgrade" ]]; then
-    # Check if the init container reported an error
-    INIT_ERROR_FILE="/tmp/shared/init-error.log"
-    if [[ "${PLUGIN_NAME:-}" == "openshift-kube-conformance" ]] && [[ -f "${INIT_ERROR_FILE}" ]]; then
-        INIT_ERR=$(<"${INIT_ERROR_FILE}")
-        # Normalize to single line for suite list and FIFO consumers
-        INIT_ERR_LINE=${INIT_ERR//$'\n'/ }
-        echo "ERROR: Init container failed to extract conformance tests:"
-        echo "${INIT_ERR}"
-        # Escape XML special characters in error message
-        INIT_ERR_XML=$(echo "${INIT_ERR}" | sed 's/&/\&amp;/g; s/</\&lt;/g; s/>/\&gt;/g; s/"/\&quot;/g')
-        # Write error as suite list entry so plugin reports it
-        printf '"[opct] init-error: %s"\n' "${INIT_ERR_LINE}" > "${CTRL_SUITE_LIST}"
-        touch ${CTRL_SUITE_LIST}.done
-        # Create a JUnit XML so the plugin can process results without crashing
-        mkdir -p /tmp/shared/junit
-        cat > /tmp/shared/junit/junit_e2e_init-error.xml <<JUNITEOF
-<?xml version="1.0" encoding="UTF-8"?>
-<testsuite name="openshift-kube-conformance" tests="1" failures="1" errors="0" time="0">
-  <testcase name="[opct] kube-conformance init container" time="0">
-    <failure message="Init container failed to extract conformance tests">${INIT_ERR_XML}</failure>
-  </testcase>
-</testsuite>
-JUNITEOF
-        # Wait for FIFO to be created by plugin, then write failed result
-        for attempt in $(seq 1 30); do
-            if [[ -p /tmp/shared/fifo ]]; then
-                echo "FIFO ready (attempt ${attempt}), writing error result..."
-                fifo_msg="failed: (0s) $(date -u +%Y-%m-%dT%H:%M:%S) \"[opct] kube-conformance init container error: ${INIT_ERR_LINE}\""
-                echo "${fifo_msg}" > /tmp/shared/fifo-msg.txt
-                if timeout 5s sh -c "cat /tmp/shared/fifo-msg.txt > /tmp/shared/fifo"; then
-                    echo "FIFO write completed."
-                else
-                    echo "Warning: timed out writing init error to FIFO; continuing with JUnit-only failure reporting."
-                fi
-                break
-            fi
-            sleep 1
-        done
-        touch ${CTRL_DONE_TESTS}
-        # Wait for plugin done with timeout (max 30 minutes)
-        max_wait=180
-        wait_count=0
-        while [[ ${wait_count} -lt ${max_wait} ]]; do
-            if [[ -f ${CTRL_DONE_PLUGIN} ]]; then
-                echo "Plugin done detected, exiting."
-                exit 0
-            fi
-            wait_count=$((wait_count + 1))
-            if (( wait_count % 6 == 0 )); then
-                echo "Waiting for plugin done [${CTRL_DONE_PLUGIN}] (${wait_count}/${max_wait})..."
-            fi
-            sleep 10
-        done
-        echo "Timeout waiting for plugin done after $((max_wait * 10))s, exiting."
-        exit 1
-    fi
-
-    # For 10-openshift-kube-conformance plugin, check if the init container extracted
-    # the conformance test list. If so, use it as the suite list for both progress
-    # tracking and test execution (via -f flag).
-    K8S_CONFORMANCE_LIST="/tmp/shared/k8s-conformance-tests.list"
-    if [[ "${PLUGIN_NAME:-}" == "openshift-kube-conformance" ]] && [[ -f "${K8S_CONFORMANCE_LIST}" ]]; then
-        TEST_COUNT=$(wc -l < "${K8S_CONFORMANCE_LIST}")
-        if [[ $TEST_COUNT -gt 0 ]]; then
-            echo "Using extracted Kubernetes conformance tests (${TEST_COUNT} tests)"
-            cp "${K8S_CONFORMANCE_LIST}" "${CTRL_SUITE_LIST}"
-            echo "Tests extracted from openshift-tests binary" > ${CTRL_SUITE_LIST}.log
-        else
-            echo "Warning: Extracted test list is empty, falling back to default suite"
-            # shellcheck disable=SC2086
-            ${CMD_OTESTS} ${OT_RUN_COMMAND:-run} ${SUITE_NAME:-${DEFAULT_SUITE_NAME-}} --dry-run -o ${CTRL_SUITE_LIST} >${CTRL_SUITE_LIST}.log
-        fi
+    # The suite is discovered by the plugin binary from openshift-tests and the extension
+    # binaries, selecting the suite of the cluster version. Discovery errors, including the
+    # init container errors, are reported by the plugin in the JUnit.
+    if wait_plugin_binary; then
+        echo "Discovering suite for plugin ${PLUGIN_NAME:-} (log: ${CTRL_SUITE_LIST}.log)"
+        KUBECONFIG="${KUBECONFIG}" "${CMD_PLUGIN}" exec discover-suite --plugin "${PLUGIN_NAME:-}" >${CTRL_SUITE_LIST}.log 2>&1 \
+            || echo "Suite discovery failed for plugin ${PLUGIN_NAME:-}, errors will be reported by the plugin. Read ${CTRL_SUITE_LIST}.log for details."
+        touch ${CTRL_SUITE_LIST}
     else
-        echo "Gathering suite list for plugin ${PLUGIN_NAME:-} (stdin is redirected to ${CTRL_SUITE_LIST}.log)"
+        echo "Plugin binary not found, gathering suite list for plugin ${PLUGIN_NAME:-} (stdin is redirected to ${CTRL_SUITE_LIST}.log)"
         # shellcheck disable=SC2086
         ${CMD_OTESTS} ${OT_RUN_COMMAND:-run} ${SUITE_NAME:-${DEFAULT_SUITE_NAME-}} --dry-run -o ${CTRL_SUITE_LIST} >${CTRL_SUITE_LIST}.log
     fi
@@ -144,7 +93,14 @@ else
     echo "Skipping suite list for plugin ${PLUGIN_NAME:-}"
     touch ${CTRL_SUITE_LIST}
 fi
-echo "${SUITE_NAME:-${DEFAULT_SUITE_NAME-}}" > /tmp/shared/suite.name
+# suite.name is written by the suite discovery.
+[[ -s /tmp/shared/suite.name ]] || echo "${SUITE_NAME:-${DEFAULT_SUITE_NAME-}}" > /tmp/shared/suite.name
+
+# disconnected environments: the images required by the tests are validated in the mirror by the plugin.
+if [[ -n "${MIRROR_IMAGE_REPOSITORY:-}" ]]; then
+    echo "Gathering images required by openshift-tests for mirror ${MIRROR_IMAGE_REPOSITORY} (log: /tmp/shared/images.mapping.log)"
+    ${CMD_OTESTS} images --to-repository "${MIRROR_IMAGE_REPOSITORY}" >/tmp/shared/images.mapping 2>/tmp/shared/images.mapping.log || true
+fi
 touch ${CTRL_SUITE_LIST}.done
 
 echo "#> setting up cloud provider..."
@@ -164,23 +120,23 @@ do
     echo "$(date) ${msg}";
     sleep 10;
 done
-echo -e "\n\n\t>> Copying e2e artifacts to collector plugin..."
+echo -e "\n\n\t>> Uploading e2e artifacts to collector plugin..."
 {
-    echo -e ">> Discoverying artifacts pod..."
-    COLLECTOR_POD=$(oc get pods -n opct -l sonobuoy-plugin=99-openshift-artifacts-collector -o jsonpath='{.items[*].metadata.name}')
-
-    suite_file="artifacts_e2e-tests_${PLUGIN_NAME-}.txt"
-    echo -e ">> Copying e2e suite list metadata..."
-    oc cp -c plugin "${CTRL_SUITE_LIST}" opct/"${COLLECTOR_POD}":/tmp/sonobuoy/results/"${suite_file}" || true
+    # sharded plugins must be suffixed by shard index to not override artifacts from other shards.
+    # The shard suffix is written by the plugin, which reads the shard from the config file, env or flags.
+    artifact_suffix="$(cat "${CTRL_SHARD}" 2>/dev/null || true)"
+    suite_file="artifacts_e2e-tests_${PLUGIN_NAME-}${artifact_suffix}.txt"
+    echo -e ">> Uploading e2e suite list metadata..."
+    "${CMD_PLUGIN}" exec upload-artifact --file "${CTRL_SUITE_LIST}" --name "${suite_file}" || true
 
     echo -e ">> Preparing e2e metatada..."
     # must set the filename prefix artifacts_
-    e2e_artifact_name="artifacts_e2e-metadata-${PLUGIN_NAME:-}.tar.gz"
+    e2e_artifact_name="artifacts_e2e-metadata-${PLUGIN_NAME:-}${artifact_suffix}.tar.gz"
     e2e_artifact="/tmp/${e2e_artifact_name}"
-    tar cfzv "${e2e_artifact}"  /tmp/shared/junit/* || true
+    tar cfzv "${e2e_artifact}"  /tmp/shared/junit/* /tmp/shared/done.json /tmp/shared/suite.name || true
 
-    echo -e ">> Copying e2e metadata ${e2e_artifact_name}..."
-    oc cp -c plugin "${e2e_artifact}" opct/"${COLLECTOR_POD}":/tmp/sonobuoy/results/"${e2e_artifact_name}" || true
+    echo -e ">> Uploading e2e metadata ${e2e_artifact_name}..."
+    "${CMD_PLUGIN}" exec upload-artifact --file "${e2e_artifact}" --name "${e2e_artifact_name}" || true
 
 } || true
 touch ${CTRL_DONE_TESTS}
@@ -0,0 +1,29 @@
+#!/usr/bin/env bash
+started_at="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+/usr/bin/openshift-tests run openshift/conformance \
+  --junit-dir=/tmp/shared/junit \
+  --max-parallel-tests=0 \
+  --monitor=etcd-log-analyzer \
+  '--provider={"type":"aws","region":"us-east-1","zone":"us-east-1a","multizone":true}' \
+  --cluster-stability=Disruptive \
+  --shard-count=4 \
+  --dry-run \
+  2> >(tee /tmp/shared/stderr.log >&2) \
+  | tee -a /tmp/shared/fifo
+exit_code="${PIPESTATUS[0]}"
+signal=""
+if [[ ${exit_code} -gt 128 ]]; then
+  signal="SIG$(kill -l $((exit_code - 128)) 2>/dev/null || true)"
+fi
+jq -n \
+  --argjson exitCode "${exit_code}" \
+  --arg signal "${signal}" \
+  --arg startedAt "${started_at}" \
+  --arg finishedAt "$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+  --argjson command '["/usr/bin/openshift-tests","run","openshift/conformance","--junit-dir=/tmp/shared/junit","--max-parallel-tests=0","--monitor=etcd-log-analyzer","--provider={\"type\":\"aws\",\"region\":\"us-east-1\",\"zone\":\"us-east-1a\",\"multizone\":true}","--cluster-stability=Disruptive","--shard-count=4","--dry-run"]' \
+  --arg stderr "$(tail -n 50 /tmp/shared/stderr.log 2>/dev/null)" \
+  '{exitCode: $exitCode, startedAt: $startedAt, finishedAt: $finishedAt, command: $command}
+   + (if $signal != "" then {signal: $signal} else {} end)
+   + (if $stderr != "" then {stderr: ($stderr | split("\n"))} else {} end)' \
+  > /tmp/shared/done.json || true
+exit 0
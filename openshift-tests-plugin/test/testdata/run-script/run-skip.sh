@@ -0,0 +1,2 @@
+#!/usr/bin/env bash
+echo 'skipped: (0.0s) 2024-07-05T20:27:49 "[opct] openshift-tests runner"' | tee -a /tmp/shared/fifo || true